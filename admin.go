@@ -1,11 +1,23 @@
 package main
 
 import (
+	"chirpy/internal/auth"
+	"crypto/subtle"
 	"fmt"
 
 	"net/http"
 )
 
+// isAdmin reports whether the request carries the ADMIN_KEY as its ApiKey.
+// Admin access is off when no key is configured.
+func (cfg *apiConfig) isAdmin(r *http.Request) bool {
+	if cfg.adminKey == "" {
+		return false
+	}
+	apiKey, err := auth.GetAPIKey(r.Header)
+	return err == nil && subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.adminKey)) == 1
+}
+
 func (cfg *apiConfig) fileserverResetHandler(w http.ResponseWriter, r *http.Request) {
 	if cfg.platform != "dev" {
 		w.WriteHeader(403)
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"chirpy/internal/auth"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// requireUser validates the bearer JWT and returns its subject. On failure it
+// writes the 401 response itself and reports false.
+func (cfg *apiConfig) requireUser(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return uuid.UUID{}, false
+	}
+	userID, err := cfg.keys.ValidateJWT(token)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return uuid.UUID{}, false
+	}
+	return userID, true
+}
+
+// requireSession is requireUser for endpoints that also need the session
+// the access token was issued under. Tokens issued without one give
+// uuid.Nil.
+func (cfg *apiConfig) requireSession(w http.ResponseWriter, r *http.Request) (userID, sessionID uuid.UUID, ok bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+	claims, err := cfg.keys.ParseJWT(token)
+	if err == nil {
+		userID, err = uuid.Parse(claims.Subject)
+	}
+	if err == nil && claims.SessionID != "" {
+		sessionID, err = uuid.Parse(claims.SessionID)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return uuid.UUID{}, uuid.UUID{}, false
+	}
+	return userID, sessionID, true
+}
+
+// optionalUser returns the caller's ID when the request carries a valid
+// bearer JWT. Public endpoints use it to personalise responses; a missing or
+// invalid token just means an anonymous caller.
+func (cfg *apiConfig) optionalUser(r *http.Request) (uuid.UUID, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	userID, err := cfg.keys.ValidateJWT(token)
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	return userID, true
+}
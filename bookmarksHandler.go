@@ -0,0 +1,347 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/textlen"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxCollectionNameLength is in user-perceived characters.
+const maxCollectionNameLength = 50
+
+type BookmarkCollection struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	BookmarkCount int64     `json:"bookmark_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func collectionFromModel(m database.BookmarkCollection, count int64) BookmarkCollection {
+	return BookmarkCollection{
+		ID:            m.ID,
+		Name:          m.Name,
+		BookmarkCount: count,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+	}
+}
+
+// bookmarkChirpHandler bookmarks a chirp for the caller. The body may name
+// one of the caller's collections in collection_id; bookmarking a chirp
+// again moves it to that collection, or out of any without one.
+func (cfg *apiConfig) bookmarkChirpHandler(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		CollectionID *uuid.UUID `json:"collection_id"`
+	}
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	chirpID, ok := cfg.likeTarget(w, r)
+	if !ok {
+		return
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil && err != io.EOF {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	arg := database.BookmarkChirpParams{UserID: userID, ChirpID: chirpID}
+	if params.CollectionID != nil {
+		arg.CollectionID = uuid.NullUUID{UUID: *params.CollectionID, Valid: true}
+	}
+	n, err := cfg.db.BookmarkChirp(r.Context(), arg)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't bookmark chirp", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Collection not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unbookmarkChirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+
+	_, err = cfg.db.UnbookmarkChirp(r.Context(), database.UnbookmarkChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't remove bookmark", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getBookmarksHandler lists the caller's bookmarks, most recent first.
+func (cfg *apiConfig) getBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	cfg.listBookmarks(w, r, userID, uuid.NullUUID{})
+}
+
+// getCollectionBookmarksHandler lists the bookmarks filed in one of the
+// caller's collections, most recent first.
+func (cfg *apiConfig) getCollectionBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	collection, ok := cfg.ownCollection(w, r, userID)
+	if !ok {
+		return
+	}
+	cfg.listBookmarks(w, r, userID, uuid.NullUUID{UUID: collection.ID, Valid: true})
+}
+
+func (cfg *apiConfig) listBookmarks(w http.ResponseWriter, r *http.Request, userID uuid.UUID, collectionID uuid.NullUUID) {
+	page, err := parsePageParams(r.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if !page.Desc {
+		respondWithError(w, http.StatusBadRequest, "only sort=desc is supported", nil)
+		return
+	}
+
+	rows, err := cfg.db.ListBookmarks(r.Context(), database.ListBookmarksParams{
+		UserID:          userID,
+		CollectionID:    collectionID,
+		CursorCreatedAt: page.cursorTime(),
+		CursorID:        page.cursorID(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching bookmarks", err)
+		return
+	}
+
+	rows, next := trimPage(page, rows, func(row database.ListBookmarksRow) pageCursor {
+		return pageCursor{CreatedAt: row.BookmarkedAt, ID: row.Chirp.ID}
+	})
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	apiChirps, err := cfg.presentChirps(r, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	setLinkHeader(w, r, next)
+	respondWithJSON(w, http.StatusOK, chirpsPage{Chirps: apiChirps, NextCursor: next})
+}
+
+// ownCollection loads the collectionID path value, which must be one of
+// userID's collections. Other users' collections are reported as missing.
+// It writes the error response itself and reports false on failure.
+func (cfg *apiConfig) ownCollection(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.BookmarkCollection, bool) {
+	id, err := uuid.Parse(r.PathValue("collectionID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid collection ID", err)
+		return database.BookmarkCollection{}, false
+	}
+	collection, err := cfg.db.GetBookmarkCollection(r.Context(), database.GetBookmarkCollectionParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Collection not found", err)
+		return database.BookmarkCollection{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't fetch collection", err)
+		return database.BookmarkCollection{}, false
+	}
+	return collection, true
+}
+
+// collectionName decodes and checks the name in a create or rename request.
+func collectionName(r *http.Request) (string, error) {
+	var params struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return "", errors.New("Couldn't decode parameters")
+	}
+	name := strings.TrimSpace(params.Name)
+	if name == "" || textlen.Graphemes(name) > maxCollectionNameLength {
+		return "", fmt.Errorf("Collection names must be 1 to %d characters", maxCollectionNameLength)
+	}
+	return name, nil
+}
+
+func (cfg *apiConfig) createCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	name, err := collectionName(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	collection, err := cfg.db.CreateBookmarkCollection(r.Context(), database.CreateBookmarkCollectionParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You already have a collection with that name", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create collection", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, collectionFromModel(collection, 0))
+}
+
+// getCollectionsHandler lists the caller's collections by name.
+func (cfg *apiConfig) getCollectionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	rows, err := cfg.db.ListBookmarkCollections(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching collections", err)
+		return
+	}
+	collections := make([]BookmarkCollection, 0, len(rows))
+	for _, row := range rows {
+		collections = append(collections, collectionFromModel(row.BookmarkCollection, row.BookmarkCount))
+	}
+	respondWithJSON(w, http.StatusOK, collections)
+}
+
+func (cfg *apiConfig) renameCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("collectionID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid collection ID", err)
+		return
+	}
+	name, err := collectionName(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	collection, err := cfg.db.RenameBookmarkCollection(r.Context(), database.RenameBookmarkCollectionParams{
+		Name:   name,
+		ID:     id,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Collection not found", err)
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You already have a collection with that name", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rename collection", err)
+		return
+	}
+	rows, err := cfg.db.ListBookmarkCollections(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching collections", err)
+		return
+	}
+	var count int64
+	for _, row := range rows {
+		if row.BookmarkCollection.ID == collection.ID {
+			count = row.BookmarkCount
+		}
+	}
+	respondWithJSON(w, http.StatusOK, collectionFromModel(collection, count))
+}
+
+// deleteCollectionHandler deletes one of the caller's collections. The
+// bookmarks in it are kept, unfiled.
+func (cfg *apiConfig) deleteCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("collectionID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid collection ID", err)
+		return
+	}
+
+	n, err := cfg.db.DeleteBookmarkCollection(r.Context(), database.DeleteBookmarkCollectionParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete collection", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Collection not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) setBookmarkedByMe(ctx context.Context, viewerID uuid.UUID, chirps []*Chirp) error {
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, c := range chirps {
+		if !c.Deleted {
+			ids = append(ids, c.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	bookmarkedIDs, err := cfg.db.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+		UserID:   viewerID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return err
+	}
+	bookmarked := make(map[uuid.UUID]bool, len(bookmarkedIDs))
+	for _, id := range bookmarkedIDs {
+		bookmarked[id] = true
+	}
+	for _, c := range chirps {
+		if !c.Deleted {
+			bookmarkedByMe := bookmarked[c.ID]
+			c.BookmarkedByMe = &bookmarkedByMe
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestBookmarks(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var first, second Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "save me"}, &first)
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "me too"}, &second)
+
+	for _, c := range []Chirp{first, second} {
+		if code := doJSON(t, srv, "POST", "/api/chirps/"+c.ID.String()+"/bookmark", bob.Token, nil, nil); code != http.StatusNoContent {
+			t.Fatalf("POST bookmark: got status %d", code)
+		}
+	}
+	if code := doJSON(t, srv, "POST", "/api/chirps/"+first.ID.String()+"/bookmark", "", nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("anonymous bookmark: got status %d", code)
+	}
+
+	var page chirpsPage
+	doJSON(t, srv, "GET", "/api/bookmarks?limit=1", bob.Token, nil, &page)
+	if len(page.Chirps) != 1 || page.Chirps[0].ID != second.ID || page.NextCursor == "" {
+		t.Fatalf("first page: %+v", page)
+	}
+	if b := page.Chirps[0].BookmarkedByMe; b == nil || !*b {
+		t.Errorf("bookmarked_by_me = %v", b)
+	}
+	doJSON(t, srv, "GET", "/api/bookmarks?limit=1&cursor="+page.NextCursor, bob.Token, nil, &page)
+	if len(page.Chirps) != 1 || page.Chirps[0].ID != first.ID {
+		t.Fatalf("second page: %+v", page)
+	}
+
+	// Bookmarks are private.
+	doJSON(t, srv, "GET", "/api/bookmarks", alice.Token, nil, &page)
+	if len(page.Chirps) != 0 {
+		t.Errorf("alice sees bob's bookmarks: %+v", page.Chirps)
+	}
+	var got Chirp
+	doJSON(t, srv, "GET", "/api/chirps/"+first.ID.String(), alice.Token, nil, &got)
+	if got.BookmarkedByMe == nil || *got.BookmarkedByMe {
+		t.Errorf("alice sees bookmarked_by_me=%v", got.BookmarkedByMe)
+	}
+	var anon Chirp
+	doJSON(t, srv, "GET", "/api/chirps/"+first.ID.String(), "", nil, &anon)
+	if anon.BookmarkedByMe != nil {
+		t.Error("anonymous caller got bookmarked_by_me")
+	}
+
+	doJSON(t, srv, "DELETE", "/api/chirps/"+second.ID.String()+"/bookmark", bob.Token, nil, nil)
+	doJSON(t, srv, "GET", "/api/bookmarks", bob.Token, nil, &page)
+	if len(page.Chirps) != 1 || page.Chirps[0].ID != first.ID {
+		t.Errorf("after removing a bookmark: %+v", page.Chirps)
+	}
+
+	// Deleted chirps drop out of the list.
+	doJSON(t, srv, "DELETE", "/api/chirps/"+first.ID.String(), alice.Token, nil, nil)
+	doJSON(t, srv, "GET", "/api/bookmarks", bob.Token, nil, &page)
+	if len(page.Chirps) != 0 {
+		t.Errorf("deleted chirp still bookmarked: %+v", page.Chirps)
+	}
+	if code := doJSON(t, srv, "POST", "/api/chirps/"+first.ID.String()+"/bookmark", bob.Token, nil, nil); code != http.StatusNotFound {
+		t.Errorf("bookmarking a deleted chirp: got status %d", code)
+	}
+}
+
+func TestBookmarkCollections(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var chirp, other Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "recipe"}, &chirp)
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "unfiled"}, &other)
+
+	var reading BookmarkCollection
+	if code := doJSON(t, srv, "POST", "/api/collections", bob.Token, map[string]string{"name": " Reading "}, &reading); code != http.StatusCreated {
+		t.Fatalf("POST collection: got status %d", code)
+	}
+	if reading.Name != "Reading" {
+		t.Errorf("name = %q, want it trimmed", reading.Name)
+	}
+	if code := doJSON(t, srv, "POST", "/api/collections", bob.Token, map[string]string{"name": "Reading"}, nil); code != http.StatusConflict {
+		t.Errorf("duplicate name: got status %d", code)
+	}
+	if code := doJSON(t, srv, "POST", "/api/collections", bob.Token, map[string]string{"name": "  "}, nil); code != http.StatusBadRequest {
+		t.Errorf("blank name: got status %d", code)
+	}
+	// Names are per user.
+	var alices BookmarkCollection
+	if code := doJSON(t, srv, "POST", "/api/collections", alice.Token, map[string]string{"name": "Reading"}, &alices); code != http.StatusCreated {
+		t.Fatalf("alice's collection: got status %d", code)
+	}
+
+	bookmark := "/api/chirps/" + chirp.ID.String() + "/bookmark"
+	if code := doJSON(t, srv, "POST", bookmark, bob.Token, map[string]string{"collection_id": alices.ID.String()}, nil); code != http.StatusNotFound {
+		t.Errorf("filing in someone else's collection: got status %d", code)
+	}
+	if code := doJSON(t, srv, "POST", bookmark, bob.Token, map[string]string{"collection_id": reading.ID.String()}, nil); code != http.StatusNoContent {
+		t.Fatalf("filing a bookmark: got status %d", code)
+	}
+	doJSON(t, srv, "POST", "/api/chirps/"+other.ID.String()+"/bookmark", bob.Token, nil, nil)
+
+	collectionPath := "/api/collections/" + reading.ID.String()
+	var page chirpsPage
+	doJSON(t, srv, "GET", collectionPath+"/bookmarks", bob.Token, nil, &page)
+	if len(page.Chirps) != 1 || page.Chirps[0].ID != chirp.ID {
+		t.Errorf("collection bookmarks: %+v", page.Chirps)
+	}
+	doJSON(t, srv, "GET", "/api/bookmarks", bob.Token, nil, &page)
+	if len(page.Chirps) != 2 {
+		t.Errorf("all bookmarks: %+v", page.Chirps)
+	}
+	if code := doJSON(t, srv, "GET", collectionPath+"/bookmarks", alice.Token, nil, nil); code != http.StatusNotFound {
+		t.Errorf("reading someone else's collection: got status %d", code)
+	}
+
+	var renamed BookmarkCollection
+	if code := doJSON(t, srv, "PUT", collectionPath, bob.Token, map[string]string{"name": "Later"}, &renamed); code != http.StatusOK {
+		t.Fatalf("rename: got status %d", code)
+	}
+	if renamed.Name != "Later" || renamed.BookmarkCount != 1 {
+		t.Errorf("renamed: %+v", renamed)
+	}
+	if code := doJSON(t, srv, "PUT", collectionPath, alice.Token, map[string]string{"name": "Mine"}, nil); code != http.StatusNotFound {
+		t.Errorf("renaming someone else's collection: got status %d", code)
+	}
+
+	var collections []BookmarkCollection
+	doJSON(t, srv, "GET", "/api/collections", bob.Token, nil, &collections)
+	if len(collections) != 1 || collections[0].ID != reading.ID || collections[0].BookmarkCount != 1 {
+		t.Errorf("collections: %+v", collections)
+	}
+
+	// Deleting a collection keeps its bookmarks.
+	if code := doJSON(t, srv, "DELETE", collectionPath, alice.Token, nil, nil); code != http.StatusNotFound {
+		t.Errorf("deleting someone else's collection: got status %d", code)
+	}
+	if code := doJSON(t, srv, "DELETE", collectionPath, bob.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete: got status %d", code)
+	}
+	doJSON(t, srv, "GET", "/api/bookmarks", bob.Token, nil, &page)
+	if len(page.Chirps) != 2 {
+		t.Errorf("bookmarks after deleting the collection: %+v", page.Chirps)
+	}
+	doJSON(t, srv, "GET", "/api/collections", bob.Token, nil, &collections)
+	if len(collections) != 0 {
+		t.Errorf("collections after delete: %+v", collections)
+	}
+}
+
+func TestPurgeDeletedChirpBookmarks(t *testing.T) {
+	var cfg *apiConfig
+	srv := newTestServerWithConfig(t, func(c *apiConfig) { cfg = c })
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var chirp Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "gone soon"}, &chirp)
+	doJSON(t, srv, "POST", "/api/chirps/"+chirp.ID.String()+"/bookmark", bob.Token, nil, nil)
+	doJSON(t, srv, "DELETE", "/api/chirps/"+chirp.ID.String(), alice.Token, nil, nil)
+
+	ctx := context.Background()
+	if _, _, err := cfg.purgeDeleted(ctx, time.Now().Add(cfg.retention+time.Minute)); err != nil {
+		t.Fatalf("purgeDeleted returned error: %v", err)
+	}
+	ids, err := cfg.db.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+		UserID:   bob.ID,
+		ChirpIds: []uuid.UUID{chirp.ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 0 {
+		t.Error("bookmark of an expired chirp was not removed")
+	}
+}
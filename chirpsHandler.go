@@ -3,29 +3,141 @@ package main
 import (
 	"chirpy/internal/auth"
 	"chirpy/internal/database"
+	"chirpy/internal/moderation"
+	"chirpy/internal/textlen"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
-
-	//"fmt"
+	"fmt"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserId    uuid.UUID `json:"user_id"`
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Body       string     `json:"body"`
+	UserId     uuid.UUID  `json:"user_id"`
+	InReplyTo  *uuid.UUID `json:"in_reply_to,omitempty"`
+	RootID     *uuid.UUID `json:"root_id,omitempty"`
+	ReplyCount int32      `json:"reply_count"`
+	LikeCount  int32      `json:"like_count"`
+	LikedByMe  *bool      `json:"liked_by_me,omitempty"`
+	// BookmarkedByMe is only set for the signed-in caller; bookmarks are
+	// never shown to anyone else.
+	BookmarkedByMe *bool      `json:"bookmarked_by_me,omitempty"`
+	RechirpOfID    *uuid.UUID `json:"rechirp_of_id,omitempty"`
+	RechirpOf      *Chirp     `json:"rechirp_of,omitempty"`
+	QuoteOfID      *uuid.UUID `json:"quote_of_id,omitempty"`
+	QuoteOf        *Chirp     `json:"quote_of,omitempty"`
+	Deleted        bool       `json:"deleted,omitempty"`
+	Edited         bool       `json:"edited"`
+	Entities       *Entities  `json:"entities,omitempty"`
+	Media          []Media    `json:"media,omitempty"`
+	Poll           *Poll      `json:"poll,omitempty"`
+	// Pinned marks the author's pinned chirps put ahead of the first page
+	// of their chirps when pinned_first is set.
+	Pinned bool `json:"pinned,omitempty"`
+	// Moderation lists the moderation rules the body tripped. It is only
+	// reported to the author, on the response to posting or editing.
+	Moderation []moderation.Hit `json:"moderation,omitempty"`
+}
+
+// chirpFromModel converts a row to its API shape. Deleted chirps that are
+// kept as thread tombstones come back with an empty body; the row keeps it
+// until the purger runs so the chirp can still be restored.
+func chirpFromModel(m database.Chirp) Chirp {
+	body := m.Body
+	if m.DeletedAt.Valid {
+		body = ""
+	}
+	return Chirp{
+		ID:          m.ID,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+		Body:        body,
+		UserId:      m.UserID,
+		InReplyTo:   nullUUIDPtr(m.ParentID),
+		RootID:      nullUUIDPtr(m.RootID),
+		ReplyCount:  m.ReplyCount,
+		LikeCount:   m.LikeCount,
+		RechirpOfID: nullUUIDPtr(m.RechirpOfID),
+		QuoteOfID:   nullUUIDPtr(m.QuoteOfID),
+		Deleted:     m.DeletedAt.Valid,
+		Edited:      m.EditedAt.Valid,
+	}
+}
+
+// tombstoneChirp stands in for a referenced chirp that has been deleted.
+func tombstoneChirp(id uuid.UUID) Chirp {
+	return Chirp{ID: id, Deleted: true}
+}
+
+func nullUUIDPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
+
+// Chirp length limits, in user-perceived characters with links counted as
+// textlen.URLWeight. Chirpy Red members get the longer limit.
+const (
+	maxChirpLength    = 140
+	maxRedChirpLength = 280
+)
+
+func chirpLengthLimit(user database.User) int {
+	if user.IsChirpyRed {
+		return maxRedChirpLength
+	}
+	return maxChirpLength
+}
+
+// checkChirpLength measures body against the limit for the author's tier.
+// A body that is too long gets a 400 stating the limit and the length it
+// was counted at, and ok is false.
+func (cfg *apiConfig) checkChirpLength(w http.ResponseWriter, r *http.Request, authorID uuid.UUID, body string) bool {
+	author, err := cfg.db.GetUserByID(r.Context(), authorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "User not found", err)
+		return false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't fetch user", err)
+		return false
+	}
+
+	limit, length := chirpLengthLimit(author), textlen.Weighted(body)
+	if length > limit {
+		type tooLong struct {
+			Error  string `json:"error"`
+			Limit  int    `json:"limit"`
+			Length int    `json:"length"`
+		}
+		respondWithJSON(w, http.StatusBadRequest, tooLong{
+			Error:  fmt.Sprintf("Chirp is too long: %d characters, the limit is %d", length, limit),
+			Limit:  limit,
+			Length: length,
+		})
+		return false
+	}
+	return true
 }
 
 func (cfg *apiConfig) postChirpsHandler(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body      string      `json:"body"`
+		InReplyTo *uuid.UUID  `json:"in_reply_to"`
+		RechirpOf *uuid.UUID  `json:"rechirp_of"`
+		QuoteOf   *uuid.UUID  `json:"quote_of"`
+		MediaIDs  []uuid.UUID `json:"media_ids"`
+		Poll      *pollParams `json:"poll"`
 	}
 
 	token, err := auth.GetBearerToken(r.Header)
@@ -33,7 +145,7 @@ func (cfg *apiConfig) postChirpsHandler(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
 		return
 	}
-	userID, err := auth.ValidateJWT(token, cfg.secret)
+	userID, err := cfg.keys.ValidateJWT(token)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
 		return
@@ -47,157 +159,458 @@ func (cfg *apiConfig) postChirpsHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	const maxChirpLength = 140
-	if len(params.Body) > maxChirpLength {
-		respondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
+	if params.RechirpOf != nil && (params.Body != "" || params.InReplyTo != nil || params.QuoteOf != nil || len(params.MediaIDs) > 0 || params.Poll != nil) {
+		respondWithError(w, http.StatusBadRequest, "A rechirp can't have a body, reply, quote, media or poll", nil)
+		return
+	}
+	if err := validateMediaIDs(params.MediaIDs); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	badWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
+	if !cfg.checkChirpLength(w, r, userID, params.Body) {
+		return
+	}
+	moderated, ok := cfg.moderateBody(w, params.Body)
+	if !ok {
+		return
+	}
+	cleaned := moderated.Text
+
+	var poll *database.CreatePollParams
+	var pollModeration []moderation.Result
+	if params.Poll != nil {
+		p, results, ok := cfg.preparePoll(w, *params.Poll)
+		if !ok {
+			return
+		}
+		poll, pollModeration = &p, results
+	}
+
+	var parentID, rootID uuid.NullUUID
+	if params.InReplyTo != nil {
+		parent, err := referencedChirp(r.Context(), cfg.db, *params.InReplyTo)
+		if err != nil {
+			respondWithReferenceError(w, "Parent chirp not found", err)
+			return
+		}
+		parentID, rootID = threadIDs(parent)
+	}
+
+	var rechirpOfID, quoteOfID uuid.NullUUID
+	if params.RechirpOf != nil {
+		original, err := referencedChirp(r.Context(), cfg.db, *params.RechirpOf)
+		if err != nil {
+			respondWithReferenceError(w, "Rechirped chirp not found", err)
+			return
+		}
+		rechirpOfID = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
+	if params.QuoteOf != nil {
+		original, err := referencedChirp(r.Context(), cfg.db, *params.QuoteOf)
+		if err != nil {
+			respondWithReferenceError(w, "Quoted chirp not found", err)
+			return
+		}
+		quoteOfID = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
+
+	mentions, err := resolveMentions(r.Context(), cfg.db, cleaned)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't resolve mentions", err)
+		return
 	}
-	cleaned := getCleanedBody(params.Body, badWords)
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   cleaned,
-		UserID: userID,
+	var chirp database.Chirp
+	err = cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		var err error
+		chirp, err = q.CreateChirp(r.Context(), database.CreateChirpParams{
+			Body:        cleaned,
+			UserID:      userID,
+			ParentID:    parentID,
+			RootID:      rootID,
+			RechirpOfID: rechirpOfID,
+			QuoteOfID:   quoteOfID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := saveChirpEntities(r.Context(), q, chirp, mentions); err != nil {
+			return err
+		}
+		if err := attachMedia(r.Context(), q, chirp, params.MediaIDs); err != nil {
+			return err
+		}
+		if poll != nil {
+			poll.ChirpID = chirp.ID
+			if _, err := q.CreatePoll(r.Context(), *poll); err != nil {
+				return err
+			}
+		}
+		return flagForReview(r.Context(), q, chirp.ID, append([]moderation.Result{moderated}, pollModeration...)...)
 	})
+	if errors.Is(err, errMediaUnavailable) {
+		respondWithError(w, http.StatusBadRequest, "Media not found or already attached", err)
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You already rechirped this chirp", err)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, Chirp{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserId:    chirp.UserID,
-	})
+	apiChirp, err := cfg.presentChirp(r, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	apiChirp.Moderation = moderated.Hits
+	respondWithJSON(w, http.StatusCreated, apiChirp)
 }
 
-func getCleanedBody(body string, badWords map[string]struct{}) string {
-	words := strings.Split(body, " ")
-	for i, word := range words {
-		loweredWord := strings.ToLower(word)
-		if _, ok := badWords[loweredWord]; ok {
-			words[i] = "****"
+// saveChirpEntities stores the hashtags and resolved mentions found in a
+// chirp's body.
+func saveChirpEntities(ctx context.Context, q database.Querier, chirp database.Chirp, mentions database.CreateChirpMentionsParams) error {
+	if tags := extractHashtags(chirp.Body); len(tags) > 0 {
+		err := q.TagChirp(ctx, database.TagChirpParams{
+			Names:     tags,
+			ChirpID:   chirp.ID,
+			CreatedAt: chirp.CreatedAt,
+		})
+		if err != nil {
+			return err
 		}
 	}
-	cleaned := strings.Join(words, " ")
-	return cleaned
+	if len(mentions.UserIds) == 0 {
+		return nil
+	}
+	mentions.ChirpID = chirp.ID
+	return q.CreateChirpMentions(ctx, mentions)
+}
+
+// referencedChirp loads a live chirp that a new chirp replies to, rechirps
+// or quotes. A rechirp stands in for its original, so references to one are
+// followed through. Missing and deleted chirps both yield sql.ErrNoRows.
+func referencedChirp(ctx context.Context, q database.Querier, id uuid.UUID) (database.Chirp, error) {
+	chirp, err := q.GetOneChirps(ctx, id)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if chirp.RechirpOfID.Valid {
+		return q.GetOneChirps(ctx, chirp.RechirpOfID.UUID)
+	}
+	return chirp, nil
+}
+
+// threadIDs returns the parent and root IDs of a reply to parent.
+func threadIDs(parent database.Chirp) (parentID, rootID uuid.NullUUID) {
+	parentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	rootID = parent.RootID
+	if !rootID.Valid {
+		rootID = parentID
+	}
+	return parentID, rootID
+}
+
+func respondWithReferenceError(w http.ResponseWriter, notFoundMsg string, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, notFoundMsg, err)
+		return
+	}
+	respondWithError(w, http.StatusInternalServerError, "Couldn't fetch referenced chirp", err)
+}
+
+type chirpsPage struct {
+	Chirps     []Chirp `json:"chirps"`
+	NextCursor string  `json:"next_cursor,omitempty"`
 }
 
 func (cfg *apiConfig) getAllChirpsHandler(w http.ResponseWriter, r *http.Request) {
-	authorID := r.URL.Query().Get("author_id")
-	var chirpsfromDB []database.Chirp
-	var err error
+	page, err := parsePageParams(r.URL.Query(), "asc")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
 
-	if authorID != "" {
-		id, parseErr := uuid.Parse(authorID)
+	var authorID uuid.NullUUID
+	if s := r.URL.Query().Get("author_id"); s != "" {
+		id, parseErr := uuid.Parse(s)
 		if parseErr != nil {
 			respondWithError(w, http.StatusBadRequest, "invalid author_id", parseErr)
 			return
 		}
-		chirpsfromDB, err = cfg.db.GetChirpsByID(r.Context(), id)
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	var pinnedFirst bool
+	if s := r.URL.Query().Get("pinned_first"); s != "" {
+		pinnedFirst, err = strconv.ParseBool(s)
 		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				w.WriteHeader(http.StatusNotFound)
-				return
-			}
-			respondWithError(w, http.StatusInternalServerError, "error fetching chirps", err)
+			respondWithError(w, http.StatusBadRequest, "invalid pinned_first", err)
+			return
+		}
+		if pinnedFirst && !authorID.Valid {
+			respondWithError(w, http.StatusBadRequest, "pinned_first requires author_id", nil)
 			return
 		}
+	}
+
+	var chirpsfromDB []database.Chirp
+	if page.Desc {
+		chirpsfromDB, err = cfg.db.ListChirpsDesc(r.Context(), database.ListChirpsDescParams{
+			AuthorID:        authorID,
+			CursorCreatedAt: page.cursorTime(),
+			CursorID:        page.cursorID(),
+			Limit:           page.queryLimit(),
+		})
 	} else {
-		chirpsfromDB, err = cfg.db.GetAllChirps(r.Context())
+		chirpsfromDB, err = cfg.db.ListChirpsAsc(r.Context(), database.ListChirpsAscParams{
+			AuthorID:        authorID,
+			CursorCreatedAt: page.cursorTime(),
+			CursorID:        page.cursorID(),
+			Limit:           page.queryLimit(),
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching chirps", err)
+		return
+	}
+
+	chirpsfromDB, next := trimPage(page, chirpsfromDB, chirpCursor)
+	// Pinned chirps lead the first page and also keep their place in the
+	// listing, so paging is unaffected.
+	var pinned []database.Chirp
+	if pinnedFirst && page.Cursor == nil {
+		pinned, err = cfg.db.ListPinnedChirps(r.Context(), authorID.UUID)
 		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "error fetching chirps", err)
+			respondWithError(w, http.StatusInternalServerError, "error fetching pinned chirps", err)
 			return
 		}
 	}
-	respondWithJSON(w, http.StatusOK, chirpModelsToAPIChirps(chirpsfromDB))
+	apiChirps, err := cfg.presentChirps(r, append(pinned, chirpsfromDB...))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	for i := range pinned {
+		apiChirps[i].Pinned = true
+	}
+	setLinkHeader(w, r, next)
+	respondWithJSON(w, http.StatusOK, chirpsPage{
+		Chirps:     apiChirps,
+		NextCursor: next,
+	})
+}
+
+func chirpCursor(c database.Chirp) pageCursor {
+	return pageCursor{CreatedAt: c.CreatedAt, ID: c.ID}
 }
 
 func chirpModelsToAPIChirps(models []database.Chirp) []Chirp {
 	apiChirps := make([]Chirp, 0, len(models))
 	for _, m := range models {
-		apiChirps = append(apiChirps, Chirp{
-			ID:        m.ID,
-			CreatedAt: m.CreatedAt,
-			UpdatedAt: m.UpdatedAt,
-			Body:      m.Body,
-			UserId:    m.UserID,
-		})
+		apiChirps = append(apiChirps, chirpFromModel(m))
 	}
 	return apiChirps
 }
 
+// presentChirps converts rows to API chirps, embeds the chirps they rechirp
+// or quote, and fills in the fields that depend on who is asking, such as
+// liked_by_me and bookmarked_by_me.
+func (cfg *apiConfig) presentChirps(r *http.Request, models []database.Chirp) ([]Chirp, error) {
+	apiChirps := chirpModelsToAPIChirps(models)
+	if len(apiChirps) == 0 {
+		return apiChirps, nil
+	}
+
+	refs, err := cfg.referencedChirps(r, apiChirps)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make([]*Chirp, 0, len(apiChirps)+len(refs))
+	for i := range apiChirps {
+		all = append(all, &apiChirps[i])
+	}
+	for _, ref := range refs {
+		all = append(all, ref)
+	}
+	if err := cfg.setMentions(r.Context(), all); err != nil {
+		return nil, err
+	}
+	if err := cfg.setMedia(r.Context(), all); err != nil {
+		return nil, err
+	}
+	viewerID, signedIn := cfg.optionalUser(r)
+	if err := cfg.setPolls(r.Context(), uuid.NullUUID{UUID: viewerID, Valid: signedIn}, all); err != nil {
+		return nil, err
+	}
+	if signedIn {
+		if err := cfg.setLikedByMe(r.Context(), viewerID, all); err != nil {
+			return nil, err
+		}
+		if err := cfg.setBookmarkedByMe(r.Context(), viewerID, all); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range apiChirps {
+		if id := apiChirps[i].RechirpOfID; id != nil {
+			apiChirps[i].RechirpOf = refs[*id]
+		}
+		if id := apiChirps[i].QuoteOfID; id != nil {
+			apiChirps[i].QuoteOf = refs[*id]
+		}
+	}
+	return apiChirps, nil
+}
+
+// referencedChirps loads the chirps that apiChirps rechirp or quote. Ones
+// that no longer exist come back as tombstones.
+func (cfg *apiConfig) referencedChirps(r *http.Request, apiChirps []Chirp) (map[uuid.UUID]*Chirp, error) {
+	refs := map[uuid.UUID]*Chirp{}
+	var ids []uuid.UUID
+	for _, c := range apiChirps {
+		for _, id := range []*uuid.UUID{c.RechirpOfID, c.QuoteOfID} {
+			if id == nil {
+				continue
+			}
+			if _, ok := refs[*id]; !ok {
+				tombstone := tombstoneChirp(*id)
+				refs[*id] = &tombstone
+				ids = append(ids, *id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return refs, nil
+	}
+
+	models, err := cfg.db.ListChirpsByIDs(r.Context(), ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range models {
+		if !m.DeletedAt.Valid {
+			ref := chirpFromModel(m)
+			refs[m.ID] = &ref
+		}
+	}
+	return refs, nil
+}
+
+func (cfg *apiConfig) setLikedByMe(ctx context.Context, viewerID uuid.UUID, chirps []*Chirp) error {
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, c := range chirps {
+		if !c.Deleted {
+			ids = append(ids, c.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	likedIDs, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+		UserID:   viewerID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return err
+	}
+	liked := make(map[uuid.UUID]bool, len(likedIDs))
+	for _, id := range likedIDs {
+		liked[id] = true
+	}
+	for _, c := range chirps {
+		if !c.Deleted {
+			likedByMe := liked[c.ID]
+			c.LikedByMe = &likedByMe
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) presentChirp(r *http.Request, model database.Chirp) (Chirp, error) {
+	apiChirps, err := cfg.presentChirps(r, []database.Chirp{model})
+	if err != nil {
+		return Chirp{}, err
+	}
+	return apiChirps[0], nil
+}
+
 func (cfg *apiConfig) getOneChirpHandler(w http.ResponseWriter, r *http.Request) {
 	parsedID, err := uuid.Parse(r.PathValue("chirpID"))
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
 	}
 
 	oneChirp, err := cfg.db.GetOneChirps(r.Context(), parsedID)
 	if errors.Is(err, sql.ErrNoRows) {
 		respondWithError(w, http.StatusNotFound, "ID not Found", err)
+		return
 	} else if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "error fetching chirp by ID", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, Chirp{
-		ID:        oneChirp.ID,
-		CreatedAt: oneChirp.CreatedAt,
-		UpdatedAt: oneChirp.UpdatedAt,
-		Body:      oneChirp.Body,
-		UserId:    oneChirp.UserID,
-	})
+	apiChirp, err := cfg.presentChirp(r, oneChirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, apiChirp)
 
 }
 
-func (cfg *apiConfig) deleteChirpHandler(w http.ResponseWriter, r *http.Request) {
-
+// ownChirp loads the live chirp named in the path and checks that the
+// caller wrote it. It writes the error response itself and reports whether
+// the caller may go ahead.
+func (cfg *apiConfig) ownChirp(w http.ResponseWriter, r *http.Request) (database.Chirp, bool) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
-		return
+		return database.Chirp{}, false
 	}
-	userID, err := auth.ValidateJWT(token, cfg.secret)
+	userID, err := cfg.keys.ValidateJWT(token)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
-		return
+		return database.Chirp{}, false
 	}
 	chirpID := r.PathValue("chirpID")
 	id, err := uuid.Parse(chirpID)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		return
+		return database.Chirp{}, false
 	}
 
 	chirp, err := cfg.db.GetOneChirps(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			w.WriteHeader(http.StatusNotFound)
-			return
+			return database.Chirp{}, false
 		}
 		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return database.Chirp{}, false
 	}
 
 	if userID != chirp.UserID {
 		w.WriteHeader(http.StatusForbidden)
+		return database.Chirp{}, false
+	}
+	return chirp, true
+}
+
+func (cfg *apiConfig) deleteChirpHandler(w http.ResponseWriter, r *http.Request) {
+	chirp, ok := cfg.ownChirp(w, r)
+	if !ok {
 		return
 	}
 
-	err = cfg.db.DeleteChirp(r.Context(), id)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
+	// The row is only marked deleted so the author can restore it during
+	// the grace period; the purger removes it once retention runs out.
+	if err := cfg.db.SoftDeleteChirp(r.Context(), chirp.ID); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRechirpAndQuote(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var original Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "original"}, &original)
+
+	var rechirp Chirp
+	if code := doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]interface{}{"rechirp_of": original.ID}, &rechirp); code != http.StatusCreated {
+		t.Fatalf("rechirp: got status %d", code)
+	}
+	if rechirp.RechirpOf == nil || rechirp.RechirpOf.Body != "original" {
+		t.Errorf("rechirp does not embed the original: %+v", rechirp)
+	}
+	if code := doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]interface{}{"rechirp_of": original.ID}, nil); code != http.StatusConflict {
+		t.Errorf("duplicate rechirp: got status %d, want %d", code, http.StatusConflict)
+	}
+	// Rechirping a rechirp points at the original.
+	var chained Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{"rechirp_of": rechirp.ID}, &chained)
+	if chained.RechirpOfID == nil || *chained.RechirpOfID != original.ID {
+		t.Errorf("rechirp of a rechirp points at %v, want %v", chained.RechirpOfID, original.ID)
+	}
+
+	var quote Chirp
+	doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]interface{}{"body": "so true", "quote_of": original.ID}, &quote)
+	if quote.QuoteOf == nil || quote.QuoteOf.ID != original.ID {
+		t.Fatalf("quote does not embed the original: %+v", quote)
+	}
+
+	if code := doJSON(t, srv, "DELETE", "/api/chirps/"+original.ID.String(), alice.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("DELETE original: got status %d", code)
+	}
+
+	var got Chirp
+	doJSON(t, srv, "GET", "/api/chirps/"+quote.ID.String(), "", nil, &got)
+	if got.QuoteOf == nil || !got.QuoteOf.Deleted || got.QuoteOf.Body != "" {
+		t.Errorf("quote of deleted chirp is not a tombstone: %+v", got.QuoteOf)
+	}
+	if code := doJSON(t, srv, "GET", "/api/chirps/"+rechirp.ID.String(), "", nil, nil); code != http.StatusNotFound {
+		t.Errorf("rechirp survived deletion of the original: got status %d", code)
+	}
+}
+
+func TestPostChirp_LengthLimit(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+
+	// 140 emoji with skin tones are 140 characters, though far more bytes.
+	thumbs := strings.Repeat("\U0001F44D\U0001F3FD", maxChirpLength)
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": thumbs}, nil); code != http.StatusCreated {
+		t.Errorf("140 emoji: got status %d, want %d", code, http.StatusCreated)
+	}
+	// A link counts the same however long it is.
+	link := "read https://example.com/" + strings.Repeat("a", 200)
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": link}, nil); code != http.StatusCreated {
+		t.Errorf("long link: got status %d, want %d", code, http.StatusCreated)
+	}
+
+	long := strings.Repeat("a", maxChirpLength+1)
+	var tooLong struct {
+		Limit  int `json:"limit"`
+		Length int `json:"length"`
+	}
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": long}, &tooLong); code != http.StatusBadRequest {
+		t.Fatalf("141 characters: got status %d, want %d", code, http.StatusBadRequest)
+	}
+	if tooLong.Limit != maxChirpLength || tooLong.Length != maxChirpLength+1 {
+		t.Errorf("error reports limit %d and length %d", tooLong.Limit, tooLong.Length)
+	}
+
+	upgradeToRed(t, srv, alice.ID)
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": long}, nil); code != http.StatusCreated {
+		t.Errorf("141 characters as Chirpy Red: got status %d, want %d", code, http.StatusCreated)
+	}
+	tooLongForRed := strings.Repeat("a", maxRedChirpLength+1)
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": tooLongForRed}, &tooLong); code != http.StatusBadRequest {
+		t.Errorf("281 characters as Chirpy Red: got status %d, want %d", code, http.StatusBadRequest)
+	}
+	if tooLong.Limit != maxRedChirpLength {
+		t.Errorf("Chirpy Red error reports limit %d, want %d", tooLong.Limit, maxRedChirpLength)
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const pqUniqueViolation = pq.ErrorCode("23505")
+
+// isUniqueViolation reports whether err is Postgres rejecting a duplicate
+// key, which handlers surface as 409 Conflict.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation
+}
+
+// violatedConstraint names the constraint err tripped, or returns "" if err
+// didn't come from one.
+func violatedConstraint(err error) string {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return ""
+	}
+	return pqErr.Constraint
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// restoreCutoff is the oldest deleted_at that can still be restored.
+func (cfg *apiConfig) restoreCutoff() time.Time {
+	return time.Now().Add(-cfg.restoreWindow)
+}
+
+// restoreChirpHandler brings back a deleted chirp, along with the rechirps
+// that were removed with it. The author or an admin may restore it during
+// the grace period.
+func (cfg *apiConfig) restoreChirpHandler(w http.ResponseWriter, r *http.Request) {
+	// Authenticate before looking the chirp up, so callers who may not
+	// restore anything can't tell which deleted chirps exist.
+	admin := cfg.isAdmin(r)
+	var userID uuid.UUID
+	if !admin {
+		var ok bool
+		userID, ok = cfg.requireUser(w, r)
+		if !ok {
+			return
+		}
+	}
+	id, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpIncludingDeleted(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "ID not Found", err)
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching chirp by ID", err)
+		return
+	}
+	if !admin && userID != chirp.UserID {
+		respondWithError(w, http.StatusForbidden, "Only the author can restore this chirp", nil)
+		return
+	}
+	if !chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusConflict, "Chirp is not deleted", nil)
+		return
+	}
+
+	// Chirps removed with their author's account come back with the account.
+	if _, err := cfg.db.GetUserByID(r.Context(), chirp.UserID); errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "The author's account is deleted", err)
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching user", err)
+		return
+	}
+
+	restored, err := cfg.db.RestoreChirp(r.Context(), database.RestoreChirpParams{
+		ID:     chirp.ID,
+		Cutoff: cfg.restoreCutoff(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusGone, "The restore window has passed", err)
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You already rechirped this chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't restore chirp", err)
+		return
+	}
+
+	apiChirp, err := cfg.presentChirp(r, restored)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, apiChirp)
+}
+
+// deleteUserHandler deletes the caller's account. Their chirps and the
+// rechirps of them go with it, and their refresh and access tokens are
+// revoked.
+func (cfg *apiConfig) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	if _, err := cfg.db.GetUserByID(r.Context(), userID); errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching user", err)
+		return
+	}
+
+	var revoked []database.RevokeUserAccessTokensRow
+	err := cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		// Replies stop counting towards their parents before they are
+		// marked deleted, while they can still be told apart from replies
+		// that were deleted earlier.
+		err := q.AddUserReplyCounts(r.Context(), database.AddUserReplyCountsParams{
+			Delta:  -1,
+			UserID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.SoftDeleteUser(r.Context(), userID); err != nil {
+			return err
+		}
+		revoked, err = q.RevokeUserAccessTokens(r.Context(), database.RevokeUserAccessTokensParams{UserID: userID})
+		return err
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete user", err)
+		return
+	}
+	cfg.denylist.add(revoked)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// restoreUserHandler lets an admin bring back a deleted account during the
+// grace period, together with everything that was deleted with it. Deleted
+// accounts free their email and handle, so this fails if someone has signed
+// up with either since.
+func (cfg *apiConfig) restoreUserHandler(w http.ResponseWriter, r *http.Request) {
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin key required", nil)
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	user, err := cfg.db.GetUserIncludingDeleted(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching user", err)
+		return
+	}
+	if !user.DeletedAt.Valid {
+		respondWithError(w, http.StatusConflict, "User is not deleted", nil)
+		return
+	}
+
+	var restored database.User
+	err = cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		err := q.AddUserReplyCounts(r.Context(), database.AddUserReplyCountsParams{
+			Delta:     1,
+			UserID:    id,
+			DeletedAt: user.DeletedAt,
+		})
+		if err != nil {
+			return err
+		}
+		restored, err = q.RestoreUser(r.Context(), database.RestoreUserParams{
+			ID:     id,
+			Cutoff: cfg.restoreCutoff(),
+		})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusGone, "The restore window has passed", err)
+		return
+	}
+	if isUniqueViolation(err) {
+		switch violatedConstraint(err) {
+		case "users_email_key", "users_handle_key":
+			respondWithError(w, http.StatusConflict, "Another account has taken this user's email or handle", err)
+		default:
+			respondWithError(w, http.StatusConflict, "A restored rechirp conflicts with a newer one", err)
+		}
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't restore user", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, userFromModel(restored))
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDeleteAndRestoreChirp(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var root, reply Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{"body": "root"}, &root)
+	doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]interface{}{"body": "oops", "in_reply_to": root.ID}, &reply)
+	path := "/api/chirps/" + reply.ID.String()
+
+	if code := doJSON(t, srv, "DELETE", path, bob.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("DELETE reply: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", path, "", nil, nil); code != http.StatusNotFound {
+		t.Errorf("GET deleted chirp: got status %d, want %d", code, http.StatusNotFound)
+	}
+	var got Chirp
+	doJSON(t, srv, "GET", "/api/chirps/"+root.ID.String(), "", nil, &got)
+	if got.ReplyCount != 0 {
+		t.Errorf("root has reply_count %d after delete, want 0", got.ReplyCount)
+	}
+
+	// Callers who can't restore anything learn nothing about which chirps
+	// exist, and a malformed Authorization header is just rejected.
+	for _, p := range []string{path, "/api/chirps/" + uuid.NewString()} {
+		if code := doJSON(t, srv, "POST", p+"/restore", "", nil, nil); code != http.StatusUnauthorized {
+			t.Errorf("anonymous restore of %s: got status %d, want %d", p, code, http.StatusUnauthorized)
+		}
+	}
+	req, err := http.NewRequest("POST", srv.URL+path+"/restore", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "ApiKey")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("restore with a malformed Authorization header: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("restore with a malformed Authorization header: got status %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	if code := doJSON(t, srv, "POST", path+"/restore", alice.Token, nil, nil); code != http.StatusForbidden {
+		t.Errorf("restore by non-author: got status %d, want %d", code, http.StatusForbidden)
+	}
+	var restored Chirp
+	if code := doJSON(t, srv, "POST", path+"/restore", bob.Token, nil, &restored); code != http.StatusOK {
+		t.Fatalf("restore by author: got status %d", code)
+	}
+	if restored.Body != "oops" || restored.Deleted {
+		t.Errorf("unexpected restored chirp: %+v", restored)
+	}
+	doJSON(t, srv, "GET", "/api/chirps/"+root.ID.String(), "", nil, &got)
+	if got.ReplyCount != 1 {
+		t.Errorf("root has reply_count %d after restore, want 1", got.ReplyCount)
+	}
+	if code := doJSON(t, srv, "POST", path+"/restore", bob.Token, nil, nil); code != http.StatusConflict {
+		t.Errorf("restore of live chirp: got status %d, want %d", code, http.StatusConflict)
+	}
+}
+
+func TestRestoreChirp_WindowClosed(t *testing.T) {
+	srv := newTestServerWithConfig(t, func(cfg *apiConfig) { cfg.restoreWindow = 0 })
+	alice := signUp(t, srv, "alice@example.com")
+
+	var chirp Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "gone"}, &chirp)
+	path := "/api/chirps/" + chirp.ID.String()
+	doJSON(t, srv, "DELETE", path, alice.Token, nil, nil)
+	if code := doAdmin(t, srv, "POST", path+"/restore", nil, nil); code != http.StatusGone {
+		t.Errorf("restore after window: got status %d, want %d", code, http.StatusGone)
+	}
+}
+
+func TestDeleteAndRestoreUser(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUpWithHandle(t, srv, "alice@example.com", "alice")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var chirp, rechirp Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "hello"}, &chirp)
+	doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]interface{}{"rechirp_of": chirp.ID}, &rechirp)
+
+	if code := doJSON(t, srv, "DELETE", "/api/users", alice.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("DELETE /api/users: got status %d", code)
+	}
+	creds := map[string]string{"email": "alice@example.com", "password": "hunter2"}
+	if code := doJSON(t, srv, "POST", "/api/login", "", creds, nil); code != http.StatusUnauthorized {
+		t.Errorf("login as deleted user: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := doJSON(t, srv, "POST", "/api/refresh", alice.RefreshToken, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("refresh as deleted user: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := doJSON(t, srv, "GET", "/api/users/alice", "", nil, nil); code != http.StatusNotFound {
+		t.Errorf("profile of deleted user: got status %d, want %d", code, http.StatusNotFound)
+	}
+	var page chirpsPage
+	doJSON(t, srv, "GET", "/api/chirps", "", nil, &page)
+	if len(page.Chirps) != 0 {
+		t.Errorf("deleted user's chirps and rechirps are still listed: %+v", page.Chirps)
+	}
+
+	path := "/admin/users/" + alice.ID.String() + "/restore"
+	if code := doJSON(t, srv, "POST", path, bob.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("restore without admin key: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+	var restored User
+	if code := doAdmin(t, srv, "POST", path, nil, &restored); code != http.StatusOK {
+		t.Fatalf("POST %s: got status %d", path, code)
+	}
+	if restored.ID != alice.ID {
+		t.Errorf("restored the wrong user: %+v", restored)
+	}
+	if code := doJSON(t, srv, "POST", "/api/login", "", creds, nil); code != http.StatusOK {
+		t.Errorf("login after restore: got status %d", code)
+	}
+	doJSON(t, srv, "GET", "/api/chirps", "", nil, &page)
+	if len(page.Chirps) != 2 {
+		t.Errorf("got %d chirps after restore, want the chirp and its rechirp", len(page.Chirps))
+	}
+}
+
+func TestDeleteUser_FreesEmailAndHandle(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUpWithHandle(t, srv, "alice@example.com", "alice")
+	doJSON(t, srv, "DELETE", "/api/users", alice.Token, nil, nil)
+
+	newcomer := signUpWithHandle(t, srv, "alice@example.com", "Alice")
+	if newcomer.ID == alice.ID {
+		t.Fatalf("signed in as the deleted account")
+	}
+	path := "/admin/users/" + alice.ID.String() + "/restore"
+	if code := doAdmin(t, srv, "POST", path, nil, nil); code != http.StatusConflict {
+		t.Errorf("restore with email taken: got status %d, want %d", code, http.StatusConflict)
+	}
+
+	// Once the newcomer moves on, the account can come back.
+	update := map[string]string{"email": "newcomer@example.com", "password": "hunter2", "handle": "newcomer"}
+	if code := doJSON(t, srv, "PUT", "/api/users", newcomer.Token, update, nil); code != http.StatusOK {
+		t.Fatalf("PUT /api/users: got status %d", code)
+	}
+	if code := doAdmin(t, srv, "POST", path, nil, nil); code != http.StatusOK {
+		t.Errorf("restore after email freed: got status %d", code)
+	}
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	var cfg *apiConfig
+	srv := newTestServerWithConfig(t, func(c *apiConfig) { cfg = c })
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var root, reply, lonely Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{"body": "root"}, &root)
+	doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]interface{}{"body": "reply", "in_reply_to": root.ID}, &reply)
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{"body": "lonely"}, &lonely)
+	doJSON(t, srv, "DELETE", "/api/chirps/"+root.ID.String(), alice.Token, nil, nil)
+	doJSON(t, srv, "DELETE", "/api/chirps/"+lonely.ID.String(), alice.Token, nil, nil)
+
+	ctx := context.Background()
+	if chirps, _, err := cfg.purgeDeleted(ctx, time.Now()); err != nil || chirps != 0 {
+		t.Fatalf("purge within retention removed %d chirps, err %v", chirps, err)
+	}
+
+	chirps, _, err := cfg.purgeDeleted(ctx, time.Now().Add(cfg.retention+time.Minute))
+	if err != nil {
+		t.Fatalf("purgeDeleted returned error: %v", err)
+	}
+	if chirps != 1 {
+		t.Errorf("purged %d chirps, want only the one without replies", chirps)
+	}
+	if _, err := cfg.db.GetChirpIncludingDeleted(ctx, lonely.ID); err == nil {
+		t.Error("expired chirp without replies was not purged")
+	}
+	tombstone, err := cfg.db.GetChirpIncludingDeleted(ctx, root.ID)
+	if err != nil || tombstone.Body != "" {
+		t.Errorf("expired chirp with replies should stay as a blank tombstone: %+v, %v", tombstone, err)
+	}
+}
+
+func TestDeleteUser_RevokesAccessTokens(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	if code := doJSON(t, srv, "DELETE", "/api/users", alice.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete user: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", alice.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("access token of a deleted user: got status %d", code)
+	}
+}
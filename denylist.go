@@ -0,0 +1,85 @@
+package main
+
+import (
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// tokenDenylist is this server's copy of the access tokens that were
+// revoked before they expired, so validating a token never waits on the
+// database. Tokens this server revokes are added at once; ones revoked
+// through other servers show up on the next reload.
+type tokenDenylist struct {
+	db database.Querier
+
+	mu sync.RWMutex
+	// revoked maps jtis to when the token expires, after which it no
+	// longer needs denying.
+	revoked map[string]time.Time
+}
+
+var _ auth.Denylist = (*tokenDenylist)(nil)
+
+func newTokenDenylist(db database.Querier) *tokenDenylist {
+	return &tokenDenylist{db: db, revoked: map[string]time.Time{}}
+}
+
+func (d *tokenDenylist) Revoked(claims *auth.Claims) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := d.revoked[claims.ID]
+	return ok
+}
+
+func (d *tokenDenylist) add(rows []database.RevokeUserAccessTokensRow) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, row := range rows {
+		d.revoked[row.Jti] = row.ExpiresAt
+	}
+}
+
+// reload reads the revoked tokens from the database and forgets the ones
+// that have expired. Entries added while it runs are kept, since a
+// revocation committed after the read started may not be in it.
+func (d *tokenDenylist) reload(ctx context.Context) error {
+	rows, err := d.db.ListRevokedAccessTokens(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	revoked := make(map[string]time.Time, len(rows))
+	for jti, expiresAt := range d.revoked {
+		if expiresAt.After(now) {
+			revoked[jti] = expiresAt
+		}
+	}
+	for _, row := range rows {
+		revoked[row.Jti] = row.ExpiresAt
+	}
+	d.revoked = revoked
+	return nil
+}
+
+// runDenylistSync reloads the denylist every interval until ctx is
+// cancelled.
+func (cfg *apiConfig) runDenylistSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := cfg.denylist.reload(ctx); err != nil {
+			log.Printf("Reloading revoked access tokens failed: %s", err)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/moderation"
+	"chirpy/internal/textlen"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Draft struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Body      string     `json:"body"`
+	InReplyTo *uuid.UUID `json:"in_reply_to,omitempty"`
+	QuoteOf   *uuid.UUID `json:"quote_of,omitempty"`
+	// PublishAt is set on scheduled drafts.
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// LastError says why the scheduler couldn't publish the draft. It is
+	// cleared when the draft is saved again.
+	LastError string `json:"last_error,omitempty"`
+}
+
+func draftFromModel(d database.Draft) Draft {
+	draft := Draft{
+		ID:        d.ID,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+		Body:      d.Body,
+		InReplyTo: nullUUIDPtr(d.ParentID),
+		QuoteOf:   nullUUIDPtr(d.QuoteOfID),
+		LastError: d.LastError,
+	}
+	if d.PublishAt.Valid {
+		draft.PublishAt = &d.PublishAt.Time
+	}
+	return draft
+}
+
+type draftsPage struct {
+	Drafts     []Draft `json:"drafts"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+type draftParams struct {
+	Body      string     `json:"body"`
+	InReplyTo *uuid.UUID `json:"in_reply_to"`
+	QuoteOf   *uuid.UUID `json:"quote_of"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// checkedDraft holds the columns of a draft that passed checkDraft.
+type checkedDraft struct {
+	ParentID  uuid.NullUUID
+	QuoteOfID uuid.NullUUID
+	PublishAt sql.NullTime
+}
+
+// checkDraft applies the checks posting would, so a draft that saves can be
+// published, and checks that a scheduled draft is due in the future. The
+// body is stored as written; moderation masks it when it is published. On
+// failure it writes the error response itself and ok is false.
+func (cfg *apiConfig) checkDraft(w http.ResponseWriter, r *http.Request, userID uuid.UUID, p draftParams) (checkedDraft, bool) {
+	var checked checkedDraft
+	if !cfg.checkChirpLength(w, r, userID, p.Body) {
+		return checked, false
+	}
+	if _, ok := cfg.moderateBody(w, p.Body); !ok {
+		return checked, false
+	}
+	if p.InReplyTo != nil {
+		parent, err := referencedChirp(r.Context(), cfg.db, *p.InReplyTo)
+		if err != nil {
+			respondWithReferenceError(w, "Parent chirp not found", err)
+			return checked, false
+		}
+		checked.ParentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+	if p.QuoteOf != nil {
+		original, err := referencedChirp(r.Context(), cfg.db, *p.QuoteOf)
+		if err != nil {
+			respondWithReferenceError(w, "Quoted chirp not found", err)
+			return checked, false
+		}
+		checked.QuoteOfID = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
+	if p.PublishAt != nil {
+		if !p.PublishAt.After(time.Now()) {
+			respondWithError(w, http.StatusBadRequest, "publish_at must be in the future", nil)
+			return checked, false
+		}
+		checked.PublishAt = sql.NullTime{Time: p.PublishAt.UTC(), Valid: true}
+	}
+	return checked, true
+}
+
+func (cfg *apiConfig) createDraftHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	params := draftParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	checked, ok := cfg.checkDraft(w, r, userID, params)
+	if !ok {
+		return
+	}
+
+	draft, err := cfg.db.CreateDraft(r.Context(), database.CreateDraftParams{
+		UserID:    userID,
+		Body:      params.Body,
+		ParentID:  checked.ParentID,
+		QuoteOfID: checked.QuoteOfID,
+		PublishAt: checked.PublishAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save draft", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, draftFromModel(draft))
+}
+
+// getDraftsHandler lists the caller's drafts, scheduled or not, newest
+// first.
+func (cfg *apiConfig) getDraftsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	page, err := parsePageParams(r.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if !page.Desc {
+		respondWithError(w, http.StatusBadRequest, "only sort=desc is supported", nil)
+		return
+	}
+
+	rows, err := cfg.db.ListDrafts(r.Context(), database.ListDraftsParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorTime(),
+		CursorID:        page.cursorID(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching drafts", err)
+		return
+	}
+	rows, next := trimPage(page, rows, func(d database.Draft) pageCursor {
+		return pageCursor{CreatedAt: d.CreatedAt, ID: d.ID}
+	})
+	drafts := make([]Draft, 0, len(rows))
+	for _, d := range rows {
+		drafts = append(drafts, draftFromModel(d))
+	}
+	setLinkHeader(w, r, next)
+	respondWithJSON(w, http.StatusOK, draftsPage{Drafts: drafts, NextCursor: next})
+}
+
+// draftID parses the draft named in the path, writing a 400 if it is
+// malformed.
+func draftID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Draft ID", err)
+		return uuid.UUID{}, false
+	}
+	return id, true
+}
+
+func (cfg *apiConfig) getDraftHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := draftID(w, r)
+	if !ok {
+		return
+	}
+	draft, err := cfg.db.GetDraft(r.Context(), database.GetDraftParams{ID: id, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't fetch draft", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, draftFromModel(draft))
+}
+
+// updateDraftHandler replaces a draft. Leaving out publish_at unschedules
+// it.
+func (cfg *apiConfig) updateDraftHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := draftID(w, r)
+	if !ok {
+		return
+	}
+	params := draftParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	checked, ok := cfg.checkDraft(w, r, userID, params)
+	if !ok {
+		return
+	}
+
+	draft, err := cfg.db.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		Body:      params.Body,
+		ParentID:  checked.ParentID,
+		QuoteOfID: checked.QuoteOfID,
+		PublishAt: checked.PublishAt,
+		ID:        id,
+		UserID:    userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save draft", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, draftFromModel(draft))
+}
+
+func (cfg *apiConfig) deleteDraftHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := draftID(w, r)
+	if !ok {
+		return
+	}
+	n, err := cfg.db.DeleteDraft(r.Context(), database.DeleteDraftParams{ID: id, UserID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete draft", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Draft not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// publishDraftHandler posts a draft now, whether or not it is scheduled.
+func (cfg *apiConfig) publishDraftHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := draftID(w, r)
+	if !ok {
+		return
+	}
+
+	var chirp database.Chirp
+	var moderated moderation.Result
+	err := cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		draft, err := q.LockDraft(r.Context(), database.LockDraftParams{ID: id, UserID: userID})
+		if err != nil {
+			return err
+		}
+		chirp, moderated, err = cfg.publishDraft(r.Context(), q, draft)
+		return err
+	})
+	var notPublishable draftError
+	if errors.As(err, &notPublishable) {
+		respondWithError(w, http.StatusBadRequest, notPublishable.msg, err)
+		return
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't publish draft", err)
+		return
+	}
+
+	apiChirp, err := cfg.presentChirp(r, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	apiChirp.Moderation = moderated.Hits
+	respondWithJSON(w, http.StatusCreated, apiChirp)
+}
+
+// draftError is a reason a draft can't be published as it stands, as
+// opposed to a failure to reach the database.
+type draftError struct {
+	msg string
+}
+
+func (e draftError) Error() string {
+	return e.msg
+}
+
+// publishDraft posts d as a chirp and deletes it, both through q so they
+// happen in one transaction. The checks made when the draft was saved are
+// made again through q too, since the author's tier, the moderation rules
+// and the chirps it refers to may have changed since.
+func (cfg *apiConfig) publishDraft(ctx context.Context, q database.Querier, d database.Draft) (database.Chirp, moderation.Result, error) {
+	author, err := q.GetUserByID(ctx, d.UserID)
+	if err != nil {
+		return database.Chirp{}, moderation.Result{}, err
+	}
+	if length, limit := textlen.Weighted(d.Body), chirpLengthLimit(author); length > limit {
+		return database.Chirp{}, moderation.Result{}, draftError{fmt.Sprintf("Chirp is too long: %d characters, the limit is %d", length, limit)}
+	}
+	moderated := cfg.moderationFilter().Check(d.Body)
+	if moderated.Rejected() {
+		return database.Chirp{}, moderated, draftError{"Chirp contains words that aren't allowed"}
+	}
+
+	var parentID, rootID, quoteOfID uuid.NullUUID
+	if d.ParentID.Valid {
+		parent, err := referencedChirp(ctx, q, d.ParentID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Chirp{}, moderated, draftError{"Parent chirp not found"}
+		}
+		if err != nil {
+			return database.Chirp{}, moderated, err
+		}
+		parentID, rootID = threadIDs(parent)
+	}
+	if d.QuoteOfID.Valid {
+		original, err := referencedChirp(ctx, q, d.QuoteOfID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Chirp{}, moderated, draftError{"Quoted chirp not found"}
+		}
+		if err != nil {
+			return database.Chirp{}, moderated, err
+		}
+		quoteOfID = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
+	mentions, err := resolveMentions(ctx, q, moderated.Text)
+	if err != nil {
+		return database.Chirp{}, moderated, err
+	}
+
+	chirp, err := q.CreateChirp(ctx, database.CreateChirpParams{
+		Body:      moderated.Text,
+		UserID:    d.UserID,
+		ParentID:  parentID,
+		RootID:    rootID,
+		QuoteOfID: quoteOfID,
+	})
+	if err != nil {
+		return database.Chirp{}, moderated, err
+	}
+	if err := saveChirpEntities(ctx, q, chirp, mentions); err != nil {
+		return database.Chirp{}, moderated, err
+	}
+	if err := flagForReview(ctx, q, chirp.ID, moderated); err != nil {
+		return database.Chirp{}, moderated, err
+	}
+	if _, err := q.DeleteDraft(ctx, database.DeleteDraftParams{ID: d.ID, UserID: d.UserID}); err != nil {
+		return database.Chirp{}, moderated, err
+	}
+	return chirp, moderated, nil
+}
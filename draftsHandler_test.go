@@ -0,0 +1,207 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDrafts(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var draft Draft
+	if code := doJSON(t, srv, "POST", "/api/drafts", alice.Token, map[string]string{"body": "work in progress"}, &draft); code != http.StatusCreated {
+		t.Fatalf("POST /api/drafts: got status %d", code)
+	}
+	path := "/api/drafts/" + draft.ID.String()
+
+	var chirps chirpsPage
+	doJSON(t, srv, "GET", "/api/chirps", "", nil, &chirps)
+	if len(chirps.Chirps) != 0 {
+		t.Errorf("draft is listed as a chirp: %+v", chirps.Chirps)
+	}
+	if code := doJSON(t, srv, "GET", path, bob.Token, nil, nil); code != http.StatusNotFound {
+		t.Errorf("GET someone else's draft: got status %d, want %d", code, http.StatusNotFound)
+	}
+
+	var updated Draft
+	if code := doJSON(t, srv, "PUT", path, alice.Token, map[string]string{"body": "finished kerfuffle"}, &updated); code != http.StatusOK {
+		t.Fatalf("PUT %s: got status %d", path, code)
+	}
+	if updated.Body != "finished kerfuffle" {
+		t.Errorf("draft body = %q; drafts should keep what the author wrote", updated.Body)
+	}
+	var drafts draftsPage
+	doJSON(t, srv, "GET", "/api/drafts", alice.Token, nil, &drafts)
+	if len(drafts.Drafts) != 1 || drafts.Drafts[0].Body != "finished kerfuffle" {
+		t.Errorf("unexpected drafts: %+v", drafts.Drafts)
+	}
+
+	var chirp Chirp
+	if code := doJSON(t, srv, "POST", path+"/publish", alice.Token, nil, &chirp); code != http.StatusCreated {
+		t.Fatalf("publish: got status %d", code)
+	}
+	if chirp.Body != "finished ****" {
+		t.Errorf("published body = %q", chirp.Body)
+	}
+	if code := doJSON(t, srv, "GET", path, alice.Token, nil, nil); code != http.StatusNotFound {
+		t.Errorf("draft survived publishing: got status %d", code)
+	}
+	if code := doJSON(t, srv, "POST", path+"/publish", alice.Token, nil, nil); code != http.StatusNotFound {
+		t.Errorf("publishing twice: got status %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestScheduledDrafts(t *testing.T) {
+	var cfg *apiConfig
+	srv := newTestServerWithConfig(t, func(c *apiConfig) { cfg = c })
+	alice := signUp(t, srv, "alice@example.com")
+
+	if code := doJSON(t, srv, "POST", "/api/drafts", alice.Token, map[string]interface{}{"body": "too late", "publish_at": time.Now().Add(-time.Minute)}, nil); code != http.StatusBadRequest {
+		t.Errorf("publish_at in the past: got status %d, want %d", code, http.StatusBadRequest)
+	}
+
+	var parent Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "parent"}, &parent)
+	publishAt := time.Now().Add(time.Hour)
+	for i := 0; i < 5; i++ {
+		doJSON(t, srv, "POST", "/api/drafts", alice.Token, map[string]interface{}{"body": "scheduled", "publish_at": publishAt}, nil)
+	}
+	var orphan Draft
+	doJSON(t, srv, "POST", "/api/drafts", alice.Token, map[string]interface{}{"body": "reply", "in_reply_to": parent.ID, "publish_at": publishAt}, &orphan)
+	doJSON(t, srv, "DELETE", "/api/chirps/"+parent.ID.String(), alice.Token, nil, nil)
+
+	ctx := context.Background()
+	if n, err := cfg.publishDueDrafts(ctx, time.Now()); err != nil || n != 0 {
+		t.Fatalf("publishing before publish_at: published %d, err %v", n, err)
+	}
+
+	// Several schedulers racing publish each draft once.
+	due := publishAt.Add(time.Minute)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := cfg.publishDueDrafts(ctx, due)
+			if err != nil {
+				t.Errorf("publishDueDrafts: %v", err)
+			}
+			mu.Lock()
+			total += n
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if total != 5 {
+		t.Errorf("published %d drafts, want 5", total)
+	}
+
+	var chirps chirpsPage
+	doJSON(t, srv, "GET", "/api/chirps", "", nil, &chirps)
+	if len(chirps.Chirps) != 5 {
+		t.Errorf("got %d chirps, want 5", len(chirps.Chirps))
+	}
+
+	// The reply lost its parent, so it was unscheduled instead.
+	var failed Draft
+	doJSON(t, srv, "GET", "/api/drafts/"+orphan.ID.String(), alice.Token, nil, &failed)
+	if failed.PublishAt != nil || failed.LastError != "Parent chirp not found" {
+		t.Errorf("unpublishable draft: %+v", failed)
+	}
+}
+
+// failingStore fails to create chirps with one body, standing in for a
+// database error partway through publishing.
+type failingStore struct {
+	database.Store
+	body string
+}
+
+func (s failingStore) ExecTx(ctx context.Context, fn func(database.Querier) error) error {
+	return s.Store.ExecTx(ctx, func(q database.Querier) error {
+		return fn(failingQuerier{Querier: q, body: s.body})
+	})
+}
+
+type failingQuerier struct {
+	database.Querier
+	body string
+}
+
+func (q failingQuerier) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	if arg.Body == q.body {
+		return database.Chirp{}, errors.New("connection reset by peer")
+	}
+	return q.Querier.CreateChirp(ctx, arg)
+}
+
+func TestScheduledDrafts_RetriesFailures(t *testing.T) {
+	var cfg *apiConfig
+	srv := newTestServerWithConfig(t, func(c *apiConfig) {
+		c.db = failingStore{Store: c.db, body: "poison"}
+		cfg = c
+	})
+	alice := signUp(t, srv, "alice@example.com")
+
+	publishAt := time.Now().Add(time.Hour)
+	var poison Draft
+	doJSON(t, srv, "POST", "/api/drafts", alice.Token, map[string]interface{}{"body": "poison", "publish_at": publishAt}, &poison)
+	doJSON(t, srv, "POST", "/api/drafts", alice.Token, map[string]interface{}{"body": "fine", "publish_at": publishAt.Add(time.Second)}, nil)
+
+	// The failing draft comes first but doesn't hold up the one after it.
+	ctx := context.Background()
+	now := publishAt.Add(time.Minute)
+	if n, err := cfg.publishDueDrafts(ctx, now); err != nil || n != 1 {
+		t.Fatalf("publishDueDrafts: published %d, err %v", n, err)
+	}
+	var draft Draft
+	doJSON(t, srv, "GET", "/api/drafts/"+poison.ID.String(), alice.Token, nil, &draft)
+	if draft.PublishAt == nil || draft.LastError != "" {
+		t.Errorf("draft that failed once was unscheduled: %+v", draft)
+	}
+	// It isn't tried again until its retry is due.
+	if n, err := cfg.publishDueDrafts(ctx, now); err != nil || n != 0 {
+		t.Fatalf("publishDueDrafts before retry: published %d, err %v", n, err)
+	}
+
+	for i := 1; i < maxDraftAttempts; i++ {
+		now = now.Add(draftRetryDelay << i)
+		if _, err := cfg.publishDueDrafts(ctx, now); err != nil {
+			t.Fatalf("publishDueDrafts: %v", err)
+		}
+	}
+	var failed Draft
+	doJSON(t, srv, "GET", "/api/drafts/"+poison.ID.String(), alice.Token, nil, &failed)
+	if failed.PublishAt != nil || failed.LastError == "" {
+		t.Errorf("draft still scheduled after %d attempts: %+v", maxDraftAttempts, failed)
+	}
+}
+
+func TestScheduledDrafts_WaitForSuspensions(t *testing.T) {
+	var cfg *apiConfig
+	srv := newTestServerWithConfig(t, func(c *apiConfig) { cfg = c })
+	alice := signUp(t, srv, "alice@example.com")
+
+	publishAt := time.Now().Add(time.Hour)
+	doJSON(t, srv, "POST", "/api/drafts", alice.Token, map[string]interface{}{"body": "later", "publish_at": publishAt}, nil)
+	doAdmin(t, srv, "POST", "/admin/users/"+alice.ID.String()+"/suspend", nil, nil)
+
+	ctx := context.Background()
+	now := publishAt.Add(time.Minute)
+	if n, err := cfg.publishDueDrafts(ctx, now); err != nil || n != 0 {
+		t.Fatalf("publishDueDrafts while suspended: published %d, err %v", n, err)
+	}
+	doAdmin(t, srv, "POST", "/admin/users/"+alice.ID.String()+"/unsuspend", nil, nil)
+	if n, err := cfg.publishDueDrafts(ctx, now); err != nil || n != 1 {
+		t.Fatalf("publishDueDrafts after the suspension was lifted: published %d, err %v", n, err)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) editChirpHandler(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	chirp, ok := cfg.ownChirp(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	params := parameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	if chirp.RechirpOfID.Valid {
+		respondWithError(w, http.StatusBadRequest, "Rechirps can't be edited", nil)
+		return
+	}
+	if time.Since(chirp.CreatedAt) > cfg.editWindow {
+		respondWithError(w, http.StatusForbidden, "The edit window for this chirp has closed", nil)
+		return
+	}
+	if !cfg.checkChirpLength(w, r, chirp.UserID, params.Body) {
+		return
+	}
+	moderated, ok := cfg.moderateBody(w, params.Body)
+	if !ok {
+		return
+	}
+	cleaned := moderated.Text
+
+	mentions, err := resolveMentions(r.Context(), cfg.db, cleaned)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't resolve mentions", err)
+		return
+	}
+
+	err = cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		var err error
+		chirp, err = q.EditChirp(r.Context(), database.EditChirpParams{
+			ID:   chirp.ID,
+			Body: cleaned,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.DeleteChirpTags(r.Context(), chirp.ID); err != nil {
+			return err
+		}
+		if err := q.DeleteChirpMentions(r.Context(), chirp.ID); err != nil {
+			return err
+		}
+		if err := saveChirpEntities(r.Context(), q, chirp, mentions); err != nil {
+			return err
+		}
+		return flagForReview(r.Context(), q, chirp.ID, moderated)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "ID not Found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't edit chirp", err)
+		return
+	}
+
+	apiChirp, err := cfg.presentChirp(r, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	apiChirp.Moderation = moderated.Hits
+	respondWithJSON(w, http.StatusOK, apiChirp)
+}
+
+type Revision struct {
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"`
+}
+
+type historyResponse struct {
+	ChirpID   uuid.UUID  `json:"chirp_id"`
+	Revisions []Revision `json:"revisions"`
+}
+
+// getChirpHistoryHandler lists every body a chirp has had, oldest first.
+// The last entry is the current body.
+func (cfg *apiConfig) getChirpHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetOneChirps(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "ID not Found", err)
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching chirp by ID", err)
+		return
+	}
+
+	rows, err := cfg.db.ListChirpRevisions(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching chirp history", err)
+		return
+	}
+	revisions := make([]Revision, 0, len(rows)+1)
+	for _, row := range rows {
+		revisions = append(revisions, Revision{Body: row.Body, CreatedAt: row.CreatedAt})
+	}
+	current := Revision{Body: chirp.Body, CreatedAt: chirp.CreatedAt, Current: true}
+	if chirp.EditedAt.Valid {
+		current.CreatedAt = chirp.EditedAt.Time
+	}
+	revisions = append(revisions, current)
+
+	respondWithJSON(w, http.StatusOK, historyResponse{
+		ChirpID:   id,
+		Revisions: revisions,
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEditChirp(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var chirp Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "frist #typo"}, &chirp)
+	if chirp.Edited {
+		t.Error("new chirp is marked as edited")
+	}
+	path := "/api/chirps/" + chirp.ID.String()
+	// Deleting and restoring touches the chirp without changing its body.
+	doJSON(t, srv, "DELETE", path, alice.Token, nil, nil)
+	doJSON(t, srv, "POST", path+"/restore", alice.Token, nil, nil)
+
+	if code := doJSON(t, srv, "PUT", path, bob.Token, map[string]string{"body": "mine now"}, nil); code != http.StatusForbidden {
+		t.Errorf("edit by non-author: got status %d, want %d", code, http.StatusForbidden)
+	}
+
+	var edited Chirp
+	if code := doJSON(t, srv, "PUT", path, alice.Token, map[string]string{"body": "first kerfuffle #fixed"}, &edited); code != http.StatusOK {
+		t.Fatalf("PUT %s: got status %d", path, code)
+	}
+	if edited.Body != "first **** #fixed" || !edited.Edited || !edited.UpdatedAt.After(chirp.UpdatedAt) {
+		t.Errorf("unexpected edited chirp: %+v", edited)
+	}
+
+	var tagged chirpsPage
+	doJSON(t, srv, "GET", "/api/tags/typo/chirps", "", nil, &tagged)
+	if len(tagged.Chirps) != 0 {
+		t.Error("edited chirp is still listed under its old hashtag")
+	}
+	doJSON(t, srv, "GET", "/api/tags/fixed/chirps", "", nil, &tagged)
+	if len(tagged.Chirps) != 1 {
+		t.Error("edited chirp is not listed under its new hashtag")
+	}
+
+	var history historyResponse
+	if code := doJSON(t, srv, "GET", path+"/history", "", nil, &history); code != http.StatusOK {
+		t.Fatalf("GET history: got status %d", code)
+	}
+	if len(history.Revisions) != 2 || history.Revisions[0].Body != "frist #typo" || !history.Revisions[1].Current {
+		t.Fatalf("unexpected history: %+v", history.Revisions)
+	}
+	if !history.Revisions[0].CreatedAt.Equal(chirp.CreatedAt) {
+		t.Errorf("first revision dated %v, chirp was posted at %v", history.Revisions[0].CreatedAt, chirp.CreatedAt)
+	}
+	if !history.Revisions[1].CreatedAt.After(chirp.CreatedAt) {
+		t.Errorf("current revision dated %v, before the edit", history.Revisions[1].CreatedAt)
+	}
+}
+
+func TestEditChirp_WindowClosed(t *testing.T) {
+	srv := newTestServerWithConfig(t, func(cfg *apiConfig) { cfg.editWindow = 0 })
+	alice := signUp(t, srv, "alice@example.com")
+
+	var chirp Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "too late"}, &chirp)
+	if code := doJSON(t, srv, "PUT", "/api/chirps/"+chirp.ID.String(), alice.Token, map[string]string{"body": "edit"}, nil); code != http.StatusForbidden {
+		t.Errorf("edit after window: got status %d, want %d", code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Follow struct {
+	UserID     uuid.UUID `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type followsPage struct {
+	Users      []Follow `json:"users"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+func followCursor(f Follow) pageCursor {
+	return pageCursor{CreatedAt: f.FollowedAt, ID: f.UserID}
+}
+
+func (cfg *apiConfig) followHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	targetID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	if targetID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't follow yourself", nil)
+		return
+	}
+
+	_, err = cfg.db.GetUserByID(r.Context(), targetID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "User not found", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't look up user", err)
+		return
+	}
+
+	err = cfg.db.FollowUser(r.Context(), database.FollowUserParams{
+		FollowerID: userID,
+		FolloweeID: targetID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't follow user", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unfollowHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	targetID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	err = cfg.db.UnfollowUser(r.Context(), database.UnfollowUserParams{
+		FollowerID: userID,
+		FolloweeID: targetID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unfollow user", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getFollowersHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, true)
+}
+
+func (cfg *apiConfig) getFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.listFollows(w, r, false)
+}
+
+// listFollows serves both directions of the follow graph, newest edge first.
+func (cfg *apiConfig) listFollows(w http.ResponseWriter, r *http.Request, followers bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	page, err := parsePageParams(r.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if !page.Desc {
+		respondWithError(w, http.StatusBadRequest, "only sort=desc is supported", nil)
+		return
+	}
+
+	follows := []Follow{}
+	if followers {
+		rows, err := cfg.db.ListFollowers(r.Context(), database.ListFollowersParams{
+			UserID:          userID,
+			CursorCreatedAt: page.cursorTime(),
+			CursorID:        page.cursorID(),
+			Limit:           page.queryLimit(),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error fetching followers", err)
+			return
+		}
+		for _, row := range rows {
+			follows = append(follows, Follow{UserID: row.UserID, FollowedAt: row.CreatedAt})
+		}
+	} else {
+		rows, err := cfg.db.ListFollowing(r.Context(), database.ListFollowingParams{
+			UserID:          userID,
+			CursorCreatedAt: page.cursorTime(),
+			CursorID:        page.cursorID(),
+			Limit:           page.queryLimit(),
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error fetching followed users", err)
+			return
+		}
+		for _, row := range rows {
+			follows = append(follows, Follow{UserID: row.UserID, FollowedAt: row.CreatedAt})
+		}
+	}
+
+	follows, next := trimPage(page, follows, followCursor)
+	setLinkHeader(w, r, next)
+	respondWithJSON(w, http.StatusOK, followsPage{Users: follows, NextCursor: next})
+}
+
+func (cfg *apiConfig) getFeedHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	page, err := parsePageParams(r.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if !page.Desc {
+		respondWithError(w, http.StatusBadRequest, "only sort=desc is supported", nil)
+		return
+	}
+
+	chirps, err := cfg.db.GetFeed(r.Context(), database.GetFeedParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorTime(),
+		CursorID:        page.cursorID(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching feed", err)
+		return
+	}
+
+	chirps, next := trimPage(page, chirps, chirpCursor)
+	apiChirps, err := cfg.presentChirps(r, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	setLinkHeader(w, r, next)
+	respondWithJSON(w, http.StatusOK, chirpsPage{
+		Chirps:     apiChirps,
+		NextCursor: next,
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFeed_IncludesFollowedUsers(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+	carol := signUp(t, srv, "carol@example.com")
+
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "from alice"}, nil)
+	doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]string{"body": "from bob"}, nil)
+	doJSON(t, srv, "POST", "/api/chirps", carol.Token, map[string]string{"body": "from carol"}, nil)
+
+	if code := doJSON(t, srv, "POST", "/api/users/"+bob.ID.String()+"/follow", alice.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("follow: got status %d", code)
+	}
+	// Following twice is a no-op.
+	if code := doJSON(t, srv, "POST", "/api/users/"+bob.ID.String()+"/follow", alice.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("repeat follow: got status %d", code)
+	}
+
+	var feed chirpsPage
+	if code := doJSON(t, srv, "GET", "/api/feed", alice.Token, nil, &feed); code != http.StatusOK {
+		t.Fatalf("GET /api/feed: got status %d", code)
+	}
+	if len(feed.Chirps) != 2 || feed.Chirps[0].Body != "from bob" || feed.Chirps[1].Body != "from alice" {
+		t.Errorf("unexpected feed: %+v", feed.Chirps)
+	}
+
+	var followers followsPage
+	doJSON(t, srv, "GET", "/api/users/"+bob.ID.String()+"/followers", "", nil, &followers)
+	if len(followers.Users) != 1 || followers.Users[0].UserID != alice.ID {
+		t.Errorf("unexpected followers: %+v", followers.Users)
+	}
+
+	doJSON(t, srv, "DELETE", "/api/users/"+bob.ID.String()+"/follow", alice.Token, nil, nil)
+	doJSON(t, srv, "GET", "/api/feed", alice.Token, nil, &feed)
+	if len(feed.Chirps) != 1 {
+		t.Errorf("feed still includes unfollowed user: %+v", feed.Chirps)
+	}
+}
+
+func TestFollow_Self(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	if code := doJSON(t, srv, "POST", "/api/users/"+alice.ID.String()+"/follow", alice.Token, nil, nil); code != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d", code, http.StatusBadRequest)
+	}
+}
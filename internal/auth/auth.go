@@ -1,16 +1,15 @@
 package auth
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -32,36 +31,6 @@ func CheckPasswordHash(hash, password string) error {
 
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject:   userID.String(),
-	})
-	signed, err := token.SignedString([]byte(tokenSecret))
-	if err != nil {
-		fmt.Println("An error occured in MakeJWT():", err)
-	}
-	return signed, nil
-}
-
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claims := &jwt.RegisteredClaims{}
-	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(tokenSecret), nil
-	})
-	if err != nil {
-		return uuid.UUID{}, err
-	}
-
-	subject, err := uuid.Parse(claims.Subject)
-	if err != nil {
-		return uuid.UUID{}, err
-	}
-	return subject, nil
-}
-
 func GetBearerToken(headers http.Header) (string, error) {
 	authHeader := headers.Get("Authorization")
 	if authHeader == "" {
@@ -81,10 +50,23 @@ func MakeRefreshToken() (string, error) {
 	return hex.EncodeToString(key), nil
 }
 
+// HashRefreshToken is the keyed hash refresh tokens are stored and looked
+// up by, so a copy of the database holds nothing that can be presented as a
+// token. Changing the key invalidates every refresh token.
+func HashRefreshToken(token string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 func GetAPIKey(headers http.Header) (string, error) {
-	authValues, exists := headers["Authorization"]
-	if !exists {
-		return "", errors.New("No Authorization header found")
+	authHeader := headers.Get("Authorization")
+	if authHeader == "" {
+		return "", ErrNoAuthHeaderIncluded
+	}
+	apiKey, ok := strings.CutPrefix(authHeader, "ApiKey ")
+	if !ok || apiKey == "" {
+		return "", errors.New("malformed authorization header")
 	}
-	return strings.Split(authValues[0], " ")[1], nil
+	return apiKey, nil
 }
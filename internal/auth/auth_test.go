@@ -3,9 +3,7 @@ package auth
 import (
 	"net/http"
 	"testing"
-	"time"
 
-	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -62,43 +60,39 @@ func TestCheckPasswordHash_InvalidHash(t *testing.T) {
 	}
 }
 
-func TestMakeJWTAndValidateJWT_Success(t *testing.T) {
-	userID := uuid.New()
-	secret := "supersecret"
-	expiresIn := time.Minute * 5
-
-	token, err := MakeJWT(userID, secret, expiresIn)
-	if err != nil {
-		t.Fatalf("MakeJWT returned error: %v", err)
-	}
-	if token == "" {
-		t.Fatal("MakeJWT returned empty token")
+func TestGetBearerToken(t *testing.T) {
+	headers := http.Header{}
+	headers["Authorization"] = []string{"Bearer 12345"}
+	tokenString, err := GetBearerToken(headers)
+	if err != nil || tokenString != "12345" {
+		t.Errorf("Failed to get Bearer token. Err: %v", err)
 	}
+}
 
-	parsedID, err := ValidateJWT(token, secret)
-	if err != nil {
-		t.Fatalf("ValidateJWT returned error: %v", err)
+func TestGetAPIKey(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Authorization", "ApiKey 12345")
+	if apiKey, err := GetAPIKey(headers); err != nil || apiKey != "12345" {
+		t.Errorf("GetAPIKey = %q, %v", apiKey, err)
 	}
-	if parsedID != userID {
-		t.Errorf("ValidateJWT returned wrong userID: got %v, want %v", parsedID, userID)
+	for _, value := range []string{"", "ApiKey", "ApiKey ", "12345", "Bearer 12345"} {
+		headers.Set("Authorization", value)
+		if apiKey, err := GetAPIKey(headers); err == nil {
+			t.Errorf("GetAPIKey accepted %q as %q", value, apiKey)
+		}
 	}
 }
 
-func TestValidateJWT_InvalidToken(t *testing.T) {
-	secret := "supersecret"
-	invalidToken := "this.is.not.a.valid.jwt"
-
-	_, err := ValidateJWT(invalidToken, secret)
-	if err == nil {
-		t.Error("ValidateJWT did not fail for invalid token")
+func TestHashRefreshToken(t *testing.T) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatal(err)
 	}
-}
-
-func TestGetBearerToken(t *testing.T) {
-	headers := http.Header{}
-	headers["Authorization"] = []string{"Bearer 12345"}
-	tokenString, err := GetBearerToken(headers)
-	if err != nil || tokenString != "12345" {
-		t.Errorf("Failed to get Bearer token. Err: %v", err)
+	hash := HashRefreshToken(token, []byte("key"))
+	if hash == token || hash != HashRefreshToken(token, []byte("key")) {
+		t.Errorf("HashRefreshToken(%q) = %q", token, hash)
+	}
+	if hash == HashRefreshToken(token, []byte("other key")) {
+		t.Error("hash doesn't depend on the key")
 	}
 }
@@ -0,0 +1,359 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// minRSABits is the smallest RSA modulus accepted for signing or verifying.
+const minRSABits = 2048
+
+const issuer = "chirpy"
+
+var (
+	ErrNoSigningKey = errors.New("no signing key is active")
+	ErrUnknownKey   = errors.New("token was signed with an unknown or retired key")
+	ErrRevoked      = errors.New("token has been revoked")
+)
+
+// Denylist holds access tokens revoked before they expire. It is consulted
+// on every validation, so lookups should not touch the network.
+type Denylist interface {
+	Revoked(claims *Claims) bool
+}
+
+// Key is one entry of a KeySet. Asymmetric keys are published in the JWKS
+// so other services can verify tokens without holding anything secret.
+type Key struct {
+	ID string
+	// ActiveFrom is when the key starts signing tokens. Before then it is
+	// only used, and published, for verification, so verifiers have it
+	// cached by the time the first token signed with it shows up.
+	ActiveFrom time.Time
+	// RetireAt is when tokens signed with the key stop being accepted and
+	// it drops out of the JWKS. Zero means never. It should be at least one
+	// token lifetime after the next key becomes active.
+	RetireAt time.Time
+
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+// NewKey makes a signing key from an RSA or Ed25519 private key. RSA keys
+// sign with RS256 and Ed25519 keys with EdDSA.
+func NewKey(id string, private crypto.PrivateKey) (Key, error) {
+	switch k := private.(type) {
+	case *rsa.PrivateKey:
+		key, err := NewVerificationKey(id, &k.PublicKey)
+		key.signKey = k
+		return key, err
+	case ed25519.PrivateKey:
+		key, err := NewVerificationKey(id, k.Public())
+		key.signKey = k
+		return key, err
+	default:
+		return Key{}, fmt.Errorf("key %q: unsupported private key type %T", id, private)
+	}
+}
+
+// NewVerificationKey makes a key that only verifies tokens, such as one
+// whose private half has already been destroyed.
+func NewVerificationKey(id string, public crypto.PublicKey) (Key, error) {
+	if id == "" {
+		return Key{}, errors.New("asymmetric keys need an ID")
+	}
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSABits {
+			return Key{}, fmt.Errorf("key %q: RSA keys must be at least %d bits", id, minRSABits)
+		}
+		return Key{ID: id, method: jwt.SigningMethodRS256, verifyKey: k}, nil
+	case ed25519.PublicKey:
+		return Key{ID: id, method: jwt.SigningMethodEdDSA, verifyKey: k}, nil
+	default:
+		return Key{}, fmt.Errorf("key %q: unsupported public key type %T", id, public)
+	}
+}
+
+// NewHMACKey makes an HS256 key from a shared secret. It is never
+// published, so only holders of the secret can verify its tokens. Tokens
+// signed before kid headers existed carry none, so they match an HMAC key
+// with an empty ID.
+func NewHMACKey(id string, secret []byte) Key {
+	return Key{ID: id, method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// ParsePEMKey reads a PKCS #8 or PKCS #1 private key, or a PKIX public key,
+// from the first PEM block in data.
+func ParsePEMKey(id string, data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("key %q: no PEM block found", id)
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, fmt.Errorf("key %q: %w", id, err)
+		}
+		return NewKey(id, private)
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, fmt.Errorf("key %q: %w", id, err)
+		}
+		return NewKey(id, private)
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return Key{}, fmt.Errorf("key %q: %w", id, err)
+		}
+		return NewVerificationKey(id, public)
+	default:
+		return Key{}, fmt.Errorf("key %q: unsupported PEM block %q", id, block.Type)
+	}
+}
+
+// Algorithm is the JWS alg the key signs and verifies with.
+func (k Key) Algorithm() string {
+	return k.method.Alg()
+}
+
+func (k Key) canSign() bool {
+	return k.signKey != nil
+}
+
+func (k Key) retired(at time.Time) bool {
+	return !k.RetireAt.IsZero() && !at.Before(k.RetireAt)
+}
+
+// KeySet signs access tokens with its active key and verifies them with
+// any key that hasn't been retired, picked by the token's kid header.
+type KeySet struct {
+	keys     []Key
+	now      func() time.Time
+	denylist Denylist
+}
+
+func NewKeySet(keys ...Key) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("a key set needs at least one key")
+	}
+	seen := map[string]bool{}
+	for _, k := range keys {
+		if k.method == nil {
+			return nil, fmt.Errorf("key %q was not made with a constructor", k.ID)
+		}
+		if seen[k.ID] {
+			return nil, fmt.Errorf("duplicate key ID %q", k.ID)
+		}
+		seen[k.ID] = true
+		if !k.RetireAt.IsZero() && !k.RetireAt.After(k.ActiveFrom) {
+			return nil, fmt.Errorf("key %q retires before it becomes active", k.ID)
+		}
+	}
+	return &KeySet{keys: keys, now: time.Now}, nil
+}
+
+// SetDenylist makes ValidateJWT and ParseJWT reject the tokens d holds.
+func (ks *KeySet) SetDenylist(d Denylist) {
+	ks.denylist = d
+}
+
+// keyFile is one entry of the file LoadKeySet reads. Exactly one of
+// PrivateKeyFile and PublicKeyFile is set; relative paths are resolved
+// against the directory of the key set file.
+type keyFile struct {
+	ID             string    `json:"kid"`
+	PrivateKeyFile string    `json:"private_key_file"`
+	PublicKeyFile  string    `json:"public_key_file"`
+	ActiveFrom     time.Time `json:"active_from"`
+	RetireAt       time.Time `json:"retire_at"`
+}
+
+// LoadKeySet reads a JSON key set file listing PEM key files and when each
+// key is used:
+//
+//	{"keys": [
+//		{"kid": "2026-09", "private_key_file": "2026-09.pem", "retire_at": "2026-10-02T00:00:00Z"},
+//		{"kid": "2026-10", "private_key_file": "2026-10.pem", "active_from": "2026-10-01T00:00:00Z"}
+//	]}
+//
+// Rotating a key is adding the next one with a future active_from and
+// giving the current one a retire_at, then restarting.
+func LoadKeySet(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Keys []keyFile `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	keys := make([]Key, 0, len(file.Keys))
+	for _, kf := range file.Keys {
+		name := kf.PrivateKeyFile
+		if (name == "") == (kf.PublicKeyFile == "") {
+			return nil, fmt.Errorf("%s: key %q needs exactly one of private_key_file and public_key_file", path, kf.ID)
+		}
+		if name == "" {
+			name = kf.PublicKeyFile
+		}
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		pemData, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParsePEMKey(kf.ID, pemData)
+		if err != nil {
+			return nil, err
+		}
+		if kf.PrivateKeyFile != "" && !key.canSign() {
+			return nil, fmt.Errorf("%s: %s holds no private key", path, kf.PrivateKeyFile)
+		}
+		if kf.PublicKeyFile != "" && key.canSign() {
+			return nil, fmt.Errorf("%s: %s holds a private key", path, kf.PublicKeyFile)
+		}
+		key.ActiveFrom = kf.ActiveFrom
+		key.RetireAt = kf.RetireAt
+		keys = append(keys, key)
+	}
+	return NewKeySet(keys...)
+}
+
+// signingKey is the most recently activated key that can sign and hasn't
+// been retired. Ties go to the key listed last.
+func (ks *KeySet) signingKey(at time.Time) (Key, error) {
+	var best *Key
+	for i, k := range ks.keys {
+		if !k.canSign() || k.ActiveFrom.After(at) || k.retired(at) {
+			continue
+		}
+		if best == nil || !k.ActiveFrom.Before(best.ActiveFrom) {
+			best = &ks.keys[i]
+		}
+	}
+	if best == nil {
+		return Key{}, ErrNoSigningKey
+	}
+	return *best, nil
+}
+
+// verificationKeys are the keys tokens may be signed with at a given time,
+// including ones that haven't started signing yet.
+func (ks *KeySet) verificationKeys(at time.Time) []Key {
+	var keys []Key
+	for _, k := range ks.keys {
+		if !k.retired(at) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Check reports whether the set has a key to sign with right now.
+func (ks *KeySet) Check() error {
+	_, err := ks.signingKey(ks.now())
+	return err
+}
+
+// Claims are the claims of an access token.
+type Claims struct {
+	jwt.RegisteredClaims
+	// SessionID is the login the token was issued under, empty for tokens
+	// that weren't.
+	SessionID string `json:"sid,omitempty"`
+}
+
+// MakeSessionJWT issues an access token for userID under sessionID, naming
+// the signing key in the kid header and the session in the sid claim. Each
+// token gets a random jti, by which it can be revoked, so the claims are
+// returned for the caller to record.
+func (ks *KeySet) MakeSessionJWT(userID, sessionID uuid.UUID, expiresIn time.Duration) (string, *Claims, error) {
+	now := ks.now().UTC()
+	key, err := ks.signingKey(now)
+	if err != nil {
+		return "", nil, err
+	}
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			Subject:   userID.String(),
+		},
+	}
+	if sessionID != uuid.Nil {
+		claims.SessionID = sessionID.String()
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	signed, err := token.SignedString(key.signKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
+// ValidateJWT checks an access token and returns its subject.
+func (ks *KeySet) ValidateJWT(tokenString string) (uuid.UUID, error) {
+	claims, err := ks.ParseJWT(tokenString)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	return uuid.Parse(claims.Subject)
+}
+
+// ParseJWT checks an access token against the key its kid header names and
+// the denylist, and returns its claims. The token's alg must be the one
+// that key uses, so an RSA public key can never be mistaken for an HMAC
+// secret.
+func (ks *KeySet) ParseJWT(tokenString string) (*Claims, error) {
+	now := ks.now()
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		for _, k := range ks.verificationKeys(now) {
+			if k.ID != kid {
+				continue
+			}
+			if token.Method.Alg() != k.Algorithm() {
+				return nil, fmt.Errorf("key %q does not use %s", kid, token.Method.Alg())
+			}
+			return k.verifyKey, nil
+		}
+		return nil, ErrUnknownKey
+	},
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(func() time.Time { return now }),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if ks.denylist != nil && ks.denylist.Revoked(claims) {
+		return nil, ErrRevoked
+	}
+	return claims, nil
+}
@@ -0,0 +1,352 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func ed25519Key(t *testing.T, id string) Key {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewKey(id, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func rsaKey(t *testing.T, id string) Key {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewKey(id, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// makeJWT issues a token outside any session.
+func makeJWT(ks *KeySet, userID uuid.UUID, expiresIn time.Duration) (string, error) {
+	token, _, err := ks.MakeSessionJWT(userID, uuid.Nil, expiresIn)
+	return token, err
+}
+
+func TestKeySet_SignAndVerify(t *testing.T) {
+	for _, key := range []Key{ed25519Key(t, "ed"), rsaKey(t, "rsa"), NewHMACKey("", []byte("secret"))} {
+		ks, err := NewKeySet(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		userID := uuid.New()
+		token, err := makeJWT(ks, userID, time.Minute)
+		if err != nil {
+			t.Fatalf("%s: MakeJWT returned error: %v", key.Algorithm(), err)
+		}
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kid, _ := parsed.Header["kid"].(string); kid != key.ID {
+			t.Errorf("%s: kid = %q, want %q", key.Algorithm(), kid, key.ID)
+		}
+		got, err := ks.ValidateJWT(token)
+		if err != nil || got != userID {
+			t.Errorf("%s: ValidateJWT = %v, %v", key.Algorithm(), got, err)
+		}
+	}
+}
+
+func TestKeySet_SessionID(t *testing.T) {
+	ks, err := NewKeySet(ed25519Key(t, "ed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, sessionID := uuid.New(), uuid.New()
+	token, issued, err := ks.MakeSessionJWT(userID, sessionID, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ks.ParseJWT(token)
+	if err != nil || claims.Subject != userID.String() || claims.SessionID != sessionID.String() {
+		t.Errorf("ParseJWT = %+v, %v", claims, err)
+	}
+	if claims.ID == "" || claims.ID != issued.ID {
+		t.Errorf("jti = %q, issued with %q", claims.ID, issued.ID)
+	}
+
+	token, err = makeJWT(ks, userID, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := ks.ParseJWT(token); err != nil || claims.SessionID != "" {
+		t.Errorf("token without a session: %+v, %v", claims, err)
+	}
+}
+
+type denylist map[string]bool
+
+func (d denylist) Revoked(claims *Claims) bool {
+	return d[claims.ID]
+}
+
+func TestKeySet_Denylist(t *testing.T) {
+	ks, err := NewKeySet(ed25519Key(t, "ed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked := denylist{}
+	ks.SetDenylist(revoked)
+	first, claims, err := ks.MakeSessionJWT(uuid.New(), uuid.Nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := makeJWT(ks, uuid.New(), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revoked[claims.ID] = true
+	if _, err := ks.ValidateJWT(first); !errors.Is(err, ErrRevoked) {
+		t.Errorf("revoked token: %v", err)
+	}
+	if _, err := ks.ValidateJWT(second); err != nil {
+		t.Errorf("token that wasn't revoked: %v", err)
+	}
+}
+
+func TestKeySet_LegacyTokens(t *testing.T) {
+	ks, err := NewKeySet(NewHMACKey("", []byte("supersecret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tokens from before key rotation are HS256 and have no kid.
+	userID := uuid.New()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    "chirpy",
+		Subject:   userID.String(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}).SignedString([]byte("supersecret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ks.ValidateJWT(token); err != nil || got != userID {
+		t.Errorf("ValidateJWT of a token without kid = %v, %v", got, err)
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	old := ed25519Key(t, "old")
+	old.RetireAt = start.Add(2 * time.Hour)
+	next := ed25519Key(t, "next")
+	next.ActiveFrom = start.Add(time.Hour)
+	ks, err := NewKeySet(old, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(d time.Duration) { ks.now = func() time.Time { return start.Add(d) } }
+	kidOf := func(token string) string {
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &jwt.RegisteredClaims{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed.Header["kid"].(string)
+	}
+
+	at(0)
+	before, err := makeJWT(ks, uuid.New(), 100*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := kidOf(before); kid != "old" {
+		t.Errorf("before rotation signed with %q", kid)
+	}
+	if n := len(ks.JWKS().Keys); n != 2 {
+		t.Errorf("the next key should be published before it signs, got %d keys", n)
+	}
+
+	at(90 * time.Minute)
+	after, err := makeJWT(ks, uuid.New(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := kidOf(after); kid != "next" {
+		t.Errorf("after rotation signed with %q", kid)
+	}
+	if _, err := ks.ValidateJWT(before); err != nil {
+		t.Errorf("token signed with the old key rejected before it retired: %v", err)
+	}
+
+	at(2*time.Hour + time.Minute)
+	if _, err := ks.ValidateJWT(before); err == nil {
+		t.Error("token signed with a retired key was accepted")
+	}
+	if _, err := ks.ValidateJWT(after); err != nil {
+		t.Errorf("token signed with the new key rejected: %v", err)
+	}
+	if keys := ks.JWKS().Keys; len(keys) != 1 || keys[0].KeyID != "next" {
+		t.Errorf("JWKS after retirement: %+v", keys)
+	}
+
+	at(3 * time.Hour)
+	retired := old
+	retired.ActiveFrom = start.Add(-time.Hour)
+	ks2, _ := NewKeySet(retired)
+	ks2.now = ks.now
+	if _, err := makeJWT(ks2, uuid.New(), time.Minute); err != ErrNoSigningKey {
+		t.Errorf("signing with only retired keys: %v", err)
+	}
+}
+
+func TestKeySet_RejectsAlgorithmConfusion(t *testing.T) {
+	key := rsaKey(t, "rsa")
+	ks, err := NewKeySet(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An HS256 token keyed with the RSA public key's bytes must not verify.
+	pub := x509.MarshalPKCS1PublicKey(key.verifyKey.(*rsa.PublicKey))
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    "chirpy",
+		Subject:   uuid.New().String(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	})
+	token.Header["kid"] = "rsa"
+	signed, err := token.SignedString(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ValidateJWT(signed); err == nil {
+		t.Error("HS256 token accepted for an RSA key")
+	}
+	if _, err := ks.ValidateJWT("this.is.not.a.valid.jwt"); err == nil {
+		t.Error("ValidateJWT did not fail for an invalid token")
+	}
+
+	unknown := ed25519Key(t, "other")
+	other, _ := NewKeySet(unknown)
+	signed, err = makeJWT(other, uuid.New(), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ValidateJWT(signed); err == nil {
+		t.Error("token with an unknown kid accepted")
+	}
+}
+
+func TestJWKS_VerifiesTokens(t *testing.T) {
+	for _, signer := range []Key{ed25519Key(t, "ed"), rsaKey(t, "rsa")} {
+		ks, err := NewKeySet(NewHMACKey("legacy", []byte("secret")), signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		set := ks.JWKS()
+		if len(set.Keys) != 1 {
+			t.Fatalf("JWKS should only hold the public key: %+v", set.Keys)
+		}
+		jwk := set.Keys[0]
+		userID := uuid.New()
+		token, err := makeJWT(ks, userID, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Verify the way a downstream service would, from the JWK alone.
+		claims := &jwt.RegisteredClaims{}
+		_, err = jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (any, error) {
+			switch jwk.KeyType {
+			case "OKP":
+				x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+				return ed25519.PublicKey(x), err
+			case "RSA":
+				n, _ := base64.RawURLEncoding.DecodeString(jwk.N)
+				e, _ := base64.RawURLEncoding.DecodeString(jwk.E)
+				return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+			}
+			return nil, jwt.ErrTokenUnverifiable
+		}, jwt.WithValidMethods([]string{jwk.Algorithm}))
+		if err != nil || claims.Subject != userID.String() {
+			t.Errorf("%s: verifying with the JWK: %v", jwk.Algorithm, err)
+		}
+	}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, blockType string, der []byte) {
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edPrivate)
+	write("current.pem", "PRIVATE KEY", der)
+	rsaPrivate, _ := rsa.GenerateKey(rand.Reader, 2048)
+	der, _ = x509.MarshalPKIXPublicKey(&rsaPrivate.PublicKey)
+	write("old.pub.pem", "PUBLIC KEY", der)
+
+	manifest := `{"keys": [
+		{"kid": "current", "private_key_file": "current.pem", "active_from": "2026-01-01T00:00:00Z"},
+		{"kid": "old", "public_key_file": "old.pub.pem"}
+	]}`
+	path := filepath.Join(dir, "keys.json")
+	os.WriteFile(path, []byte(manifest), 0o600)
+	ks, err := LoadKeySet(path)
+	if err != nil {
+		t.Fatalf("LoadKeySet returned error: %v", err)
+	}
+	if err := ks.Check(); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+	if n := len(ks.JWKS().Keys); n != 2 {
+		t.Errorf("JWKS has %d keys, want 2", n)
+	}
+
+	// A token signed by the old key before its private half was removed
+	// still verifies.
+	oldKey, _ := NewKey("old", rsaPrivate)
+	oldSet, _ := NewKeySet(oldKey)
+	token, err := makeJWT(oldSet, uuid.New(), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ValidateJWT(token); err != nil {
+		t.Errorf("token from the verification-only key rejected: %v", err)
+	}
+
+	bad := `{"keys": [{"kid": "old", "private_key_file": "old.pub.pem"}]}`
+	os.WriteFile(path, []byte(bad), 0o600)
+	if _, err := LoadKeySet(path); err == nil {
+		t.Error("a public key listed as private_key_file was accepted")
+	}
+}
+
+func TestNewKey_RejectsSmallRSA(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKey("small", private); err == nil {
+		t.Error("1024-bit RSA key accepted")
+	}
+}
@@ -7,29 +7,76 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const addUserReplyCounts = `-- name: AddUserReplyCounts :exec
+UPDATE chirps
+SET reply_count = reply_count + counts.replies * $1::int
+FROM (
+    SELECT parent_id, COUNT(*) AS replies FROM chirps
+    WHERE user_id = $2
+    AND parent_id IS NOT NULL
+    AND deleted_at IS NOT DISTINCT FROM $3
+    GROUP BY parent_id
+) counts
+WHERE chirps.id = counts.parent_id
+`
+
+type AddUserReplyCountsParams struct {
+	Delta     int32
+	UserID    uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+func (q *Queries) AddUserReplyCounts(ctx context.Context, arg AddUserReplyCountsParams) error {
+	_, err := q.db.ExecContext(ctx, addUserReplyCounts, arg.Delta, arg.UserID, arg.DeletedAt)
+	return err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+WITH parent AS (
+    UPDATE chirps
+    SET reply_count = reply_count + 1
+    WHERE chirps.id = $3::uuid
+)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, rechirp_of_id, quote_of_id)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3::uuid,
+    $4::uuid,
+    $5::uuid,
+    $6::uuid
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, parent_id, root_id, reply_count, deleted_at, like_count, rechirp_of_id, quote_of_id, edited_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body        string
+	UserID      uuid.UUID
+	ParentID    uuid.NullUUID
+	RootID      uuid.NullUUID
+	RechirpOfID uuid.NullUUID
+	QuoteOfID   uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.ParentID,
+		arg.RootID,
+		arg.RechirpOfID,
+		arg.QuoteOfID,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +84,14 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ParentID,
+		&i.RootID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.EditedAt,
 	)
 	return i, err
 }
@@ -50,18 +105,51 @@ func (q *Queries) DeleteAllChirps(ctx context.Context) error {
 	return err
 }
 
-const deleteChirp = `-- name: DeleteChirp :exec
-DELETE FROM chirps
-WHERE id = $1
+const editChirp = `-- name: EditChirp :one
+WITH revision AS (
+    INSERT INTO chirp_revisions (id, chirp_id, body, created_at)
+    SELECT gen_random_uuid(), chirps.id, chirps.body, COALESCE(chirps.edited_at, chirps.created_at)
+    FROM chirps
+    WHERE chirps.id = $1 AND chirps.deleted_at IS NULL
+)
+UPDATE chirps
+SET body = $2, updated_at = NOW(), edited_at = NOW()
+WHERE chirps.id = $1 AND chirps.deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, parent_id, root_id, reply_count, deleted_at, like_count, rechirp_of_id, quote_of_id, edited_at
 `
 
-func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deleteChirp, id)
-	return err
+type EditChirpParams struct {
+	ID   uuid.UUID
+	Body string
+}
+
+// The old body was written when the chirp was posted or last edited;
+// updated_at also moves on deletes and restores, so it can't say when.
+func (q *Queries) EditChirp(ctx context.Context, arg EditChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, editChirp, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ParentID,
+		&i.RootID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.EditedAt,
+	)
+	return i, err
 }
 
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at
+SELECT id, created_at, updated_at, body, user_id, parent_id, root_id, reply_count, deleted_at, like_count, rechirp_of_id, quote_of_id, edited_at FROM chirps
+WHERE deleted_at IS NULL
+ORDER BY created_at
 `
 
 func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
@@ -79,6 +167,14 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -93,9 +189,35 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
+const getChirpIncludingDeleted = `-- name: GetChirpIncludingDeleted :one
+SELECT id, created_at, updated_at, body, user_id, parent_id, root_id, reply_count, deleted_at, like_count, rechirp_of_id, quote_of_id, edited_at FROM chirps
+WHERE id = $1
+`
+
+func (q *Queries) GetChirpIncludingDeleted(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpIncludingDeleted, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ParentID,
+		&i.RootID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.EditedAt,
+	)
+	return i, err
+}
+
 const getChirpsByID = `-- name: GetChirpsByID :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = $1
+SELECT id, created_at, updated_at, body, user_id, parent_id, root_id, reply_count, deleted_at, like_count, rechirp_of_id, quote_of_id, edited_at FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL
 ORDER BY created_at
 `
 
@@ -114,6 +236,14 @@ func (q *Queries) GetChirpsByID(ctx context.Context, userID uuid.UUID) ([]Chirp,
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -129,8 +259,8 @@ func (q *Queries) GetChirpsByID(ctx context.Context, userID uuid.UUID) ([]Chirp,
 }
 
 const getOneChirps = `-- name: GetOneChirps :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, parent_id, root_id, reply_count, deleted_at, like_count, rechirp_of_id, quote_of_id, edited_at FROM chirps
+WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetOneChirps(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -142,6 +272,414 @@ func (q *Queries) GetOneChirps(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.ParentID,
+		&i.RootID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.EditedAt,
 	)
 	return i, err
 }
+
+const getReplies = `-- name: GetReplies :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, root_id, reply_count, deleted_at, like_count, rechirp_of_id, quote_of_id, edited_at FROM chirps
+WHERE parent_id = $1
+AND (deleted_at IS NULL OR reply_count > 0)
+AND (
+    $2::timestamptz IS NULL
+    OR (created_at, id) > ($2::timestamptz, $3::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT $4
+`
+
+type GetRepliesParams struct {
+	ParentID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetReplies(ctx context.Context, arg GetRepliesParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getReplies,
+		arg.ParentID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getThread = `-- name: GetThread :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, root_id, reply_count, deleted_at, like_count, rechirp_of_id, quote_of_id, edited_at FROM chirps
+WHERE id = $1 OR root_id = $1
+ORDER BY created_at ASC, id ASC
+LIMIT $2
+`
+
+type GetThreadParams struct {
+	RootID uuid.UUID
+	Limit  int32
+}
+
+func (q *Queries) GetThread(ctx context.Context, arg GetThreadParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getThread, arg.RootID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, root_id, reply_count, deleted_at, like_count, rechirp_of_id, quote_of_id, edited_at FROM chirps
+WHERE deleted_at IS NULL
+AND ($1::uuid IS NULL OR user_id = $1::uuid)
+AND (
+    $2::timestamptz IS NULL
+    OR (created_at, id) > ($2::timestamptz, $3::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT $4
+`
+
+type ListChirpsAscParams struct {
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsByIDs = `-- name: ListChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, root_id, reply_count, deleted_at, like_count, rechirp_of_id, quote_of_id, edited_at FROM chirps
+WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) ListChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, parent_id, root_id, reply_count, deleted_at, like_count, rechirp_of_id, quote_of_id, edited_at FROM chirps
+WHERE deleted_at IS NULL
+AND ($1::uuid IS NULL OR user_id = $1::uuid)
+AND (
+    $2::timestamptz IS NULL
+    OR (created_at, id) < ($2::timestamptz, $3::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type ListChirpsDescParams struct {
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.ParentID,
+			&i.RootID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < $1::timestamptz
+AND NOT EXISTS (
+    SELECT 1 FROM chirps replies
+    WHERE replies.parent_id = chirps.id
+)
+`
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+WITH target AS (
+    SELECT id, parent_id, deleted_at FROM chirps
+    WHERE chirps.id = $1 AND chirps.deleted_at > $2::timestamptz
+), rechirps AS (
+    UPDATE chirps
+    SET deleted_at = NULL, updated_at = NOW()
+    FROM target
+    WHERE chirps.rechirp_of_id = target.id
+    AND chirps.deleted_at = target.deleted_at
+    AND NOT EXISTS (
+        SELECT 1 FROM chirps live
+        WHERE live.user_id = chirps.user_id
+        AND live.rechirp_of_id = target.id
+        AND live.deleted_at IS NULL
+    )
+), parent AS (
+    UPDATE chirps
+    SET reply_count = reply_count + 1
+    WHERE chirps.id = (SELECT parent_id FROM target)
+)
+UPDATE chirps
+SET deleted_at = NULL, updated_at = NOW()
+FROM target
+WHERE chirps.id = target.id
+RETURNING chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.parent_id, chirps.root_id, chirps.reply_count, chirps.deleted_at, chirps.like_count, chirps.rechirp_of_id, chirps.quote_of_id, chirps.edited_at
+`
+
+type RestoreChirpParams struct {
+	ID     uuid.UUID
+	Cutoff time.Time
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.Cutoff)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.ParentID,
+		&i.RootID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.EditedAt,
+	)
+	return i, err
+}
+
+const scrubDeletedChirps = `-- name: ScrubDeletedChirps :exec
+WITH expired AS (
+    SELECT id FROM chirps
+    WHERE deleted_at < $1::timestamptz
+), revisions AS (
+    DELETE FROM chirp_revisions
+    WHERE chirp_id IN (SELECT id FROM expired)
+), mentions AS (
+    DELETE FROM chirp_mentions
+    WHERE chirp_id IN (SELECT id FROM expired)
+), tags AS (
+    DELETE FROM chirp_tags
+    WHERE chirp_id IN (SELECT id FROM expired)
+), polls AS (
+    DELETE FROM polls
+    WHERE chirp_id IN (SELECT id FROM expired)
+), bookmarks AS (
+    DELETE FROM bookmarks
+    WHERE chirp_id IN (SELECT id FROM expired)
+)
+UPDATE chirps
+SET body = ''
+WHERE id IN (SELECT id FROM expired) AND body <> ''
+`
+
+func (q *Queries) ScrubDeletedChirps(ctx context.Context, cutoff time.Time) error {
+	_, err := q.db.ExecContext(ctx, scrubDeletedChirps, cutoff)
+	return err
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :exec
+WITH target AS (
+    UPDATE chirps
+    SET deleted_at = NOW(), updated_at = NOW()
+    WHERE chirps.id = $1 AND chirps.deleted_at IS NULL
+    RETURNING parent_id
+), rechirps AS (
+    UPDATE chirps
+    SET deleted_at = NOW(), updated_at = NOW()
+    WHERE rechirp_of_id = $1 AND deleted_at IS NULL
+    RETURNING id
+), pins AS (
+    DELETE FROM pinned_chirps
+    WHERE chirp_id = $1 OR chirp_id IN (SELECT id FROM rechirps)
+)
+UPDATE chirps
+SET reply_count = reply_count - 1
+WHERE chirps.id = (SELECT parent_id FROM target)
+`
+
+func (q *Queries) SoftDeleteChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteChirp, id)
+	return err
+}
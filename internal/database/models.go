@@ -11,21 +11,163 @@ import (
 	"github.com/google/uuid"
 )
 
-type Chirp struct {
+type AccessToken struct {
+	Jti       string
+	UserID    uuid.UUID
+	SessionID uuid.NullUUID
+	ExpiresAt time.Time
+	RevokedAt sql.NullTime
+}
+
+type Bookmark struct {
+	UserID       uuid.UUID
+	ChirpID      uuid.UUID
+	CollectionID uuid.NullUUID
+	CreatedAt    time.Time
+}
+
+type BookmarkCollection struct {
 	ID        uuid.UUID
+	UserID    uuid.UUID
+	Name      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+}
+
+type Chirp struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Body        string
+	UserID      uuid.UUID
+	ParentID    uuid.NullUUID
+	RootID      uuid.NullUUID
+	ReplyCount  int32
+	DeletedAt   sql.NullTime
+	LikeCount   int32
+	RechirpOfID uuid.NullUUID
+	QuoteOfID   uuid.NullUUID
+	EditedAt    sql.NullTime
+}
+
+type ChirpFlag struct {
+	ChirpID   uuid.UUID
+	Words     []string
+	CreatedAt time.Time
+}
+
+type ChirpLike struct {
+	ChirpID   uuid.UUID
 	UserID    uuid.UUID
+	CreatedAt time.Time
 }
 
-type RefreshToken struct {
-	Token     string
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpRevision struct {
+	ID        uuid.UUID
+	ChirpID   uuid.UUID
+	Body      string
+	CreatedAt time.Time
+}
+
+type ChirpTag struct {
+	ChirpID   uuid.UUID
+	TagID     uuid.UUID
+	CreatedAt time.Time
+}
+
+type Draft struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	Body            string
+	ParentID        uuid.NullUUID
+	QuoteOfID       uuid.NullUUID
+	PublishAt       sql.NullTime
+	LastError       string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	PublishAttempts int32
+	RetryAt         sql.NullTime
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type MediaItem struct {
+	ID          uuid.UUID
+	UserID      uuid.NullUUID
+	ChirpID     uuid.NullUUID
+	Position    sql.NullInt16
+	ContentType string
+	SizeBytes   int64
+	Width       int32
+	Height      int32
+	CreatedAt   time.Time
+}
+
+type ModerationRule struct {
+	Word      string
+	Action    string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+}
+
+type Poll struct {
+	ChirpID        uuid.UUID
+	Options        []string
+	MultipleChoice bool
+	HideResults    bool
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
 	UserID    uuid.UUID
-	ExpiresAt time.Time
-	RevokedAt sql.NullTime
+	Choices   []int32
+	CreatedAt time.Time
+}
+
+type RefreshToken struct {
+	Token            sql.NullString
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	UserID           uuid.UUID
+	ExpiresAt        time.Time
+	RevokedAt        sql.NullTime
+	FamilyID         uuid.UUID
+	RotatedAt        sql.NullTime
+	ID               uuid.UUID
+	TokenHash        sql.NullString
+	DeviceName       string
+	UserAgent        string
+	IpAddress        string
+	SessionCreatedAt time.Time
+}
+
+type SecurityEvent struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Kind      string
+	FamilyID  uuid.NullUUID
+	IpAddress string
+	UserAgent string
+	CreatedAt time.Time
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	Name      string
+	CreatedAt time.Time
 }
 
 type User struct {
@@ -35,4 +177,9 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	Handle         sql.NullString
+	DisplayName    string
+	Bio            string
+	DeletedAt      sql.NullTime
+	SuspendedAt    sql.NullTime
 }
@@ -0,0 +1,52 @@
+package database_test
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestListPollVotesByUser(t *testing.T) {
+	inTx(t, func(ctx context.Context, q database.Querier) {
+		user, err := q.CreateUser(ctx, database.CreateUserParams{Email: uuid.NewString() + "@example.com", HashedPassword: "x"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		chirp, err := q.CreateChirp(ctx, database.CreateChirpParams{Body: "Tabs or spaces?", UserID: user.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = q.CreatePoll(ctx, database.CreatePollParams{
+			ChirpID:        chirp.ID,
+			Options:        []string{"Tabs", "Spaces", "Both"},
+			MultipleChoice: true,
+			ExpiresAt:      time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := q.CastPollVote(ctx, database.CastPollVoteParams{UserID: user.ID, Choices: []int32{0, 2}, ChirpID: chirp.ID})
+		if err != nil || n != 1 {
+			t.Fatalf("CastPollVote: %d, %v", n, err)
+		}
+
+		votes, err := q.ListPollVotesByUser(ctx, database.ListPollVotesByUserParams{UserID: user.ID, ChirpIds: []uuid.UUID{chirp.ID}})
+		if err != nil {
+			t.Fatalf("ListPollVotesByUser returned error: %v", err)
+		}
+		if len(votes) != 1 || !slices.Equal(votes[0].Choices, []int32{0, 2}) {
+			t.Errorf("votes: %+v", votes)
+		}
+		tallies, err := q.ListPollTallies(ctx, []uuid.UUID{chirp.ID})
+		if err != nil {
+			t.Fatalf("ListPollTallies returned error: %v", err)
+		}
+		if len(tallies) != 2 || tallies[0].Choice != 0 || tallies[1].Choice != 2 {
+			t.Errorf("tallies: %+v", tallies)
+		}
+	})
+}
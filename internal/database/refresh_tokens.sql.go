@@ -14,26 +14,46 @@ import (
 )
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens (id, token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, device_name, user_agent, ip_address, session_created_at)
 VALUES(
-    $1,
+    gen_random_uuid(),
+    $1::text,
     NOW(),
     NOW(),
     $2,
     $3,
-    NULL
+    NULL,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
 )
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at
+RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at, id, token_hash, device_name, user_agent, ip_address, session_created_at
 `
 
 type CreateRefreshTokenParams struct {
-	Token     string
-	UserID    uuid.UUID
-	ExpiresAt time.Time
+	TokenHash        string
+	UserID           uuid.UUID
+	ExpiresAt        time.Time
+	FamilyID         uuid.UUID
+	DeviceName       string
+	UserAgent        string
+	IpAddress        string
+	SessionCreatedAt time.Time
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.Token, arg.UserID, arg.ExpiresAt)
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.TokenHash,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.FamilyID,
+		arg.DeviceName,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.SessionCreatedAt,
+	)
 	var i RefreshToken
 	err := row.Scan(
 		&i.Token,
@@ -42,17 +62,25 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.ID,
+		&i.TokenHash,
+		&i.DeviceName,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.SessionCreatedAt,
 	)
 	return i, err
 }
 
 const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at FROM refresh_tokens
-WHERE token = $1
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at, id, token_hash, device_name, user_agent, ip_address, session_created_at FROM refresh_tokens
+WHERE token_hash = $1::text
 `
 
-func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, getRefreshToken, token)
+func (q *Queries) GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshToken, tokenHash)
 	var i RefreshToken
 	err := row.Scan(
 		&i.Token,
@@ -61,15 +89,56 @@ func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshTok
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.ID,
+		&i.TokenHash,
+		&i.DeviceName,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.SessionCreatedAt,
+	)
+	return i, err
+}
+
+const getSession = `-- name: GetSession :one
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at, id, token_hash, device_name, user_agent, ip_address, session_created_at FROM refresh_tokens
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+`
+
+type GetSessionParams struct {
+	FamilyID uuid.UUID
+	UserID   uuid.UUID
+}
+
+func (q *Queries) GetSession(ctx context.Context, arg GetSessionParams) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getSession, arg.FamilyID, arg.UserID)
+	var i RefreshToken
+	err := row.Scan(
+		&i.Token,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.ID,
+		&i.TokenHash,
+		&i.DeviceName,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.SessionCreatedAt,
 	)
 	return i, err
 }
 
 const getUserFromRefreshToken = `-- name: GetUserFromRefreshToken :one
-SELECT users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red FROM refresh_tokens
+SELECT users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red, users.handle, users.display_name, users.bio, users.deleted_at, users.suspended_at FROM refresh_tokens
 LEFT JOIN users
 ON users.id = refresh_tokens.user_id
-WHERE refresh_tokens.token = $1
+AND users.deleted_at IS NULL
+WHERE refresh_tokens.token_hash = $1::text
 `
 
 type GetUserFromRefreshTokenRow struct {
@@ -79,10 +148,15 @@ type GetUserFromRefreshTokenRow struct {
 	Email          sql.NullString
 	HashedPassword sql.NullString
 	IsChirpyRed    sql.NullBool
+	Handle         sql.NullString
+	DisplayName    sql.NullString
+	Bio            sql.NullString
+	DeletedAt      sql.NullTime
+	SuspendedAt    sql.NullTime
 }
 
-func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (GetUserFromRefreshTokenRow, error) {
-	row := q.db.QueryRowContext(ctx, getUserFromRefreshToken, token)
+func (q *Queries) GetUserFromRefreshToken(ctx context.Context, tokenHash string) (GetUserFromRefreshTokenRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserFromRefreshToken, tokenHash)
 	var i GetUserFromRefreshTokenRow
 	err := row.Scan(
 		&i.ID,
@@ -91,6 +165,122 @@ func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (Ge
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.DeletedAt,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const listSessions = `-- name: ListSessions :many
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at, id, token_hash, device_name, user_agent, ip_address, session_created_at FROM refresh_tokens
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY created_at DESC, id DESC
+`
+
+// The live refresh token of each of the user's sessions, most recently
+// used first.
+func (q *Queries) ListSessions(ctx context.Context, userID uuid.UUID) ([]RefreshToken, error) {
+	rows, err := q.db.QueryContext(ctx, listSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RefreshToken
+	for rows.Next() {
+		var i RefreshToken
+		if err := rows.Scan(
+			&i.Token,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.FamilyID,
+			&i.RotatedAt,
+			&i.ID,
+			&i.TokenHash,
+			&i.DeviceName,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.SessionCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnhashedRefreshTokens = `-- name: ListUnhashedRefreshTokens :many
+SELECT id, token FROM refresh_tokens
+WHERE token_hash IS NULL
+LIMIT $1
+`
+
+type ListUnhashedRefreshTokensRow struct {
+	ID    uuid.UUID
+	Token sql.NullString
+}
+
+// Tokens issued before refresh tokens were hashed.
+func (q *Queries) ListUnhashedRefreshTokens(ctx context.Context, limit int32) ([]ListUnhashedRefreshTokensRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUnhashedRefreshTokens, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUnhashedRefreshTokensRow
+	for rows.Next() {
+		var i ListUnhashedRefreshTokensRow
+		if err := rows.Scan(&i.ID, &i.Token); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockRefreshToken = `-- name: LockRefreshToken :one
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at, id, token_hash, device_name, user_agent, ip_address, session_created_at FROM refresh_tokens
+WHERE token_hash = $1::text
+FOR UPDATE
+`
+
+// Makes concurrent refreshes with the same token take turns, so only the
+// first rotates it.
+func (q *Queries) LockRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, lockRefreshToken, tokenHash)
+	var i RefreshToken
+	err := row.Scan(
+		&i.Token,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.ID,
+		&i.TokenHash,
+		&i.DeviceName,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.SessionCreatedAt,
 	)
 	return i, err
 }
@@ -98,10 +288,48 @@ func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (Ge
 const revoke = `-- name: Revoke :exec
 UPDATE refresh_tokens
 SET updated_at = NOW(), revoked_at = NOW()
-WHERE token = $1
+WHERE token_hash = $1::text
 `
 
-func (q *Queries) Revoke(ctx context.Context, token string) error {
-	_, err := q.db.ExecContext(ctx, revoke, token)
+func (q *Queries) Revoke(ctx context.Context, tokenHash string) error {
+	_, err := q.db.ExecContext(ctx, revoke, tokenHash)
+	return err
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(), revoked_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	return err
+}
+
+const rotateRefreshToken = `-- name: RotateRefreshToken :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(), rotated_at = NOW(), revoked_at = COALESCE(revoked_at, NOW())
+WHERE token_hash = $1::text
+`
+
+func (q *Queries) RotateRefreshToken(ctx context.Context, tokenHash string) error {
+	_, err := q.db.ExecContext(ctx, rotateRefreshToken, tokenHash)
+	return err
+}
+
+const setRefreshTokenHash = `-- name: SetRefreshTokenHash :exec
+UPDATE refresh_tokens
+SET token_hash = $1::text, token = NULL
+WHERE id = $2
+`
+
+type SetRefreshTokenHashParams struct {
+	TokenHash string
+	ID        uuid.UUID
+}
+
+func (q *Queries) SetRefreshTokenHash(ctx context.Context, arg SetRefreshTokenHashParams) error {
+	_, err := q.db.ExecContext(ctx, setRefreshTokenHash, arg.TokenHash, arg.ID)
 	return err
 }
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Store is the persistence layer the HTTP handlers depend on. SQLStore
+// satisfies it against Postgres and memstore.Store satisfies it in memory,
+// so the API can run without a database in tests and local demos.
+type Store interface {
+	Querier
+	// ExecTx runs fn with a Querier whose writes are committed together if
+	// fn returns nil and discarded otherwise.
+	ExecTx(ctx context.Context, fn func(Querier) error) error
+}
+
+// SQLStore is a Store backed by a *sql.DB.
+type SQLStore struct {
+	*Queries
+	db *sql.DB
+}
+
+var _ Store = (*SQLStore)(nil)
+
+func NewStore(db *sql.DB) *SQLStore {
+	return &SQLStore{Queries: New(db), db: db}
+}
+
+func (s *SQLStore) ExecTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(s.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,49 @@
+package database_test
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/migrate"
+	"chirpy/sql/schema"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+// errRollback ends the transaction inTx runs a test in.
+var errRollback = errors.New("roll back the test's writes")
+
+// inTx runs fn in a transaction against the Postgres database named by
+// TEST_DB_URL, migrated to the latest version, and rolls it back
+// afterwards. The test is skipped when TEST_DB_URL is unset.
+func inTx(t *testing.T, fn func(ctx context.Context, q database.Querier)) {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	m, err := migrate.New(db, schema.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	err = database.NewStore(db).ExecTx(ctx, func(q database.Querier) error {
+		fn(ctx, q)
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package media checks uploaded images and makes thumbnails of them, using
+// only the standard library's image packages.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	// Register the decoders for the formats in contentTypes.
+	_ "image/gif"
+	_ "image/png"
+)
+
+// contentTypes are the sniffed content types that can be uploaded.
+var contentTypes = map[string]bool{
+	"image/gif":  true,
+	"image/jpeg": true,
+	"image/png":  true,
+}
+
+// ThumbnailContentType is the format of every thumbnail.
+const ThumbnailContentType = "image/jpeg"
+
+// MaxPixels bounds the size of a decoded image, so a small file that
+// expands into a huge bitmap can't exhaust memory. A decoded image takes
+// up to four bytes a pixel; making its thumbnail adds only a row's worth.
+const MaxPixels = 24_000_000
+
+var (
+	// ErrUnsupported means the data is not an image in an accepted format.
+	ErrUnsupported = errors.New("unsupported media type")
+	// ErrTooManyPixels means the image is larger than MaxPixels.
+	ErrTooManyPixels = fmt.Errorf("image has more than %d pixels", MaxPixels)
+)
+
+// Image is a decoded upload.
+type Image struct {
+	// ContentType is sniffed from the data, not taken from the client.
+	ContentType string
+	Width       int
+	Height      int
+
+	img image.Image
+}
+
+// Decode sniffs data and decodes it if it is an accepted image. The header
+// is checked before the pixels are decoded.
+func Decode(data []byte) (Image, error) {
+	contentType := http.DetectContentType(data)
+	if !contentTypes[contentType] {
+		return Image{}, ErrUnsupported
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("reading image header: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return Image{}, errors.New("image has no pixels")
+	}
+	if cfg.Width > MaxPixels/cfg.Height {
+		return Image{}, ErrTooManyPixels
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("decoding image: %w", err)
+	}
+	return Image{ContentType: contentType, Width: cfg.Width, Height: cfg.Height, img: img}, nil
+}
+
+// Thumbnail scales the image down to fit in a size×size box, keeping its
+// aspect ratio, and encodes it as a JPEG. Smaller images are not scaled
+// up. Transparent areas are drawn on white.
+func (i Image) Thumbnail(size int) ([]byte, error) {
+	b := i.img.Bounds()
+	w, h := fit(b.Dx(), b.Dy(), size)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, shrink(i.img, w, h), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fit returns the dimensions of a w×h image scaled to fit in a size×size
+// box.
+func fit(w, h, size int) (int, int) {
+	if w <= size && h <= size {
+		return w, h
+	}
+	if w >= h {
+		return size, max(1, h*size/w)
+	}
+	return max(1, w*size/h), size
+}
+
+// shrink scales src down to w×h by averaging the block of source pixels
+// that falls under each destination pixel. Source rows are drawn onto white
+// one at a time, so the image is never copied whole.
+func shrink(src image.Image, w, h int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	white := image.NewUniform(color.White)
+	row := image.NewRGBA(image.Rect(0, 0, sw, 1))
+	sums := make([]int, w*4)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		clear(sums)
+		for sy := y0; sy < y1; sy++ {
+			draw.Draw(row, row.Bounds(), white, image.Point{}, draw.Src)
+			draw.Draw(row, row.Bounds(), src, image.Pt(b.Min.X, b.Min.Y+sy), draw.Over)
+			for x := 0; x < w; x++ {
+				x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+				sum := sums[x*4 : x*4+4]
+				for sx := x0; sx < x1; sx++ {
+					p := row.Pix[sx*4 : sx*4+4]
+					sum[0] += int(p[0])
+					sum[1] += int(p[1])
+					sum[2] += int(p[2])
+					sum[3] += int(p[3])
+				}
+			}
+		}
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+			n := (y1 - y0) * (x1 - x0)
+			d := dst.Pix[y*dst.Stride+x*4:]
+			for c := 0; c < 4; c++ {
+				d[c] = uint8(sums[x*4+c] / n)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,99 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"runtime"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 10))
+	decoded, err := Decode(encodePNG(t, img))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if decoded.ContentType != "image/png" || decoded.Width != 40 || decoded.Height != 10 {
+		t.Errorf("unexpected image: %+v", decoded)
+	}
+
+	if _, err := Decode([]byte("<svg xmlns='http://www.w3.org/2000/svg'/>")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Decode(svg) = %v, want ErrUnsupported", err)
+	}
+	// A PNG signature followed by garbage sniffs as PNG but doesn't decode.
+	if _, err := Decode([]byte("\x89PNG\r\n\x1a\nnot really")); err == nil || errors.Is(err, ErrUnsupported) {
+		t.Errorf("Decode(truncated png) = %v, want a decoding error", err)
+	}
+}
+
+func TestDecode_TooManyPixels(t *testing.T) {
+	// Only the header is read, so the image data can be bogus.
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	// Rewrite the IHDR width and height to 10000×10000 and fix its CRC.
+	copy(data[16:24], []byte{0, 0, 0x27, 0x10, 0, 0, 0x27, 0x10})
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+	if _, err := Decode(data); !errors.Is(err, ErrTooManyPixels) {
+		t.Errorf("Decode = %v, want ErrTooManyPixels", err)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 400, 100))
+	for x := 0; x < 200; x++ {
+		for y := 0; y < 100; y++ {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	decoded, err := Decode(encodePNG(t, img))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := decoded.Thumbnail(100)
+	if err != nil {
+		t.Fatalf("Thumbnail: %v", err)
+	}
+	thumb, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil || format != "jpeg" {
+		t.Fatalf("thumbnail is not a JPEG: %v %s", err, format)
+	}
+	if b := thumb.Bounds(); b.Dx() != 100 || b.Dy() != 25 {
+		t.Errorf("thumbnail is %dx%d, want 100x25", b.Dx(), b.Dy())
+	}
+	// The left half is red and the transparent right half is drawn on white.
+	if r, g, _, _ := thumb.At(10, 10).RGBA(); r>>8 < 200 || g>>8 > 60 {
+		t.Errorf("left half is %v, want red", thumb.At(10, 10))
+	}
+	if r, g, b, _ := thumb.At(90, 10).RGBA(); r>>8 < 200 || g>>8 < 200 || b>>8 < 200 {
+		t.Errorf("right half is %v, want white", thumb.At(90, 10))
+	}
+}
+
+func TestThumbnail_DoesNotCopyImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2000, 2000))
+	decoded := Image{ContentType: "image/png", Width: 2000, Height: 2000, img: img}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := decoded.Thumbnail(100); err != nil {
+		t.Fatal(err)
+	}
+	runtime.ReadMemStats(&after)
+	// A flattened copy alone would be 16 MB.
+	if n := after.TotalAlloc - before.TotalAlloc; n > 2<<20 {
+		t.Errorf("Thumbnail allocated %d bytes", n)
+	}
+}
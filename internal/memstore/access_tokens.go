@@ -0,0 +1,75 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"chirpy/internal/database"
+)
+
+func (s *Store) CreateAccessToken(ctx context.Context, arg database.CreateAccessTokenParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.accessTokens[arg.Jti]; ok {
+		return uniqueErr("access_tokens_pkey", "Key (jti) already exists.")
+	}
+	if _, ok := s.users[arg.UserID]; !ok {
+		return foreignKeyErr("access_tokens_user_id_fkey")
+	}
+	put(s, s.accessTokens, arg.Jti, database.AccessToken{
+		Jti:       arg.Jti,
+		UserID:    arg.UserID,
+		SessionID: arg.SessionID,
+		ExpiresAt: arg.ExpiresAt,
+	})
+	return nil
+}
+
+func (s *Store) RevokeUserAccessTokens(ctx context.Context, arg database.RevokeUserAccessTokensParams) ([]database.RevokeUserAccessTokensRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := now()
+	var rows []database.RevokeUserAccessTokensRow
+	for jti, at := range s.accessTokens {
+		if at.UserID != arg.UserID || at.RevokedAt.Valid || !at.ExpiresAt.After(t) {
+			continue
+		}
+		if arg.KeepSessionID.Valid && at.SessionID == arg.KeepSessionID {
+			continue
+		}
+		if arg.SessionID.Valid && at.SessionID != arg.SessionID {
+			continue
+		}
+		at.RevokedAt = sql.NullTime{Time: t, Valid: true}
+		put(s, s.accessTokens, jti, at)
+		rows = append(rows, database.RevokeUserAccessTokensRow{Jti: jti, ExpiresAt: at.ExpiresAt})
+	}
+	return rows, nil
+}
+
+func (s *Store) ListRevokedAccessTokens(ctx context.Context) ([]database.ListRevokedAccessTokensRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t := now()
+	var rows []database.ListRevokedAccessTokensRow
+	for jti, at := range s.accessTokens {
+		if at.RevokedAt.Valid && at.ExpiresAt.After(t) {
+			rows = append(rows, database.ListRevokedAccessTokensRow{Jti: jti, ExpiresAt: at.ExpiresAt})
+		}
+	}
+	return rows, nil
+}
+
+func (s *Store) DeleteExpiredAccessTokens(ctx context.Context, expiresAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for jti, at := range s.accessTokens {
+		if !at.ExpiresAt.After(expiresAt) {
+			del(s, s.accessTokens, jti)
+			n++
+		}
+	}
+	return n, nil
+}
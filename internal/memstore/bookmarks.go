@@ -0,0 +1,209 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type bookmarkKey struct {
+	user  uuid.UUID
+	chirp uuid.UUID
+}
+
+func (s *Store) BookmarkChirp(ctx context.Context, arg database.BookmarkChirpParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if arg.CollectionID.Valid {
+		c, ok := s.collections[arg.CollectionID.UUID]
+		if !ok || c.UserID != arg.UserID {
+			return 0, nil
+		}
+	}
+	if _, ok := s.users[arg.UserID]; !ok {
+		return 0, foreignKeyErr("bookmarks_user_id_fkey")
+	}
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return 0, foreignKeyErr("bookmarks_chirp_id_fkey")
+	}
+	key := bookmarkKey{user: arg.UserID, chirp: arg.ChirpID}
+	b, ok := s.bookmarks[key]
+	if !ok {
+		b = database.Bookmark{UserID: arg.UserID, ChirpID: arg.ChirpID, CreatedAt: now()}
+	}
+	b.CollectionID = arg.CollectionID
+	put(s, s.bookmarks, key, b)
+	return 1, nil
+}
+
+func (s *Store) UnbookmarkChirp(ctx context.Context, arg database.UnbookmarkChirpParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := bookmarkKey{user: arg.UserID, chirp: arg.ChirpID}
+	if _, ok := s.bookmarks[key]; !ok {
+		return 0, nil
+	}
+	del(s, s.bookmarks, key)
+	return 1, nil
+}
+
+func (s *Store) GetBookmarkedChirpIDs(ctx context.Context, arg database.GetBookmarkedChirpIDsParams) ([]uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var ids []uuid.UUID
+	for _, id := range arg.ChirpIds {
+		if _, ok := s.bookmarks[bookmarkKey{user: arg.UserID, chirp: id}]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (s *Store) ListBookmarks(ctx context.Context, arg database.ListBookmarksParams) ([]database.ListBookmarksRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.ListBookmarksRow
+	for key, b := range s.bookmarks {
+		if key.user != arg.UserID {
+			continue
+		}
+		if arg.CollectionID.Valid && b.CollectionID != arg.CollectionID {
+			continue
+		}
+		c, ok := s.chirps[key.chirp]
+		if !ok || c.DeletedAt.Valid {
+			continue
+		}
+		rows = append(rows, database.ListBookmarksRow{Chirp: c, BookmarkedAt: b.CreatedAt})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return compareKeys(rows[i].BookmarkedAt, rows[i].Chirp.ID, rows[j].BookmarkedAt, rows[j].Chirp.ID) < 0
+	})
+	key := func(r database.ListBookmarksRow) (time.Time, uuid.UUID) { return r.BookmarkedAt, r.Chirp.ID }
+	return page(rows, key, arg.CursorCreatedAt, arg.CursorID, arg.Limit, true), nil
+}
+
+func (s *Store) CreateBookmarkCollection(ctx context.Context, arg database.CreateBookmarkCollectionParams) (database.BookmarkCollection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.BookmarkCollection{}, foreignKeyErr("bookmark_collections_user_id_fkey")
+	}
+	if s.collectionNamedLocked(arg.UserID, arg.Name, uuid.Nil) {
+		return database.BookmarkCollection{}, uniqueErr("bookmark_collections_user_id_name_key", "")
+	}
+	t := now()
+	c := database.BookmarkCollection{
+		ID:        uuid.New(),
+		UserID:    arg.UserID,
+		Name:      arg.Name,
+		CreatedAt: t,
+		UpdatedAt: t,
+	}
+	put(s, s.collections, c.ID, c)
+	return c, nil
+}
+
+// collectionNamedLocked reports whether another of userID's collections than
+// except is called name. Callers must hold s.mu.
+func (s *Store) collectionNamedLocked(userID uuid.UUID, name string, except uuid.UUID) bool {
+	for _, c := range s.collections {
+		if c.UserID == userID && c.Name == name && c.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) GetBookmarkCollection(ctx context.Context, arg database.GetBookmarkCollectionParams) (database.BookmarkCollection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.collections[arg.ID]
+	if !ok || c.UserID != arg.UserID {
+		return database.BookmarkCollection{}, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+func (s *Store) ListBookmarkCollections(ctx context.Context, userID uuid.UUID) ([]database.ListBookmarkCollectionsRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	counts := map[uuid.UUID]int64{}
+	for key, b := range s.bookmarks {
+		if key.user != userID || !b.CollectionID.Valid {
+			continue
+		}
+		if c, ok := s.chirps[key.chirp]; ok && !c.DeletedAt.Valid {
+			counts[b.CollectionID.UUID]++
+		}
+	}
+	var rows []database.ListBookmarkCollectionsRow
+	for _, c := range s.collections {
+		if c.UserID == userID {
+			rows = append(rows, database.ListBookmarkCollectionsRow{BookmarkCollection: c, BookmarkCount: counts[c.ID]})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i].BookmarkCollection, rows[j].BookmarkCollection
+		if cmp := strings.Compare(a.Name, b.Name); cmp != 0 {
+			return cmp < 0
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	return rows, nil
+}
+
+func (s *Store) RenameBookmarkCollection(ctx context.Context, arg database.RenameBookmarkCollectionParams) (database.BookmarkCollection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.collections[arg.ID]
+	if !ok || c.UserID != arg.UserID {
+		return database.BookmarkCollection{}, sql.ErrNoRows
+	}
+	if s.collectionNamedLocked(arg.UserID, arg.Name, arg.ID) {
+		return database.BookmarkCollection{}, uniqueErr("bookmark_collections_user_id_name_key", "")
+	}
+	c.Name = arg.Name
+	c.UpdatedAt = now()
+	put(s, s.collections, c.ID, c)
+	return c, nil
+}
+
+func (s *Store) DeleteBookmarkCollection(ctx context.Context, arg database.DeleteBookmarkCollectionParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.collections[arg.ID]
+	if !ok || c.UserID != arg.UserID {
+		return 0, nil
+	}
+	s.deleteCollectionLocked(c.ID)
+	return 1, nil
+}
+
+// deleteCollectionLocked removes a collection and unfiles its bookmarks,
+// matching ON DELETE SET NULL. Callers must hold s.mu.
+func (s *Store) deleteCollectionLocked(id uuid.UUID) {
+	del(s, s.collections, id)
+	for key, b := range s.bookmarks {
+		if b.CollectionID.Valid && b.CollectionID.UUID == id {
+			b.CollectionID = uuid.NullUUID{}
+			put(s, s.bookmarks, key, b)
+		}
+	}
+}
+
+// deleteBookmarksOfChirpLocked removes every bookmark of a chirp. Callers
+// must hold s.mu.
+func (s *Store) deleteBookmarksOfChirpLocked(chirpID uuid.UUID) {
+	for key := range s.bookmarks {
+		if key.chirp == chirpID {
+			del(s, s.bookmarks, key)
+		}
+	}
+}
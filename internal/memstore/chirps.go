@@ -0,0 +1,455 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// compareKeys orders rows by (created_at, id) the way Postgres compares
+// the row values used for keyset pagination.
+func compareKeys(aAt time.Time, aID uuid.UUID, bAt time.Time, bID uuid.UUID) int {
+	if c := aAt.Compare(bAt); c != 0 {
+		return c
+	}
+	return bytes.Compare(aID[:], bID[:])
+}
+
+// sortChirps orders chirps the way the SQL queries do, breaking ties on id
+// so results are deterministic.
+func sortChirps(chirps []database.Chirp) {
+	sort.Slice(chirps, func(i, j int) bool {
+		return compareKeys(chirps[i].CreatedAt, chirps[i].ID, chirps[j].CreatedAt, chirps[j].ID) < 0
+	})
+}
+
+// page applies a keyset cursor and limit to rows already sorted ascending.
+// With desc set the rows are walked from the end and the cursor is an upper
+// bound instead of a lower one.
+func page[T any](rows []T, key func(T) (time.Time, uuid.UUID), cursorAt sql.NullTime, cursorID uuid.NullUUID, limit int32, desc bool) []T {
+	if desc {
+		slices.Reverse(rows)
+	}
+	var items []T
+	for _, row := range rows {
+		if int32(len(items)) >= limit {
+			break
+		}
+		if cursorAt.Valid {
+			at, id := key(row)
+			c := compareKeys(at, id, cursorAt.Time, cursorID.UUID)
+			if (!desc && c <= 0) || (desc && c >= 0) {
+				continue
+			}
+		}
+		items = append(items, row)
+	}
+	return items
+}
+
+func chirpKey(c database.Chirp) (time.Time, uuid.UUID) {
+	return c.CreatedAt, c.ID
+}
+
+func (s *Store) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Chirp{}, foreignKeyErr("chirps_user_id_fkey")
+	}
+	if arg.RootID.Valid {
+		if _, ok := s.chirps[arg.RootID.UUID]; !ok {
+			return database.Chirp{}, foreignKeyErr("chirps_root_id_fkey")
+		}
+	}
+	if arg.RechirpOfID.Valid {
+		if _, ok := s.chirps[arg.RechirpOfID.UUID]; !ok {
+			return database.Chirp{}, foreignKeyErr("chirps_rechirp_of_id_fkey")
+		}
+		if s.liveRechirpExists(arg.UserID, arg.RechirpOfID.UUID) {
+			return database.Chirp{}, uniqueErr("chirps_user_id_rechirp_of_id_key", "Key (user_id, rechirp_of_id) already exists.")
+		}
+	}
+	if arg.ParentID.Valid {
+		if _, ok := s.chirps[arg.ParentID.UUID]; !ok {
+			return database.Chirp{}, foreignKeyErr("chirps_parent_id_fkey")
+		}
+		s.addReplyCount(arg.ParentID, 1)
+	}
+	t := now()
+	chirp := database.Chirp{
+		ID:          uuid.New(),
+		CreatedAt:   t,
+		UpdatedAt:   t,
+		Body:        arg.Body,
+		UserID:      arg.UserID,
+		ParentID:    arg.ParentID,
+		RootID:      arg.RootID,
+		RechirpOfID: arg.RechirpOfID,
+		QuoteOfID:   arg.QuoteOfID,
+	}
+	put(s, s.chirps, chirp.ID, chirp)
+	return chirp, nil
+}
+
+func (s *Store) DeleteAllChirps(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reset(s, &s.chirps)
+	reset(s, &s.likes)
+	reset(s, &s.chirpTags)
+	reset(s, &s.mentions)
+	reset(s, &s.revisions)
+	reset(s, &s.flags)
+	reset(s, &s.polls)
+	reset(s, &s.pollVotes)
+	reset(s, &s.bookmarks)
+	reset(s, &s.pins)
+	for id, m := range s.media {
+		if m.ChirpID.Valid {
+			s.detachMediaLocked(id)
+		}
+	}
+	return nil
+}
+
+// addReplyCount adjusts the reply_count of parentID, if it is set and the
+// parent still exists. Callers must hold s.mu.
+func (s *Store) addReplyCount(parentID uuid.NullUUID, delta int32) {
+	if !parentID.Valid {
+		return
+	}
+	if parent, ok := s.chirps[parentID.UUID]; ok {
+		parent.ReplyCount += delta
+		put(s, s.chirps, parent.ID, parent)
+	}
+}
+
+// deleteChirpLocked removes a chirp and applies the ON DELETE rules of the
+// columns that reference it. Callers must hold s.mu.
+func (s *Store) deleteChirpLocked(id uuid.UUID) {
+	del(s, s.chirps, id)
+	s.deleteRechirpsLocked(id)
+	for key := range s.likes {
+		if key.chirp == id {
+			del(s, s.likes, key)
+		}
+	}
+	for key := range s.chirpTags {
+		if key.chirp == id {
+			del(s, s.chirpTags, key)
+		}
+	}
+	for key := range s.mentions {
+		if key.chirp == id {
+			del(s, s.mentions, key)
+		}
+	}
+	s.deleteRevisionsLocked(id)
+	del(s, s.flags, id)
+	s.deletePollLocked(id)
+	s.deleteBookmarksOfChirpLocked(id)
+	s.unpinLocked(id)
+	for mediaID, m := range s.media {
+		if m.ChirpID.Valid && m.ChirpID.UUID == id {
+			s.detachMediaLocked(mediaID)
+		}
+	}
+	for _, c := range s.chirps {
+		changed := false
+		if c.ParentID.Valid && c.ParentID.UUID == id {
+			c.ParentID = uuid.NullUUID{}
+			changed = true
+		}
+		if c.RootID.Valid && c.RootID.UUID == id {
+			c.RootID = uuid.NullUUID{}
+			changed = true
+		}
+		if changed {
+			put(s, s.chirps, c.ID, c)
+		}
+	}
+}
+
+// deleteRechirpsLocked removes every rechirp of id. Callers must hold s.mu.
+func (s *Store) deleteRechirpsLocked(id uuid.UUID) {
+	for _, c := range s.chirps {
+		if c.RechirpOfID.Valid && c.RechirpOfID.UUID == id {
+			s.deleteChirpLocked(c.ID)
+		}
+	}
+}
+
+func (s *Store) SoftDeleteChirp(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chirp, ok := s.chirps[id]
+	if !ok || chirp.DeletedAt.Valid {
+		return nil
+	}
+	t := now()
+	for _, c := range s.chirps {
+		rechirp := c.RechirpOfID.Valid && c.RechirpOfID.UUID == id
+		if c.DeletedAt.Valid || (c.ID != id && !rechirp) {
+			continue
+		}
+		c.DeletedAt = sql.NullTime{Time: t, Valid: true}
+		c.UpdatedAt = t
+		put(s, s.chirps, c.ID, c)
+		s.unpinLocked(c.ID)
+	}
+	s.addReplyCount(chirp.ParentID, -1)
+	return nil
+}
+
+// liveRechirpExists reports whether userID has a rechirp of originalID that
+// is not deleted. Callers must hold s.mu.
+func (s *Store) liveRechirpExists(userID, originalID uuid.UUID) bool {
+	for _, c := range s.chirps {
+		if c.UserID == userID && c.RechirpOfID.Valid && c.RechirpOfID.UUID == originalID && !c.DeletedAt.Valid {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) RestoreChirp(ctx context.Context, arg database.RestoreChirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chirp, ok := s.chirps[arg.ID]
+	if !ok || !chirp.DeletedAt.Valid || !chirp.DeletedAt.Time.After(arg.Cutoff) {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	if chirp.RechirpOfID.Valid && s.liveRechirpExists(chirp.UserID, chirp.RechirpOfID.UUID) {
+		return database.Chirp{}, uniqueErr("chirps_user_id_rechirp_of_id_key", "Key (user_id, rechirp_of_id) already exists.")
+	}
+	t := now()
+	var rechirps []database.Chirp
+	for _, c := range s.chirps {
+		if c.RechirpOfID.Valid && c.RechirpOfID.UUID == chirp.ID && c.DeletedAt == chirp.DeletedAt &&
+			!s.liveRechirpExists(c.UserID, chirp.ID) {
+			rechirps = append(rechirps, c)
+		}
+	}
+	for _, c := range rechirps {
+		c.DeletedAt = sql.NullTime{}
+		c.UpdatedAt = t
+		put(s, s.chirps, c.ID, c)
+	}
+	s.addReplyCount(chirp.ParentID, 1)
+	chirp.DeletedAt = sql.NullTime{}
+	chirp.UpdatedAt = t
+	put(s, s.chirps, chirp.ID, chirp)
+	return chirp, nil
+}
+
+func (s *Store) AddUserReplyCounts(ctx context.Context, arg database.AddUserReplyCountsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.chirps {
+		if c.UserID == arg.UserID && c.DeletedAt == arg.DeletedAt {
+			s.addReplyCount(c.ParentID, arg.Delta)
+		}
+	}
+	return nil
+}
+
+// hasRepliesLocked reports whether any chirp, deleted or not, replies to id.
+// Callers must hold s.mu.
+func (s *Store) hasRepliesLocked(id uuid.UUID) bool {
+	for _, c := range s.chirps {
+		if c.ParentID.Valid && c.ParentID.UUID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) PurgeDeletedChirps(ctx context.Context, cutoff time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Pick every row before deleting any, like the single DELETE statement
+	// does, so a reply purged now still holds its parent back until the next
+	// run.
+	var expired []uuid.UUID
+	for _, c := range s.chirps {
+		if c.DeletedAt.Valid && c.DeletedAt.Time.Before(cutoff) && !s.hasRepliesLocked(c.ID) {
+			expired = append(expired, c.ID)
+		}
+	}
+	var n int64
+	for _, id := range expired {
+		if _, ok := s.chirps[id]; ok {
+			s.deleteChirpLocked(id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) ScrubDeletedChirps(ctx context.Context, cutoff time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.chirps {
+		if !c.DeletedAt.Valid || !c.DeletedAt.Time.Before(cutoff) {
+			continue
+		}
+		s.deleteRevisionsLocked(c.ID)
+		for key := range s.mentions {
+			if key.chirp == c.ID {
+				del(s, s.mentions, key)
+			}
+		}
+		for key := range s.chirpTags {
+			if key.chirp == c.ID {
+				del(s, s.chirpTags, key)
+			}
+		}
+		s.deletePollLocked(c.ID)
+		s.deleteBookmarksOfChirpLocked(c.ID)
+		c.Body = ""
+		put(s, s.chirps, c.ID, c)
+	}
+	return nil
+}
+
+func (s *Store) EditChirp(ctx context.Context, arg database.EditChirpParams) (database.Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chirp, ok := s.chirps[arg.ID]
+	if !ok || chirp.DeletedAt.Valid {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	revision := database.ChirpRevision{
+		ID:        uuid.New(),
+		ChirpID:   chirp.ID,
+		Body:      chirp.Body,
+		CreatedAt: chirp.CreatedAt,
+	}
+	if chirp.EditedAt.Valid {
+		revision.CreatedAt = chirp.EditedAt.Time
+	}
+	put(s, s.revisions, revision.ID, revision)
+	t := now()
+	chirp.Body = arg.Body
+	chirp.UpdatedAt = t
+	chirp.EditedAt = sql.NullTime{Time: t, Valid: true}
+	put(s, s.chirps, chirp.ID, chirp)
+	return chirp, nil
+}
+
+func (s *Store) GetAllChirps(ctx context.Context) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var items []database.Chirp
+	for _, c := range s.chirps {
+		if !c.DeletedAt.Valid {
+			items = append(items, c)
+		}
+	}
+	sortChirps(items)
+	return items, nil
+}
+
+func (s *Store) GetChirpsByID(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var items []database.Chirp
+	for _, c := range s.chirps {
+		if c.UserID == userID && !c.DeletedAt.Valid {
+			items = append(items, c)
+		}
+	}
+	sortChirps(items)
+	return items, nil
+}
+
+func (s *Store) GetOneChirps(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.chirps[id]
+	if !ok || c.DeletedAt.Valid {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+func (s *Store) GetChirpIncludingDeleted(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.chirps[id]
+	if !ok {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return c, nil
+}
+
+func (s *Store) listChirps(authorID uuid.NullUUID, cursorAt sql.NullTime, cursorID uuid.NullUUID, limit int32, desc bool) []database.Chirp {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.Chirp
+	for _, c := range s.chirps {
+		if c.DeletedAt.Valid || (authorID.Valid && c.UserID != authorID.UUID) {
+			continue
+		}
+		rows = append(rows, c)
+	}
+	sortChirps(rows)
+	return page(rows, chirpKey, cursorAt, cursorID, limit, desc)
+}
+
+func (s *Store) ListChirpsAsc(ctx context.Context, arg database.ListChirpsAscParams) ([]database.Chirp, error) {
+	return s.listChirps(arg.AuthorID, arg.CursorCreatedAt, arg.CursorID, arg.Limit, false), nil
+}
+
+func (s *Store) ListChirpsDesc(ctx context.Context, arg database.ListChirpsDescParams) ([]database.Chirp, error) {
+	return s.listChirps(arg.AuthorID, arg.CursorCreatedAt, arg.CursorID, arg.Limit, true), nil
+}
+
+func (s *Store) GetReplies(ctx context.Context, arg database.GetRepliesParams) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.Chirp
+	for _, c := range s.chirps {
+		if c.DeletedAt.Valid && c.ReplyCount == 0 {
+			continue
+		}
+		if c.ParentID.Valid && arg.ParentID.Valid && c.ParentID.UUID == arg.ParentID.UUID {
+			rows = append(rows, c)
+		}
+	}
+	sortChirps(rows)
+	return page(rows, chirpKey, arg.CursorCreatedAt, arg.CursorID, arg.Limit, false), nil
+}
+
+func (s *Store) GetThread(ctx context.Context, arg database.GetThreadParams) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.Chirp
+	for _, c := range s.chirps {
+		if c.ID == arg.RootID || (c.RootID.Valid && c.RootID.UUID == arg.RootID) {
+			rows = append(rows, c)
+		}
+	}
+	sortChirps(rows)
+	return page(rows, chirpKey, sql.NullTime{}, uuid.NullUUID{}, arg.Limit, false), nil
+}
+
+func (s *Store) ListChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var items []database.Chirp
+	for _, id := range ids {
+		if c, ok := s.chirps[id]; ok {
+			items = append(items, c)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,144 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateDraft(ctx context.Context, arg database.CreateDraftParams) (database.Draft, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.Draft{}, foreignKeyErr("drafts_user_id_fkey")
+	}
+	t := now()
+	d := database.Draft{
+		ID:        uuid.New(),
+		UserID:    arg.UserID,
+		Body:      arg.Body,
+		ParentID:  arg.ParentID,
+		QuoteOfID: arg.QuoteOfID,
+		PublishAt: arg.PublishAt,
+		CreatedAt: t,
+		UpdatedAt: t,
+	}
+	put(s, s.drafts, d.ID, d)
+	return d, nil
+}
+
+func (s *Store) GetDraft(ctx context.Context, arg database.GetDraftParams) (database.Draft, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.drafts[arg.ID]
+	if !ok || d.UserID != arg.UserID {
+		return database.Draft{}, sql.ErrNoRows
+	}
+	return d, nil
+}
+
+// LockDraft is GetDraft: ExecTx already serialises transactions.
+func (s *Store) LockDraft(ctx context.Context, arg database.LockDraftParams) (database.Draft, error) {
+	return s.GetDraft(ctx, database.GetDraftParams(arg))
+}
+
+func (s *Store) ListDrafts(ctx context.Context, arg database.ListDraftsParams) ([]database.Draft, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var drafts []database.Draft
+	for _, d := range s.drafts {
+		if d.UserID == arg.UserID {
+			drafts = append(drafts, d)
+		}
+	}
+	sort.Slice(drafts, func(i, j int) bool {
+		return compareKeys(drafts[i].CreatedAt, drafts[i].ID, drafts[j].CreatedAt, drafts[j].ID) < 0
+	})
+	key := func(d database.Draft) (time.Time, uuid.UUID) { return d.CreatedAt, d.ID }
+	return page(drafts, key, arg.CursorCreatedAt, arg.CursorID, arg.Limit, true), nil
+}
+
+func (s *Store) UpdateDraft(ctx context.Context, arg database.UpdateDraftParams) (database.Draft, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.drafts[arg.ID]
+	if !ok || d.UserID != arg.UserID {
+		return database.Draft{}, sql.ErrNoRows
+	}
+	d.Body = arg.Body
+	d.ParentID = arg.ParentID
+	d.QuoteOfID = arg.QuoteOfID
+	d.PublishAt = arg.PublishAt
+	d.LastError = ""
+	d.PublishAttempts = 0
+	d.RetryAt = sql.NullTime{}
+	d.UpdatedAt = now()
+	put(s, s.drafts, d.ID, d)
+	return d, nil
+}
+
+func (s *Store) DeleteDraft(ctx context.Context, arg database.DeleteDraftParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.drafts[arg.ID]
+	if !ok || d.UserID != arg.UserID {
+		return 0, nil
+	}
+	del(s, s.drafts, arg.ID)
+	return 1, nil
+}
+
+// ClaimDueDraft returns the earliest due draft. There is nothing to skip:
+// ExecTx runs one transaction at a time.
+func (s *Store) ClaimDueDraft(ctx context.Context, at time.Time) (database.Draft, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var due []database.Draft
+	for _, d := range s.drafts {
+		if !d.PublishAt.Valid || d.PublishAt.Time.After(at) || (d.RetryAt.Valid && d.RetryAt.Time.After(at)) {
+			continue
+		}
+		if u := s.users[d.UserID]; !u.DeletedAt.Valid && !u.SuspendedAt.Valid {
+			due = append(due, d)
+		}
+	}
+	if len(due) == 0 {
+		return database.Draft{}, sql.ErrNoRows
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return compareKeys(due[i].PublishAt.Time, due[i].ID, due[j].PublishAt.Time, due[j].ID) < 0
+	})
+	return due[0], nil
+}
+
+func (s *Store) FailDraft(ctx context.Context, arg database.FailDraftParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.drafts[arg.ID]
+	if !ok {
+		return nil
+	}
+	d.PublishAt = sql.NullTime{}
+	d.LastError = arg.LastError
+	d.UpdatedAt = now()
+	put(s, s.drafts, d.ID, d)
+	return nil
+}
+
+func (s *Store) RetryDraft(ctx context.Context, arg database.RetryDraftParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.drafts[arg.ID]
+	if !ok {
+		return nil
+	}
+	d.PublishAttempts++
+	d.RetryAt = arg.RetryAt
+	put(s, s.drafts, d.ID, d)
+	return nil
+}
@@ -0,0 +1,103 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type followKey struct {
+	follower uuid.UUID
+	followee uuid.UUID
+}
+
+func (s *Store) FollowUser(ctx context.Context, arg database.FollowUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if arg.FollowerID == arg.FolloweeID {
+		return checkErr("follows_check")
+	}
+	if _, ok := s.users[arg.FollowerID]; !ok {
+		return foreignKeyErr("follows_follower_id_fkey")
+	}
+	if _, ok := s.users[arg.FolloweeID]; !ok {
+		return foreignKeyErr("follows_followee_id_fkey")
+	}
+	key := followKey{follower: arg.FollowerID, followee: arg.FolloweeID}
+	if _, ok := s.follows[key]; !ok {
+		put(s, s.follows, key, now())
+	}
+	return nil
+}
+
+func (s *Store) UnfollowUser(ctx context.Context, arg database.UnfollowUserParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	del(s, s.follows, followKey{follower: arg.FollowerID, followee: arg.FolloweeID})
+	return nil
+}
+
+type followRow struct {
+	userID    uuid.UUID
+	createdAt time.Time
+}
+
+func followRowKey(r followRow) (time.Time, uuid.UUID) {
+	return r.createdAt, r.userID
+}
+
+// listFollows collects one side of the follow edges touching userID, newest
+// first. With followers set it returns who follows userID, otherwise who
+// userID follows.
+func (s *Store) listFollows(userID uuid.UUID, followers bool, cursorAt sql.NullTime, cursorID uuid.NullUUID, limit int32) []followRow {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []followRow
+	for key, createdAt := range s.follows {
+		switch {
+		case followers && key.followee == userID:
+			rows = append(rows, followRow{userID: key.follower, createdAt: createdAt})
+		case !followers && key.follower == userID:
+			rows = append(rows, followRow{userID: key.followee, createdAt: createdAt})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return compareKeys(rows[i].createdAt, rows[i].userID, rows[j].createdAt, rows[j].userID) < 0
+	})
+	return page(rows, followRowKey, cursorAt, cursorID, limit, true)
+}
+
+func (s *Store) ListFollowers(ctx context.Context, arg database.ListFollowersParams) ([]database.ListFollowersRow, error) {
+	var items []database.ListFollowersRow
+	for _, r := range s.listFollows(arg.UserID, true, arg.CursorCreatedAt, arg.CursorID, arg.Limit) {
+		items = append(items, database.ListFollowersRow{UserID: r.userID, CreatedAt: r.createdAt})
+	}
+	return items, nil
+}
+
+func (s *Store) ListFollowing(ctx context.Context, arg database.ListFollowingParams) ([]database.ListFollowingRow, error) {
+	var items []database.ListFollowingRow
+	for _, r := range s.listFollows(arg.UserID, false, arg.CursorCreatedAt, arg.CursorID, arg.Limit) {
+		items = append(items, database.ListFollowingRow{UserID: r.userID, CreatedAt: r.createdAt})
+	}
+	return items, nil
+}
+
+func (s *Store) GetFeed(ctx context.Context, arg database.GetFeedParams) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.Chirp
+	for _, c := range s.chirps {
+		_, following := s.follows[followKey{follower: arg.UserID, followee: c.UserID}]
+		if !c.DeletedAt.Valid && (c.UserID == arg.UserID || following) {
+			rows = append(rows, c)
+		}
+	}
+	sortChirps(rows)
+	return page(rows, chirpKey, arg.CursorCreatedAt, arg.CursorID, arg.Limit, true), nil
+}
@@ -0,0 +1,103 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type likeKey struct {
+	chirp uuid.UUID
+	user  uuid.UUID
+}
+
+// addLikeCount mirrors the chirp_likes_count trigger. Callers must hold s.mu.
+func (s *Store) addLikeCount(chirpID uuid.UUID, delta int32) {
+	if c, ok := s.chirps[chirpID]; ok {
+		c.LikeCount += delta
+		put(s, s.chirps, chirpID, c)
+	}
+}
+
+func (s *Store) LikeChirp(ctx context.Context, arg database.LikeChirpParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return 0, foreignKeyErr("chirp_likes_chirp_id_fkey")
+	}
+	if _, ok := s.users[arg.UserID]; !ok {
+		return 0, foreignKeyErr("chirp_likes_user_id_fkey")
+	}
+	key := likeKey{chirp: arg.ChirpID, user: arg.UserID}
+	if _, ok := s.likes[key]; ok {
+		return 0, nil
+	}
+	put(s, s.likes, key, now())
+	s.addLikeCount(arg.ChirpID, 1)
+	return 1, nil
+}
+
+func (s *Store) UnlikeChirp(ctx context.Context, arg database.UnlikeChirpParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := likeKey{chirp: arg.ChirpID, user: arg.UserID}
+	if _, ok := s.likes[key]; !ok {
+		return 0, nil
+	}
+	del(s, s.likes, key)
+	s.addLikeCount(arg.ChirpID, -1)
+	return 1, nil
+}
+
+func (s *Store) GetLikedChirpIDs(ctx context.Context, arg database.GetLikedChirpIDsParams) ([]uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var items []uuid.UUID
+	for _, id := range arg.ChirpIds {
+		if _, ok := s.likes[likeKey{chirp: id, user: arg.UserID}]; ok {
+			items = append(items, id)
+		}
+	}
+	return items, nil
+}
+
+func (s *Store) ListChirpLikes(ctx context.Context, arg database.ListChirpLikesParams) ([]database.ListChirpLikesRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.ListChirpLikesRow
+	for key, createdAt := range s.likes {
+		if key.chirp == arg.ChirpID {
+			rows = append(rows, database.ListChirpLikesRow{UserID: key.user, CreatedAt: createdAt})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return compareKeys(rows[i].CreatedAt, rows[i].UserID, rows[j].CreatedAt, rows[j].UserID) < 0
+	})
+	key := func(r database.ListChirpLikesRow) (time.Time, uuid.UUID) { return r.CreatedAt, r.UserID }
+	return page(rows, key, arg.CursorCreatedAt, arg.CursorID, arg.Limit, true), nil
+}
+
+func (s *Store) ListUserLikes(ctx context.Context, arg database.ListUserLikesParams) ([]database.ListUserLikesRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.ListUserLikesRow
+	for key, createdAt := range s.likes {
+		if key.user != arg.UserID {
+			continue
+		}
+		c, ok := s.chirps[key.chirp]
+		if !ok || c.DeletedAt.Valid {
+			continue
+		}
+		rows = append(rows, database.ListUserLikesRow{Chirp: c, LikedAt: createdAt})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return compareKeys(rows[i].LikedAt, rows[i].Chirp.ID, rows[j].LikedAt, rows[j].Chirp.ID) < 0
+	})
+	key := func(r database.ListUserLikesRow) (time.Time, uuid.UUID) { return r.LikedAt, r.Chirp.ID }
+	return page(rows, key, arg.CursorCreatedAt, arg.CursorID, arg.Limit, true), nil
+}
@@ -0,0 +1,140 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateMediaItem(ctx context.Context, arg database.CreateMediaItemParams) (database.MediaItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.MediaItem{}, foreignKeyErr("media_items_user_id_fkey")
+	}
+	if _, ok := s.media[arg.ID]; ok {
+		return database.MediaItem{}, uniqueErr("media_items_pkey", "")
+	}
+	m := database.MediaItem{
+		ID:          arg.ID,
+		UserID:      uuid.NullUUID{UUID: arg.UserID, Valid: true},
+		ContentType: arg.ContentType,
+		SizeBytes:   arg.SizeBytes,
+		Width:       arg.Width,
+		Height:      arg.Height,
+		CreatedAt:   now(),
+	}
+	put(s, s.media, m.ID, m)
+	return m, nil
+}
+
+func (s *Store) GetMediaItem(ctx context.Context, id uuid.UUID) (database.MediaItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.media[id]
+	if !ok {
+		return database.MediaItem{}, sql.ErrNoRows
+	}
+	if m.ChirpID.Valid && s.chirps[m.ChirpID.UUID].DeletedAt.Valid {
+		return database.MediaItem{}, sql.ErrNoRows
+	}
+	return m, nil
+}
+
+func (s *Store) AttachMediaItem(ctx context.Context, arg database.AttachMediaItemParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.media[arg.ID]
+	if !ok || m.UserID != (uuid.NullUUID{UUID: arg.UserID, Valid: true}) || m.ChirpID.Valid {
+		return 0, nil
+	}
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return 0, foreignKeyErr("media_items_chirp_id_fkey")
+	}
+	if arg.Position < 0 || arg.Position > 3 {
+		return 0, checkErr("media_items_position_check")
+	}
+	for _, other := range s.media {
+		if other.ChirpID.Valid && other.ChirpID.UUID == arg.ChirpID && other.Position.Int16 == arg.Position {
+			return 0, uniqueErr("media_items_chirp_id_position_key", "")
+		}
+	}
+	m.ChirpID = uuid.NullUUID{UUID: arg.ChirpID, Valid: true}
+	m.Position = sql.NullInt16{Int16: arg.Position, Valid: true}
+	put(s, s.media, m.ID, m)
+	return 1, nil
+}
+
+func (s *Store) ListMediaItemsForChirps(ctx context.Context, chirpIds []uuid.UUID) ([]database.MediaItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	wanted := make(map[uuid.UUID]bool, len(chirpIds))
+	for _, id := range chirpIds {
+		wanted[id] = true
+	}
+	var rows []database.MediaItem
+	for _, m := range s.media {
+		if m.ChirpID.Valid && wanted[m.ChirpID.UUID] {
+			rows = append(rows, m)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].ChirpID != rows[j].ChirpID {
+			return rows[i].ChirpID.UUID.String() < rows[j].ChirpID.UUID.String()
+		}
+		return rows[i].Position.Int16 < rows[j].Position.Int16
+	})
+	return rows, nil
+}
+
+func (s *Store) DetachDeletedChirpMedia(ctx context.Context, cutoff time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, m := range s.media {
+		if !m.ChirpID.Valid {
+			continue
+		}
+		if c := s.chirps[m.ChirpID.UUID]; c.DeletedAt.Valid && c.DeletedAt.Time.Before(cutoff) {
+			s.detachMediaLocked(id)
+		}
+	}
+	return nil
+}
+
+func (s *Store) ListOrphanedMediaItems(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.MediaItem
+	for _, m := range s.media {
+		if !m.ChirpID.Valid && m.CreatedAt.Before(cutoff) {
+			rows = append(rows, m)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].CreatedAt.Before(rows[j].CreatedAt) })
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, m := range rows {
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
+}
+
+func (s *Store) DeleteMediaItem(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	del(s, s.media, id)
+	return nil
+}
+
+// detachMediaLocked clears a media item's chirp, as ON DELETE SET NULL
+// does. Callers must hold s.mu.
+func (s *Store) detachMediaLocked(id uuid.UUID) {
+	m := s.media[id]
+	m.ChirpID = uuid.NullUUID{}
+	m.Position = sql.NullInt16{}
+	put(s, s.media, id, m)
+}
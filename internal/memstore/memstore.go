@@ -0,0 +1,179 @@
+// Package memstore is an in-memory implementation of database.Store. It
+// mirrors the Postgres behaviour the handlers rely on: rows come back
+// ordered by created_at, missing rows yield sql.ErrNoRows, constraint
+// violations surface as *pq.Error and deleting a user cascades to the rows
+// that reference it.
+package memstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation     = pq.ErrorCode("23505")
+	foreignKeyViolation = pq.ErrorCode("23503")
+	checkViolation      = pq.ErrorCode("23514")
+)
+
+type Store struct {
+	*state
+	// undo is set on the Store an ExecTx callback runs against. Every write
+	// made through it appends the step that reverses it, so rolling back
+	// costs as much as the transaction wrote rather than a copy of every
+	// table.
+	undo *[]func()
+}
+
+type state struct {
+	mu sync.RWMutex
+	// txMu serialises ExecTx calls so one transaction's rollback can't undo
+	// another's writes.
+	txMu sync.Mutex
+	tables
+}
+
+// tables holds every row in the store. Writes go through put, del and
+// reset so they can be undone.
+type tables struct {
+	users         map[uuid.UUID]database.User
+	chirps        map[uuid.UUID]database.Chirp
+	refreshTokens map[uuid.UUID]database.RefreshToken
+	accessTokens  map[string]database.AccessToken
+	follows       map[followKey]time.Time
+	likes         map[likeKey]time.Time
+	tags          map[string]database.Tag
+	chirpTags     map[chirpTagKey]time.Time
+	mentions      map[mentionKey]database.ChirpMention
+	revisions     map[uuid.UUID]database.ChirpRevision
+	rules         map[string]database.ModerationRule
+	flags         map[uuid.UUID]database.ChirpFlag
+	media         map[uuid.UUID]database.MediaItem
+	polls         map[uuid.UUID]database.Poll
+	pollVotes     map[pollVoteKey]database.PollVote
+	drafts        map[uuid.UUID]database.Draft
+	bookmarks     map[bookmarkKey]database.Bookmark
+	collections   map[uuid.UUID]database.BookmarkCollection
+	pins          map[pinKey]time.Time
+	events        map[uuid.UUID]database.SecurityEvent
+}
+
+var _ database.Store = (*Store)(nil)
+
+func New() *Store {
+	return &Store{state: &state{tables: tables{
+		users:         map[uuid.UUID]database.User{},
+		chirps:        map[uuid.UUID]database.Chirp{},
+		refreshTokens: map[uuid.UUID]database.RefreshToken{},
+		accessTokens:  map[string]database.AccessToken{},
+		follows:       map[followKey]time.Time{},
+		likes:         map[likeKey]time.Time{},
+		tags:          map[string]database.Tag{},
+		chirpTags:     map[chirpTagKey]time.Time{},
+		mentions:      map[mentionKey]database.ChirpMention{},
+		revisions:     map[uuid.UUID]database.ChirpRevision{},
+		rules:         map[string]database.ModerationRule{},
+		flags:         map[uuid.UUID]database.ChirpFlag{},
+		media:         map[uuid.UUID]database.MediaItem{},
+		polls:         map[uuid.UUID]database.Poll{},
+		pollVotes:     map[pollVoteKey]database.PollVote{},
+		drafts:        map[uuid.UUID]database.Draft{},
+		bookmarks:     map[bookmarkKey]database.Bookmark{},
+		collections:   map[uuid.UUID]database.BookmarkCollection{},
+		pins:          map[pinKey]time.Time{},
+		events:        map[uuid.UUID]database.SecurityEvent{},
+	}}}
+}
+
+// ExecTx runs fn against the store and reverses its writes if it returns an
+// error. Transactions are serialised with each other but not isolated from
+// concurrent calls made outside ExecTx, whose writes survive a rollback.
+func (s *Store) ExecTx(ctx context.Context, fn func(database.Querier) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	var undo []func()
+	if err := fn(&Store{state: s.state, undo: &undo}); err != nil {
+		s.mu.Lock()
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// onRollback records how to reverse a write if s belongs to a transaction.
+// Callers must hold s.mu.
+func (s *Store) onRollback(step func()) {
+	if s.undo != nil {
+		*s.undo = append(*s.undo, step)
+	}
+}
+
+// put sets m[k] = v, remembering the previous row so a rollback can put it
+// back.
+func put[K comparable, V any](s *Store, m map[K]V, k K, v V) {
+	old, ok := m[k]
+	s.onRollback(func() {
+		if ok {
+			m[k] = old
+		} else {
+			delete(m, k)
+		}
+	})
+	m[k] = v
+}
+
+func del[K comparable, V any](s *Store, m map[K]V, k K) {
+	old, ok := m[k]
+	if !ok {
+		return
+	}
+	s.onRollback(func() { m[k] = old })
+	delete(m, k)
+}
+
+// reset empties a whole table. The old map is kept as it was, so undoing
+// the reset just swaps it back in.
+func reset[K comparable, V any](s *Store, m *map[K]V) {
+	old := *m
+	s.onRollback(func() { *m = old })
+	*m = map[K]V{}
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+func uniqueErr(constraint, detail string) error {
+	return &pq.Error{
+		Code:       uniqueViolation,
+		Message:    "duplicate key value violates unique constraint \"" + constraint + "\"",
+		Detail:     detail,
+		Constraint: constraint,
+	}
+}
+
+func foreignKeyErr(constraint string) error {
+	return &pq.Error{
+		Code:       foreignKeyViolation,
+		Message:    "insert or update violates foreign key constraint \"" + constraint + "\"",
+		Constraint: constraint,
+	}
+}
+
+func checkErr(constraint string) error {
+	return &pq.Error{
+		Code:       checkViolation,
+		Message:    "new row violates check constraint \"" + constraint + "\"",
+		Constraint: constraint,
+	}
+}
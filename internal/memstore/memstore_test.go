@@ -0,0 +1,174 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func TestCreateUser_DuplicateEmail(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	if _, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"}); err != nil {
+		t.Fatalf("CreateUser returned error: %v", err)
+	}
+	_, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "y"})
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != uniqueViolation {
+		t.Errorf("expected unique violation, got: %v", err)
+	}
+}
+
+func TestGetOneChirps_NotFound(t *testing.T) {
+	s := New()
+	_, err := s.GetOneChirps(context.Background(), uuid.New())
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows, got: %v", err)
+	}
+}
+
+func TestCreateChirp_UnknownUser(t *testing.T) {
+	s := New()
+	_, err := s.CreateChirp(context.Background(), database.CreateChirpParams{Body: "hi", UserID: uuid.New()})
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != foreignKeyViolation {
+		t.Errorf("expected foreign key violation, got: %v", err)
+	}
+}
+
+func TestGetAllChirps_OrderedByCreatedAt(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	user, _ := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"})
+	for _, body := range []string{"one", "two", "three"} {
+		if _, err := s.CreateChirp(ctx, database.CreateChirpParams{Body: body, UserID: user.ID}); err != nil {
+			t.Fatalf("CreateChirp returned error: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	chirps, err := s.GetAllChirps(ctx)
+	if err != nil {
+		t.Fatalf("GetAllChirps returned error: %v", err)
+	}
+	if len(chirps) != 3 || chirps[0].Body != "one" || chirps[2].Body != "three" {
+		t.Errorf("chirps not ordered by created_at: %+v", chirps)
+	}
+}
+
+func TestDeleteAllUsers_Cascades(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	user, _ := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"})
+	chirp, _ := s.CreateChirp(ctx, database.CreateChirpParams{Body: "hi", UserID: user.ID})
+	s.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{TokenHash: "tok", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)})
+
+	if err := s.DeleteAllUsers(ctx); err != nil {
+		t.Fatalf("DeleteAllUsers returned error: %v", err)
+	}
+	if _, err := s.GetOneChirps(ctx, chirp.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("chirp survived user deletion: %v", err)
+	}
+	if _, err := s.GetRefreshToken(ctx, "tok"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("refresh token survived user deletion: %v", err)
+	}
+}
+
+func TestExecTx_RollsBackOnError(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	user, err := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"})
+	if err != nil {
+		t.Fatalf("CreateUser returned error: %v", err)
+	}
+
+	failure := errors.New("boom")
+	err = s.ExecTx(ctx, func(q database.Querier) error {
+		if _, err := q.CreateChirp(ctx, database.CreateChirpParams{Body: "hi", UserID: user.ID}); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("ExecTx returned %v, want %v", err, failure)
+	}
+	chirps, _ := s.GetAllChirps(ctx)
+	if len(chirps) != 0 {
+		t.Errorf("rolled back transaction left %d chirps", len(chirps))
+	}
+}
+
+func TestExecTx_RollbackRestoresDeletedRows(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	user, _ := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "x"})
+	chirp, _ := s.CreateChirp(ctx, database.CreateChirpParams{Body: "hi", UserID: user.ID})
+
+	failure := errors.New("boom")
+	err := s.ExecTx(ctx, func(q database.Querier) error {
+		if _, err := q.UpdateUser(ctx, database.UpdateUserParams{ID: user.ID, Email: "b@example.com", HashedPassword: "y"}); err != nil {
+			return err
+		}
+		if err := q.SoftDeleteUser(ctx, user.ID); err != nil {
+			return err
+		}
+		if _, err := q.PurgeDeletedUsers(ctx, time.Now().Add(time.Hour)); err != nil {
+			return err
+		}
+		// A write made outside the transaction isn't part of its rollback.
+		if _, err := s.CreateUser(ctx, database.CreateUserParams{Email: "c@example.com"}); err != nil {
+			return err
+		}
+		if err := q.DeleteAllUsers(ctx); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("ExecTx returned %v, want %v", err, failure)
+	}
+	if got, err := s.GetUserByID(ctx, user.ID); err != nil || got.Email != "a@example.com" {
+		t.Errorf("user after rollback: %+v, %v", got, err)
+	}
+	if _, err := s.GetOneChirps(ctx, chirp.ID); err != nil {
+		t.Errorf("cascaded chirp not restored: %v", err)
+	}
+	if _, err := s.GetUserByEmail(ctx, "c@example.com"); err != nil {
+		t.Errorf("write outside the transaction was rolled back: %v", err)
+	}
+}
+
+func TestSetRefreshTokenHash_ClearsPlaintext(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	user, _ := s.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"})
+	// A token stored before tokens were hashed.
+	legacy := database.RefreshToken{
+		ID:        uuid.New(),
+		Token:     sql.NullString{String: "plain", Valid: true},
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	s.refreshTokens[legacy.ID] = legacy
+
+	rows, err := s.ListUnhashedRefreshTokens(ctx, 10)
+	if err != nil || len(rows) != 1 || rows[0].Token.String != "plain" {
+		t.Fatalf("ListUnhashedRefreshTokens = %+v, %v", rows, err)
+	}
+	if err := s.SetRefreshTokenHash(ctx, database.SetRefreshTokenHashParams{TokenHash: "hashed", ID: legacy.ID}); err != nil {
+		t.Fatal(err)
+	}
+	rt, err := s.GetRefreshToken(ctx, "hashed")
+	if err != nil || rt.Token.Valid {
+		t.Errorf("after hashing: %+v, %v", rt, err)
+	}
+	if rows, _ := s.ListUnhashedRefreshTokens(ctx, 10); len(rows) != 0 {
+		t.Errorf("still unhashed: %+v", rows)
+	}
+}
@@ -0,0 +1,98 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type mentionKey struct {
+	chirp uuid.UUID
+	start int32
+}
+
+func (s *Store) CreateChirpMentions(ctx context.Context, arg database.CreateChirpMentionsParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return foreignKeyErr("chirp_mentions_chirp_id_fkey")
+	}
+	for i, userID := range arg.UserIds {
+		if _, ok := s.users[userID]; !ok {
+			return foreignKeyErr("chirp_mentions_user_id_fkey")
+		}
+		key := mentionKey{chirp: arg.ChirpID, start: arg.StartOffsets[i]}
+		if _, ok := s.mentions[key]; ok {
+			return uniqueErr("chirp_mentions_pkey", "")
+		}
+		put(s, s.mentions, key, database.ChirpMention{
+			ChirpID:     arg.ChirpID,
+			UserID:      userID,
+			StartOffset: arg.StartOffsets[i],
+			EndOffset:   arg.EndOffsets[i],
+		})
+	}
+	return nil
+}
+
+func (s *Store) ListMentionsForChirps(ctx context.Context, chirpIds []uuid.UUID) ([]database.ListMentionsForChirpsRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	wanted := make(map[uuid.UUID]bool, len(chirpIds))
+	for _, id := range chirpIds {
+		wanted[id] = true
+	}
+	var rows []database.ListMentionsForChirpsRow
+	for _, m := range s.mentions {
+		if !wanted[m.ChirpID] {
+			continue
+		}
+		rows = append(rows, database.ListMentionsForChirpsRow{
+			ChirpID:     m.ChirpID,
+			UserID:      m.UserID,
+			StartOffset: m.StartOffset,
+			EndOffset:   m.EndOffset,
+			Handle:      s.users[m.UserID].Handle,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].ChirpID != rows[j].ChirpID {
+			return rows[i].ChirpID.String() < rows[j].ChirpID.String()
+		}
+		return rows[i].StartOffset < rows[j].StartOffset
+	})
+	return rows, nil
+}
+
+func (s *Store) ListMentioningChirps(ctx context.Context, arg database.ListMentioningChirpsParams) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	mentioned := map[uuid.UUID]bool{}
+	for _, m := range s.mentions {
+		if m.UserID == arg.UserID {
+			mentioned[m.ChirpID] = true
+		}
+	}
+	var chirps []database.Chirp
+	for id := range mentioned {
+		if c, ok := s.chirps[id]; ok && !c.DeletedAt.Valid {
+			chirps = append(chirps, c)
+		}
+	}
+	sortChirps(chirps)
+	return page(chirps, chirpKey, arg.CursorCreatedAt, arg.CursorID, arg.Limit, true), nil
+}
+
+func (s *Store) DeleteChirpMentions(ctx context.Context, chirpID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.mentions {
+		if key.chirp == chirpID {
+			del(s, s.mentions, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) ListModerationRules(ctx context.Context) ([]database.ModerationRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rules []database.ModerationRule
+	for _, r := range s.rules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Word < rules[j].Word })
+	return rules, nil
+}
+
+func (s *Store) UpsertModerationRule(ctx context.Context, arg database.UpsertModerationRuleParams) (database.ModerationRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch arg.Action {
+	case "mask", "reject", "flag", "allow":
+	default:
+		return database.ModerationRule{}, checkErr("moderation_rules_action_check")
+	}
+	t := now()
+	rule, ok := s.rules[arg.Word]
+	if !ok {
+		rule = database.ModerationRule{Word: arg.Word, CreatedAt: t}
+	}
+	rule.Action = arg.Action
+	rule.UpdatedAt = t
+	put(s, s.rules, rule.Word, rule)
+	return rule, nil
+}
+
+func (s *Store) DeleteModerationRule(ctx context.Context, word string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rules[word]; !ok {
+		return 0, nil
+	}
+	del(s, s.rules, word)
+	return 1, nil
+}
+
+func (s *Store) FlagChirp(ctx context.Context, arg database.FlagChirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return foreignKeyErr("chirp_flags_chirp_id_fkey")
+	}
+	put(s, s.flags, arg.ChirpID, database.ChirpFlag{
+		ChirpID:   arg.ChirpID,
+		Words:     slices.Clone(arg.Words),
+		CreatedAt: now(),
+	})
+	return nil
+}
+
+func (s *Store) ListChirpFlags(ctx context.Context, arg database.ListChirpFlagsParams) ([]database.ListChirpFlagsRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.ListChirpFlagsRow
+	for _, f := range s.flags {
+		c, ok := s.chirps[f.ChirpID]
+		if !ok || c.DeletedAt.Valid {
+			continue
+		}
+		rows = append(rows, database.ListChirpFlagsRow{Chirp: c, Words: f.Words, FlaggedAt: f.CreatedAt})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return compareKeys(rows[i].FlaggedAt, rows[i].Chirp.ID, rows[j].FlaggedAt, rows[j].Chirp.ID) < 0
+	})
+	key := func(r database.ListChirpFlagsRow) (time.Time, uuid.UUID) { return r.FlaggedAt, r.Chirp.ID }
+	return page(rows, key, arg.CursorCreatedAt, arg.CursorID, arg.Limit, true), nil
+}
+
+func (s *Store) DeleteChirpFlag(ctx context.Context, chirpID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.flags[chirpID]; !ok {
+		return 0, nil
+	}
+	del(s, s.flags, chirpID)
+	return 1, nil
+}
@@ -0,0 +1,91 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type pinKey struct {
+	user  uuid.UUID
+	chirp uuid.UUID
+}
+
+func (s *Store) PinChirp(ctx context.Context, arg database.PinChirpParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.UserID]; !ok {
+		return 0, foreignKeyErr("pinned_chirps_user_id_fkey")
+	}
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return 0, foreignKeyErr("pinned_chirps_chirp_id_fkey")
+	}
+	key := pinKey{user: arg.UserID, chirp: arg.ChirpID}
+	if _, ok := s.pins[key]; ok {
+		return 0, nil
+	}
+	put(s, s.pins, key, now())
+	return 1, nil
+}
+
+func (s *Store) UnpinChirp(ctx context.Context, arg database.UnpinChirpParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := pinKey{user: arg.UserID, chirp: arg.ChirpID}
+	if _, ok := s.pins[key]; !ok {
+		return 0, nil
+	}
+	del(s, s.pins, key)
+	return 1, nil
+}
+
+func (s *Store) CountPinnedChirps(ctx context.Context, userID uuid.UUID) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var n int64
+	for key := range s.pins {
+		if key.user == userID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) ListPinnedChirps(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	type pinned struct {
+		chirp    database.Chirp
+		pinnedAt time.Time
+	}
+	var rows []pinned
+	for key, pinnedAt := range s.pins {
+		if key.user != userID {
+			continue
+		}
+		if c, ok := s.chirps[key.chirp]; ok && !c.DeletedAt.Valid {
+			rows = append(rows, pinned{chirp: c, pinnedAt: pinnedAt})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return compareKeys(rows[i].pinnedAt, rows[i].chirp.ID, rows[j].pinnedAt, rows[j].chirp.ID) > 0
+	})
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.chirp)
+	}
+	return chirps, nil
+}
+
+// unpinLocked removes every pin of a chirp. Callers must hold s.mu.
+func (s *Store) unpinLocked(chirpID uuid.UUID) {
+	for key := range s.pins {
+		if key.chirp == chirpID {
+			del(s, s.pins, key)
+		}
+	}
+}
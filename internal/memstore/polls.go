@@ -0,0 +1,153 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type pollVoteKey struct {
+	chirp uuid.UUID
+	user  uuid.UUID
+}
+
+func (s *Store) CreatePoll(ctx context.Context, arg database.CreatePollParams) (database.Poll, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return database.Poll{}, foreignKeyErr("polls_chirp_id_fkey")
+	}
+	if _, ok := s.polls[arg.ChirpID]; ok {
+		return database.Poll{}, uniqueErr("polls_pkey", "")
+	}
+	if len(arg.Options) < 2 || len(arg.Options) > 4 {
+		return database.Poll{}, checkErr("polls_options_check")
+	}
+	p := database.Poll{
+		ChirpID:        arg.ChirpID,
+		Options:        slices.Clone(arg.Options),
+		MultipleChoice: arg.MultipleChoice,
+		HideResults:    arg.HideResults,
+		ExpiresAt:      arg.ExpiresAt,
+		CreatedAt:      now(),
+	}
+	put(s, s.polls, p.ChirpID, p)
+	return p, nil
+}
+
+func (s *Store) GetPoll(ctx context.Context, chirpID uuid.UUID) (database.Poll, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.polls[chirpID]
+	if !ok {
+		return database.Poll{}, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (s *Store) CastPollVote(ctx context.Context, arg database.CastPollVoteParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.polls[arg.ChirpID]
+	if !ok || !p.ExpiresAt.After(now()) {
+		return 0, nil
+	}
+	if _, ok := s.users[arg.UserID]; !ok {
+		return 0, foreignKeyErr("poll_votes_user_id_fkey")
+	}
+	if len(arg.Choices) == 0 {
+		return 0, checkErr("poll_votes_choices_check")
+	}
+	key := pollVoteKey{chirp: arg.ChirpID, user: arg.UserID}
+	if _, ok := s.pollVotes[key]; ok {
+		return 0, uniqueErr("poll_votes_pkey", "")
+	}
+	put(s, s.pollVotes, key, database.PollVote{
+		ChirpID:   arg.ChirpID,
+		UserID:    arg.UserID,
+		Choices:   slices.Clone(arg.Choices),
+		CreatedAt: now(),
+	})
+	return 1, nil
+}
+
+func (s *Store) ListPollsForChirps(ctx context.Context, chirpIds []uuid.UUID) ([]database.ListPollsForChirpsRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.ListPollsForChirpsRow
+	for _, id := range chirpIds {
+		p, ok := s.polls[id]
+		if !ok {
+			continue
+		}
+		var voters int64
+		for key := range s.pollVotes {
+			if key.chirp == id {
+				voters++
+			}
+		}
+		rows = append(rows, database.ListPollsForChirpsRow{Poll: p, VoterCount: voters})
+	}
+	return rows, nil
+}
+
+func (s *Store) ListPollTallies(ctx context.Context, chirpIds []uuid.UUID) ([]database.ListPollTalliesRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	wanted := make(map[uuid.UUID]bool, len(chirpIds))
+	for _, id := range chirpIds {
+		wanted[id] = true
+	}
+	type tallyKey struct {
+		chirp  uuid.UUID
+		choice int32
+	}
+	tallies := map[tallyKey]int64{}
+	for key, v := range s.pollVotes {
+		if !wanted[key.chirp] {
+			continue
+		}
+		for _, choice := range v.Choices {
+			tallies[tallyKey{key.chirp, choice}]++
+		}
+	}
+	rows := make([]database.ListPollTalliesRow, 0, len(tallies))
+	for key, votes := range tallies {
+		rows = append(rows, database.ListPollTalliesRow{ChirpID: key.chirp, Choice: key.choice, Votes: votes})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].ChirpID != rows[j].ChirpID {
+			return rows[i].ChirpID.String() < rows[j].ChirpID.String()
+		}
+		return rows[i].Choice < rows[j].Choice
+	})
+	return rows, nil
+}
+
+func (s *Store) ListPollVotesByUser(ctx context.Context, arg database.ListPollVotesByUserParams) ([]database.PollVote, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.PollVote
+	for _, id := range arg.ChirpIds {
+		if v, ok := s.pollVotes[pollVoteKey{chirp: id, user: arg.UserID}]; ok {
+			rows = append(rows, v)
+		}
+	}
+	return rows, nil
+}
+
+// deletePollLocked removes a chirp's poll and the votes cast in it.
+// Callers must hold s.mu.
+func (s *Store) deletePollLocked(chirpID uuid.UUID) {
+	del(s, s.polls, chirpID)
+	for key := range s.pollVotes {
+		if key.chirp == chirpID {
+			del(s, s.pollVotes, key)
+		}
+	}
+}
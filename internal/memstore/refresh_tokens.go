@@ -0,0 +1,196 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// tokenByHashLocked finds a refresh token by its hash. Callers must hold
+// s.mu.
+func (s *Store) tokenByHashLocked(hash string) (database.RefreshToken, bool) {
+	for _, rt := range s.refreshTokens {
+		if rt.TokenHash.Valid && rt.TokenHash.String == hash {
+			return rt, true
+		}
+	}
+	return database.RefreshToken{}, false
+}
+
+func (s *Store) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tokenByHashLocked(arg.TokenHash); ok {
+		return database.RefreshToken{}, uniqueErr("refresh_tokens_token_hash_idx", "Key (token_hash) already exists.")
+	}
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.RefreshToken{}, foreignKeyErr("refresh_tokens_user_id_fkey")
+	}
+	t := now()
+	token := database.RefreshToken{
+		ID:               uuid.New(),
+		TokenHash:        sql.NullString{String: arg.TokenHash, Valid: true},
+		CreatedAt:        t,
+		UpdatedAt:        t,
+		UserID:           arg.UserID,
+		ExpiresAt:        arg.ExpiresAt,
+		FamilyID:         arg.FamilyID,
+		DeviceName:       arg.DeviceName,
+		UserAgent:        arg.UserAgent,
+		IpAddress:        arg.IpAddress,
+		SessionCreatedAt: arg.SessionCreatedAt,
+	}
+	put(s, s.refreshTokens, token.ID, token)
+	return token, nil
+}
+
+func (s *Store) GetRefreshToken(ctx context.Context, tokenHash string) (database.RefreshToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rt, ok := s.tokenByHashLocked(tokenHash)
+	if !ok {
+		return database.RefreshToken{}, sql.ErrNoRows
+	}
+	return rt, nil
+}
+
+// LockRefreshToken is GetRefreshToken: ExecTx already serialises transactions.
+func (s *Store) LockRefreshToken(ctx context.Context, tokenHash string) (database.RefreshToken, error) {
+	return s.GetRefreshToken(ctx, tokenHash)
+}
+
+func (s *Store) GetUserFromRefreshToken(ctx context.Context, tokenHash string) (database.GetUserFromRefreshTokenRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rt, ok := s.tokenByHashLocked(tokenHash)
+	if !ok {
+		return database.GetUserFromRefreshTokenRow{}, sql.ErrNoRows
+	}
+	u, ok := s.users[rt.UserID]
+	if !ok || u.DeletedAt.Valid {
+		return database.GetUserFromRefreshTokenRow{}, nil
+	}
+	return database.GetUserFromRefreshTokenRow{
+		ID:             uuid.NullUUID{UUID: u.ID, Valid: true},
+		CreatedAt:      sql.NullTime{Time: u.CreatedAt, Valid: true},
+		UpdatedAt:      sql.NullTime{Time: u.UpdatedAt, Valid: true},
+		Email:          sql.NullString{String: u.Email, Valid: true},
+		HashedPassword: sql.NullString{String: u.HashedPassword, Valid: true},
+		IsChirpyRed:    sql.NullBool{Bool: u.IsChirpyRed, Valid: true},
+		Handle:         u.Handle,
+		DisplayName:    sql.NullString{String: u.DisplayName, Valid: true},
+		Bio:            sql.NullString{String: u.Bio, Valid: true},
+		DeletedAt:      u.DeletedAt,
+		SuspendedAt:    u.SuspendedAt,
+	}, nil
+}
+
+func (s *Store) Revoke(ctx context.Context, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt, ok := s.tokenByHashLocked(tokenHash)
+	if !ok {
+		return nil
+	}
+	t := now()
+	rt.UpdatedAt = t
+	rt.RevokedAt = sql.NullTime{Time: t, Valid: true}
+	put(s, s.refreshTokens, rt.ID, rt)
+	return nil
+}
+
+func (s *Store) RotateRefreshToken(ctx context.Context, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt, ok := s.tokenByHashLocked(tokenHash)
+	if !ok {
+		return nil
+	}
+	t := now()
+	rt.UpdatedAt = t
+	rt.RotatedAt = sql.NullTime{Time: t, Valid: true}
+	if !rt.RevokedAt.Valid {
+		rt.RevokedAt = rt.RotatedAt
+	}
+	put(s, s.refreshTokens, rt.ID, rt)
+	return nil
+}
+
+func (s *Store) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := now()
+	for id, rt := range s.refreshTokens {
+		if rt.FamilyID == familyID && !rt.RevokedAt.Valid {
+			rt.UpdatedAt = t
+			rt.RevokedAt = sql.NullTime{Time: t, Valid: true}
+			put(s, s.refreshTokens, id, rt)
+		}
+	}
+	return nil
+}
+
+func (s *Store) ListUnhashedRefreshTokens(ctx context.Context, limit int32) ([]database.ListUnhashedRefreshTokensRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.ListUnhashedRefreshTokensRow
+	for _, rt := range s.refreshTokens {
+		if len(rows) == int(limit) {
+			break
+		}
+		if !rt.TokenHash.Valid {
+			rows = append(rows, database.ListUnhashedRefreshTokensRow{ID: rt.ID, Token: rt.Token})
+		}
+	}
+	return rows, nil
+}
+
+func (s *Store) SetRefreshTokenHash(ctx context.Context, arg database.SetRefreshTokenHashParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rt, ok := s.refreshTokens[arg.ID]
+	if !ok {
+		return nil
+	}
+	if other, ok := s.tokenByHashLocked(arg.TokenHash); ok && other.ID != rt.ID {
+		return uniqueErr("refresh_tokens_token_hash_idx", "Key (token_hash) already exists.")
+	}
+	rt.TokenHash = sql.NullString{String: arg.TokenHash, Valid: true}
+	rt.Token = sql.NullString{}
+	put(s, s.refreshTokens, rt.ID, rt)
+	return nil
+}
+
+func liveToken(rt database.RefreshToken) bool {
+	return !rt.RevokedAt.Valid && rt.ExpiresAt.After(now())
+}
+
+func (s *Store) ListSessions(ctx context.Context, userID uuid.UUID) ([]database.RefreshToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var sessions []database.RefreshToken
+	for _, rt := range s.refreshTokens {
+		if rt.UserID == userID && liveToken(rt) {
+			sessions = append(sessions, rt)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return compareKeys(sessions[i].CreatedAt, sessions[i].ID, sessions[j].CreatedAt, sessions[j].ID) > 0
+	})
+	return sessions, nil
+}
+
+func (s *Store) GetSession(ctx context.Context, arg database.GetSessionParams) (database.RefreshToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, rt := range s.refreshTokens {
+		if rt.FamilyID == arg.FamilyID && rt.UserID == arg.UserID && liveToken(rt) {
+			return rt, nil
+		}
+	}
+	return database.RefreshToken{}, sql.ErrNoRows
+}
@@ -0,0 +1,35 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// deleteRevisionsLocked removes the edit history of a chirp. Callers must
+// hold s.mu.
+func (s *Store) deleteRevisionsLocked(chirpID uuid.UUID) {
+	for id, rev := range s.revisions {
+		if rev.ChirpID == chirpID {
+			del(s, s.revisions, id)
+		}
+	}
+}
+
+func (s *Store) ListChirpRevisions(ctx context.Context, chirpID uuid.UUID) ([]database.ChirpRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var revisions []database.ChirpRevision
+	for _, rev := range s.revisions {
+		if rev.ChirpID == chirpID {
+			revisions = append(revisions, rev)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return compareKeys(revisions[i].CreatedAt, revisions[i].ID, revisions[j].CreatedAt, revisions[j].ID) < 0
+	})
+	return revisions, nil
+}
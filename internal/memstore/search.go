@@ -0,0 +1,58 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"chirpy/internal/database"
+	"chirpy/internal/search"
+)
+
+// SearchChirps evaluates the tsquery with search.Query's simplified matcher:
+// no stemming, and rank is the share of words that matched.
+func (s *Store) SearchChirps(ctx context.Context, arg database.SearchChirpsParams) ([]database.SearchChirpsRow, error) {
+	q, err := search.ParseTSQuery(arg.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []database.SearchChirpsRow
+	for _, c := range s.chirps {
+		if c.DeletedAt.Valid || (arg.AuthorID.Valid && c.UserID != arg.AuthorID.UUID) {
+			continue
+		}
+		rank, ok := q.Match(c.Body)
+		if !ok {
+			continue
+		}
+		rows = append(rows, database.SearchChirpsRow{Chirp: c, Rank: rank, Snippet: q.Highlight(c.Body)})
+	}
+
+	// Rank first, then (created_at, id), all descending.
+	less := func(a, b database.SearchChirpsRow) bool {
+		if a.Rank != b.Rank {
+			return a.Rank < b.Rank
+		}
+		return compareKeys(a.Chirp.CreatedAt, a.Chirp.ID, b.Chirp.CreatedAt, b.Chirp.ID) < 0
+	}
+	sort.Slice(rows, func(i, j int) bool { return less(rows[j], rows[i]) })
+
+	var items []database.SearchChirpsRow
+	for _, row := range rows {
+		if int32(len(items)) >= arg.Limit {
+			break
+		}
+		if arg.CursorRank.Valid {
+			cursor := database.SearchChirpsRow{Rank: float32(arg.CursorRank.Float64)}
+			cursor.Chirp.CreatedAt = arg.CursorCreatedAt.Time
+			cursor.Chirp.ID = arg.CursorID.UUID
+			if !less(row, cursor) {
+				continue
+			}
+		}
+		items = append(items, row)
+	}
+	return items, nil
+}
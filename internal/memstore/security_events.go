@@ -0,0 +1,44 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+func (s *Store) CreateSecurityEvent(ctx context.Context, arg database.CreateSecurityEventParams) (database.SecurityEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[arg.UserID]; !ok {
+		return database.SecurityEvent{}, foreignKeyErr("security_events_user_id_fkey")
+	}
+	e := database.SecurityEvent{
+		ID:        uuid.New(),
+		UserID:    arg.UserID,
+		Kind:      arg.Kind,
+		FamilyID:  arg.FamilyID,
+		IpAddress: arg.IpAddress,
+		UserAgent: arg.UserAgent,
+		CreatedAt: now(),
+	}
+	put(s, s.events, e.ID, e)
+	return e, nil
+}
+
+func (s *Store) ListSecurityEvents(ctx context.Context, userID uuid.UUID) ([]database.SecurityEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var events []database.SecurityEvent
+	for _, e := range s.events {
+		if e.UserID == userID {
+			events = append(events, e)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return compareKeys(events[i].CreatedAt, events[i].ID, events[j].CreatedAt, events[j].ID) > 0
+	})
+	return events, nil
+}
@@ -0,0 +1,117 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type chirpTagKey struct {
+	chirp uuid.UUID
+	tag   uuid.UUID
+}
+
+func (s *Store) TagChirp(ctx context.Context, arg database.TagChirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return foreignKeyErr("chirp_tags_chirp_id_fkey")
+	}
+	for _, name := range arg.Names {
+		tag, ok := s.tags[name]
+		if !ok {
+			tag = database.Tag{ID: uuid.New(), Name: name, CreatedAt: now()}
+			put(s, s.tags, name, tag)
+		}
+		key := chirpTagKey{chirp: arg.ChirpID, tag: tag.ID}
+		if _, ok := s.chirpTags[key]; !ok {
+			put(s, s.chirpTags, key, arg.CreatedAt)
+		}
+	}
+	return nil
+}
+
+func (s *Store) ListChirpsByTag(ctx context.Context, arg database.ListChirpsByTagParams) ([]database.ListChirpsByTagRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tag, ok := s.tags[arg.Tag]
+	if !ok {
+		return nil, nil
+	}
+	type taggedChirp struct {
+		chirp    database.Chirp
+		taggedAt time.Time
+	}
+	var tagged []taggedChirp
+	for key, createdAt := range s.chirpTags {
+		if key.tag != tag.ID {
+			continue
+		}
+		c, ok := s.chirps[key.chirp]
+		if !ok || c.DeletedAt.Valid {
+			continue
+		}
+		tagged = append(tagged, taggedChirp{chirp: c, taggedAt: createdAt})
+	}
+	sort.Slice(tagged, func(i, j int) bool {
+		return compareKeys(tagged[i].taggedAt, tagged[i].chirp.ID, tagged[j].taggedAt, tagged[j].chirp.ID) < 0
+	})
+	key := func(t taggedChirp) (time.Time, uuid.UUID) { return t.taggedAt, t.chirp.ID }
+	tagged = page(tagged, key, arg.CursorCreatedAt, arg.CursorID, arg.Limit, true)
+	rows := make([]database.ListChirpsByTagRow, 0, len(tagged))
+	for _, t := range tagged {
+		rows = append(rows, database.ListChirpsByTagRow{Chirp: t.chirp})
+	}
+	return rows, nil
+}
+
+func (s *Store) CountTagUsage(ctx context.Context, arg database.CountTagUsageParams) ([]database.CountTagUsageRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make(map[uuid.UUID]string, len(s.tags))
+	for name, tag := range s.tags {
+		names[tag.ID] = name
+	}
+	counts := map[string]*database.CountTagUsageRow{}
+	for key, createdAt := range s.chirpTags {
+		if createdAt.Before(arg.BaselineStart) {
+			continue
+		}
+		if c, ok := s.chirps[key.chirp]; !ok || c.DeletedAt.Valid {
+			continue
+		}
+		name := names[key.tag]
+		row, ok := counts[name]
+		if !ok {
+			row = &database.CountTagUsageRow{Name: name}
+			counts[name] = row
+		}
+		if createdAt.Before(arg.WindowStart) {
+			row.Baseline++
+		} else {
+			row.Recent++
+		}
+	}
+	var rows []database.CountTagUsageRow
+	for _, row := range counts {
+		if row.Recent > 0 {
+			rows = append(rows, *row)
+		}
+	}
+	return rows, nil
+}
+
+func (s *Store) DeleteChirpTags(ctx context.Context, chirpID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.chirpTags {
+		if key.chirp == chirpID {
+			del(s, s.chirpTags, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,414 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"chirpy/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// emailTaken reports whether a live user other than except already owns
+// email; like the partial users_email_key index, deleted users don't count.
+// Callers must hold s.mu.
+func (s *Store) emailTaken(email string, except uuid.UUID) bool {
+	for _, u := range s.users {
+		if u.Email == email && u.ID != except && !u.DeletedAt.Valid {
+			return true
+		}
+	}
+	return false
+}
+
+// handleTaken reports whether a live user other than except already owns
+// handle, ignoring case like the users_handle_key index. Callers must hold
+// s.mu.
+func (s *Store) handleTaken(handle sql.NullString, except uuid.UUID) bool {
+	if !handle.Valid {
+		return false
+	}
+	for _, u := range s.users {
+		if u.Handle.Valid && strings.EqualFold(u.Handle.String, handle.String) && u.ID != except && !u.DeletedAt.Valid {
+			return true
+		}
+	}
+	return false
+}
+
+func handleErr(handle string) error {
+	return uniqueErr("users_handle_key", "Key (lower(handle))=("+strings.ToLower(handle)+") already exists.")
+}
+
+func (s *Store) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.emailTaken(arg.Email, uuid.Nil) {
+		return database.User{}, uniqueErr("users_email_key", "Key (email)=("+arg.Email+") already exists.")
+	}
+	if s.handleTaken(arg.Handle, uuid.Nil) {
+		return database.User{}, handleErr(arg.Handle.String)
+	}
+	t := now()
+	user := database.User{
+		ID:             uuid.New(),
+		CreatedAt:      t,
+		UpdatedAt:      t,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		Handle:         arg.Handle,
+		DisplayName:    arg.DisplayName,
+		Bio:            arg.Bio,
+	}
+	put(s, s.users, user.ID, user)
+	return user, nil
+}
+
+// DeleteAllUsers removes every user along with their chirps and refresh
+// tokens, matching the ON DELETE CASCADE foreign keys. Tags and moderation
+// rules are not owned by users and survive, as they do in Postgres.
+func (s *Store) DeleteAllUsers(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reset(s, &s.users)
+	reset(s, &s.chirps)
+	reset(s, &s.refreshTokens)
+	reset(s, &s.accessTokens)
+	reset(s, &s.follows)
+	reset(s, &s.likes)
+	reset(s, &s.chirpTags)
+	reset(s, &s.mentions)
+	reset(s, &s.revisions)
+	reset(s, &s.flags)
+	reset(s, &s.polls)
+	reset(s, &s.pollVotes)
+	reset(s, &s.drafts)
+	reset(s, &s.bookmarks)
+	reset(s, &s.collections)
+	reset(s, &s.pins)
+	reset(s, &s.events)
+	for id, m := range s.media {
+		m.UserID = uuid.NullUUID{}
+		m.ChirpID = uuid.NullUUID{}
+		m.Position = sql.NullInt16{}
+		put(s, s.media, id, m)
+	}
+	return nil
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, u := range s.users {
+		if u.Email == email && !u.DeletedAt.Valid {
+			return u, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return database.User{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+// LockUser is GetUserByID: ExecTx already serialises transactions.
+func (s *Store) LockUser(ctx context.Context, id uuid.UUID) (database.User, error) {
+	return s.GetUserByID(ctx, id)
+}
+
+func (s *Store) GetUserIncludingDeleted(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	u, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return u, nil
+}
+
+func (s *Store) GetUserProfileByHandle(ctx context.Context, handle string) (database.GetUserProfileByHandleRow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, u := range s.users {
+		if u.DeletedAt.Valid || !u.Handle.Valid || !strings.EqualFold(u.Handle.String, handle) {
+			continue
+		}
+		row := database.GetUserProfileByHandleRow{
+			ID:          u.ID,
+			CreatedAt:   u.CreatedAt,
+			Handle:      u.Handle,
+			DisplayName: u.DisplayName,
+			Bio:         u.Bio,
+			IsChirpyRed: u.IsChirpyRed,
+		}
+		for _, c := range s.chirps {
+			if c.UserID == u.ID && !c.DeletedAt.Valid {
+				row.ChirpCount++
+			}
+		}
+		for key := range s.follows {
+			if key.followee == u.ID {
+				row.FollowerCount++
+			}
+			if key.follower == u.ID {
+				row.FollowingCount++
+			}
+		}
+		return row, nil
+	}
+	return database.GetUserProfileByHandleRow{}, sql.ErrNoRows
+}
+
+func (s *Store) GetUsersByHandles(ctx context.Context, handles []string) ([]database.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	wanted := make(map[string]bool, len(handles))
+	for _, h := range handles {
+		wanted[h] = true
+	}
+	var users []database.User
+	for _, u := range s.users {
+		if !u.DeletedAt.Valid && u.Handle.Valid && wanted[strings.ToLower(u.Handle.String)] {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+// authoredChirps returns the ids of every chirp userID wrote. Callers must
+// hold s.mu.
+func (s *Store) authoredChirps(userID uuid.UUID) map[uuid.UUID]bool {
+	ids := map[uuid.UUID]bool{}
+	for _, c := range s.chirps {
+		if c.UserID == userID {
+			ids[c.ID] = true
+		}
+	}
+	return ids
+}
+
+func (s *Store) SoftDeleteUser(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return nil
+	}
+	t := now()
+	deletedAt := sql.NullTime{Time: t, Valid: true}
+	u.DeletedAt = deletedAt
+	u.UpdatedAt = t
+	put(s, s.users, id, u)
+
+	for tokenID, rt := range s.refreshTokens {
+		if rt.UserID == id && !rt.RevokedAt.Valid {
+			rt.RevokedAt = deletedAt
+			rt.UpdatedAt = t
+			put(s, s.refreshTokens, tokenID, rt)
+		}
+	}
+
+	authored := map[uuid.UUID]bool{}
+	for _, c := range s.chirps {
+		if c.UserID == id && !c.DeletedAt.Valid {
+			authored[c.ID] = true
+		}
+	}
+	for _, c := range s.chirps {
+		if c.DeletedAt.Valid || (c.UserID != id && !(c.RechirpOfID.Valid && authored[c.RechirpOfID.UUID])) {
+			continue
+		}
+		c.DeletedAt = deletedAt
+		c.UpdatedAt = t
+		put(s, s.chirps, c.ID, c)
+	}
+	return nil
+}
+
+func (s *Store) RestoreUser(ctx context.Context, arg database.RestoreUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[arg.ID]
+	if !ok || !u.DeletedAt.Valid || !u.DeletedAt.Time.After(arg.Cutoff) {
+		return database.User{}, sql.ErrNoRows
+	}
+	if s.emailTaken(u.Email, u.ID) {
+		return database.User{}, uniqueErr("users_email_key", "Key (email)=("+u.Email+") already exists.")
+	}
+	if s.handleTaken(u.Handle, u.ID) {
+		return database.User{}, handleErr(u.Handle.String)
+	}
+	t := now()
+	authored := s.authoredChirps(u.ID)
+	for _, c := range s.chirps {
+		if c.DeletedAt != u.DeletedAt || (c.UserID != u.ID && !(c.RechirpOfID.Valid && authored[c.RechirpOfID.UUID])) {
+			continue
+		}
+		c.DeletedAt = sql.NullTime{}
+		c.UpdatedAt = t
+		put(s, s.chirps, c.ID, c)
+	}
+	u.DeletedAt = sql.NullTime{}
+	u.UpdatedAt = t
+	put(s, s.users, u.ID, u)
+	return u, nil
+}
+
+// deleteUserLocked removes a user and applies the ON DELETE CASCADE rules of
+// the tables that reference it. Callers must hold s.mu.
+func (s *Store) deleteUserLocked(id uuid.UUID) {
+	for chirpID := range s.authoredChirps(id) {
+		if _, ok := s.chirps[chirpID]; ok {
+			s.deleteChirpLocked(chirpID)
+		}
+	}
+	for tokenID, rt := range s.refreshTokens {
+		if rt.UserID == id {
+			del(s, s.refreshTokens, tokenID)
+		}
+	}
+	for jti, at := range s.accessTokens {
+		if at.UserID == id {
+			del(s, s.accessTokens, jti)
+		}
+	}
+	for key := range s.follows {
+		if key.follower == id || key.followee == id {
+			del(s, s.follows, key)
+		}
+	}
+	for key := range s.likes {
+		if key.user == id {
+			del(s, s.likes, key)
+			s.addLikeCount(key.chirp, -1)
+		}
+	}
+	for key, m := range s.mentions {
+		if m.UserID == id {
+			del(s, s.mentions, key)
+		}
+	}
+	for draftID, d := range s.drafts {
+		if d.UserID == id {
+			del(s, s.drafts, draftID)
+		}
+	}
+	for key := range s.pollVotes {
+		if key.user == id {
+			del(s, s.pollVotes, key)
+		}
+	}
+	for key := range s.bookmarks {
+		if key.user == id {
+			del(s, s.bookmarks, key)
+		}
+	}
+	for collectionID, c := range s.collections {
+		if c.UserID == id {
+			del(s, s.collections, collectionID)
+		}
+	}
+	for key := range s.pins {
+		if key.user == id {
+			del(s, s.pins, key)
+		}
+	}
+	for eventID, e := range s.events {
+		if e.UserID == id {
+			del(s, s.events, eventID)
+		}
+	}
+	for mediaID, m := range s.media {
+		if m.UserID.Valid && m.UserID.UUID == id {
+			m.UserID = uuid.NullUUID{}
+			put(s, s.media, mediaID, m)
+		}
+	}
+	del(s, s.users, id)
+}
+
+func (s *Store) PurgeDeletedUsers(ctx context.Context, cutoff time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, u := range s.users {
+		if u.DeletedAt.Valid && u.DeletedAt.Time.Before(cutoff) {
+			s.deleteUserLocked(u.ID)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[arg.ID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	if s.emailTaken(arg.Email, arg.ID) {
+		return database.User{}, uniqueErr("users_email_key", "Key (email)=("+arg.Email+") already exists.")
+	}
+	if s.handleTaken(arg.Handle, arg.ID) {
+		return database.User{}, handleErr(arg.Handle.String)
+	}
+	u.HashedPassword = arg.HashedPassword
+	u.Email = arg.Email
+	u.Handle = arg.Handle
+	u.DisplayName = arg.DisplayName
+	u.Bio = arg.Bio
+	u.UpdatedAt = now()
+	put(s, s.users, u.ID, u)
+	return u, nil
+}
+
+func (s *Store) UpgradeUserRed(ctx context.Context, id uuid.UUID) (database.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	u.IsChirpyRed = true
+	u.UpdatedAt = now()
+	put(s, s.users, u.ID, u)
+	return u, nil
+}
+
+func (s *Store) SuspendUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return 0, nil
+	}
+	t := now()
+	if !u.SuspendedAt.Valid {
+		u.SuspendedAt = sql.NullTime{Time: t, Valid: true}
+	}
+	u.UpdatedAt = t
+	put(s, s.users, u.ID, u)
+	return 1, nil
+}
+
+func (s *Store) UnsuspendUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok || u.DeletedAt.Valid {
+		return 0, nil
+	}
+	u.SuspendedAt = sql.NullTime{}
+	u.UpdatedAt = now()
+	put(s, s.users, u.ID, u)
+	return 1, nil
+}
@@ -0,0 +1,312 @@
+// Package migrate applies goose-annotated SQL migrations and records them in
+// a goose-compatible goose_db_version table, so the goose CLI and the server
+// binary can be used interchangeably against the same database.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+const versionTable = "goose_db_version"
+
+// lockID is the advisory lock key goose takes while migrating, so the goose
+// CLI and any number of servers started with -migrate take turns.
+const lockID int64 = 5887940537704921958
+
+var (
+	ErrNoMigrations   = errors.New("no migrations to roll back")
+	ErrDatabaseAhead  = errors.New("database schema is newer than this binary")
+	ErrUnknownVersion = errors.New("database has a migration version this binary does not know")
+)
+
+// conn is satisfied by both *sql.DB and the *sql.Conn that holds the
+// advisory lock.
+type conn interface {
+	execer
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+type Migrator struct {
+	db         conn
+	pool       *sql.DB
+	migrations []Migration
+}
+
+// Status describes one known migration and whether it has been applied.
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+	Applied   bool
+}
+
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, pool: db, migrations: migrations}, nil
+}
+
+// locked runs fn with the migration advisory lock held. Session-level
+// advisory locks belong to a single connection, so fn gets a Migrator
+// pinned to the connection holding the lock.
+func (m *Migrator) locked(ctx context.Context, fn func(m *Migrator) error) (err error) {
+	c, err := m.pool.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if _, err := c.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("taking migration lock: %w", err)
+	}
+	defer func() {
+		// Unlock even if ctx has been cancelled; closing the connection
+		// would release the lock anyway, but it goes back to the pool.
+		if _, uerr := c.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID); uerr != nil && err == nil {
+			err = fmt.Errorf("releasing migration lock: %w", uerr)
+		}
+	}()
+	return fn(&Migrator{db: c, pool: m.pool, migrations: m.migrations})
+}
+
+// Latest returns the highest version known to the binary.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+versionTable+` (
+    id SERIAL PRIMARY KEY,
+    version_id BIGINT NOT NULL,
+    is_applied BOOLEAN NOT NULL,
+    tstamp TIMESTAMP DEFAULT NOW()
+)`)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", versionTable, err)
+	}
+	// goose seeds the table with version 0 so an empty table can be told
+	// apart from a missing one.
+	_, err = m.db.ExecContext(ctx, `INSERT INTO `+versionTable+` (version_id, is_applied)
+SELECT 0, true WHERE NOT EXISTS (SELECT 1 FROM `+versionTable+`)`)
+	return err
+}
+
+// applied returns the applied versions and when they were applied. Rows are
+// read newest first and only the latest row per version counts, which is how
+// goose interprets the table.
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT version_id, is_applied, tstamp FROM `+versionTable+` ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	seen := map[int64]bool{}
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var tstamp sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &tstamp); err != nil {
+			return nil, err
+		}
+		if seen[version] {
+			continue
+		}
+		seen[version] = true
+		if isApplied && version > 0 {
+			applied[version] = tstamp.Time
+		}
+	}
+	return applied, rows.Err()
+}
+
+// Version returns the highest applied version, or 0 for a fresh database.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return 0, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var current int64
+	for v := range applied {
+		if v > current {
+			current = v
+		}
+	}
+	return current, nil
+}
+
+// Check returns ErrDatabaseAhead if the database has been migrated past the
+// newest migration embedded in the binary.
+func (m *Migrator) Check(ctx context.Context) error {
+	current, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if current > m.Latest() {
+		return fmt.Errorf("%w: database is at version %d, binary knows up to %d", ErrDatabaseAhead, current, m.Latest())
+	}
+	return nil
+}
+
+// Pending returns the migrations that have not been applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied. Concurrent callers wait for each other, and whoever goes
+// second finds nothing left to do.
+func (m *Migrator) Up(ctx context.Context) (applied []Migration, err error) {
+	err = m.locked(ctx, func(m *Migrator) error {
+		applied, err = m.up(ctx)
+		return err
+	})
+	return applied, err
+}
+
+func (m *Migrator) up(ctx context.Context) ([]Migration, error) {
+	if err := m.Check(ctx); err != nil {
+		return nil, err
+	}
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, mig := range pending {
+		if err := m.run(ctx, mig, mig.Up, true); err != nil {
+			return pending[:i], err
+		}
+	}
+	return pending, nil
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) (mig Migration, err error) {
+	err = m.locked(ctx, func(m *Migrator) error {
+		mig, err = m.down(ctx)
+		return err
+	})
+	return mig, err
+}
+
+func (m *Migrator) down(ctx context.Context) (Migration, error) {
+	current, err := m.Version(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	if current == 0 {
+		return Migration{}, ErrNoMigrations
+	}
+	mig, ok := m.find(current)
+	if !ok {
+		return Migration{}, fmt.Errorf("%w: %d", ErrUnknownVersion, current)
+	}
+	return mig, m.run(ctx, mig, mig.Down, false)
+}
+
+// Redo rolls back the most recent migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) (mig Migration, err error) {
+	err = m.locked(ctx, func(m *Migrator) error {
+		if mig, err = m.down(ctx); err != nil {
+			return err
+		}
+		return m.run(ctx, mig, mig.Up, true)
+	})
+	return mig, err
+}
+
+// Status lists every known migration along with when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		at, ok := applied[mig.Version]
+		statuses = append(statuses, Status{
+			Version:   mig.Version,
+			Name:      mig.Name,
+			AppliedAt: at,
+			Applied:   ok,
+		})
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig, true
+		}
+	}
+	return Migration{}, false
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// run executes statements and records the version change, inside a single
+// transaction unless the migration opted out.
+func (m *Migrator) run(ctx context.Context, mig Migration, statements []string, up bool) error {
+	apply := func(db execer) error {
+		for _, stmt := range statements {
+			if _, err := db.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("%s: %w", mig.Name, err)
+			}
+		}
+		var err error
+		if up {
+			_, err = db.ExecContext(ctx, `INSERT INTO `+versionTable+` (version_id, is_applied) VALUES ($1, true)`, mig.Version)
+		} else {
+			_, err = db.ExecContext(ctx, `DELETE FROM `+versionTable+` WHERE version_id = $1`, mig.Version)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: recording version: %w", mig.Name, err)
+		}
+		return nil
+	}
+
+	if !mig.UseTx {
+		return apply(m.db)
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := apply(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
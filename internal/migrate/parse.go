@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration is a single goose-annotated SQL file.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      []string
+	Down    []string
+	// UseTx is false when the file is annotated with NO TRANSACTION.
+	UseTx bool
+}
+
+const (
+	annotationPrefix = "-- +goose"
+	directionNone    = ""
+	directionUp      = "Up"
+	directionDown    = "Down"
+)
+
+// Parse splits a goose migration into its Up and Down statements. A
+// statement ends at a line ending in a semicolon unless it is wrapped in
+// StatementBegin/StatementEnd.
+func Parse(name string, r io.Reader) (Migration, error) {
+	version, err := versionFromName(name)
+	if err != nil {
+		return Migration{}, err
+	}
+	m := Migration{Version: version, Name: name, UseTx: true}
+
+	direction := directionNone
+	inBlock := false
+	var buf strings.Builder
+	flush := func() {
+		stmt := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if stmt == "" {
+			return
+		}
+		switch direction {
+		case directionUp:
+			m.Up = append(m.Up, stmt)
+		case directionDown:
+			m.Down = append(m.Down, stmt)
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, annotationPrefix) {
+			switch cmd := strings.TrimSpace(strings.TrimPrefix(trimmed, annotationPrefix)); cmd {
+			case "Up", "Down":
+				if inBlock {
+					return Migration{}, fmt.Errorf("%s: %s inside StatementBegin block", name, cmd)
+				}
+				flush()
+				direction = cmd
+			case "StatementBegin":
+				flush()
+				inBlock = true
+			case "StatementEnd":
+				inBlock = false
+				flush()
+			case "NO TRANSACTION":
+				m.UseTx = false
+			default:
+				return Migration{}, fmt.Errorf("%s: unknown goose annotation %q", name, cmd)
+			}
+			continue
+		}
+
+		if direction == directionNone {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Migration{}, fmt.Errorf("%s: %w", name, err)
+	}
+	if inBlock {
+		return Migration{}, fmt.Errorf("%s: missing StatementEnd", name)
+	}
+	flush()
+
+	if m.Up == nil {
+		return Migration{}, fmt.Errorf("%s: no +goose Up section", name)
+	}
+	return m, nil
+}
+
+func versionFromName(name string) (int64, error) {
+	base := path.Base(name)
+	prefix, _, ok := strings.Cut(base, "_")
+	if !ok {
+		return 0, fmt.Errorf("%s: migration file names must look like 001_name.sql", name)
+	}
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("%s: invalid migration version %q", name, prefix)
+	}
+	return version, nil
+}
+
+// Load parses every .sql file at the root of fsys and returns them ordered
+// by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]Migration, 0, len(names))
+	seen := map[int64]string{}
+	for _, name := range names {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		m, err := Parse(name, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[m.Version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", m.Version, other, name)
+		}
+		seen[m.Version] = name
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"chirpy/sql/schema"
+)
+
+func TestParse_UpAndDown(t *testing.T) {
+	src := `-- +goose Up
+CREATE TABLE a (id INT);
+CREATE INDEX a_id ON a (id);
+
+-- +goose Down
+DROP TABLE a;`
+	m, err := Parse("007_a.sql", strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if m.Version != 7 {
+		t.Errorf("got version %d, want 7", m.Version)
+	}
+	if len(m.Up) != 2 || len(m.Down) != 1 {
+		t.Fatalf("got %d up and %d down statements: %q %q", len(m.Up), len(m.Down), m.Up, m.Down)
+	}
+	if m.Down[0] != "DROP TABLE a;" {
+		t.Errorf("unexpected down statement %q", m.Down[0])
+	}
+	if !m.UseTx {
+		t.Error("migration should run in a transaction by default")
+	}
+}
+
+func TestParse_StatementBlock(t *testing.T) {
+	src := `-- +goose NO TRANSACTION
+-- +goose Up
+-- +goose StatementBegin
+CREATE FUNCTION f() RETURNS INT AS $$
+BEGIN
+    RETURN 1;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+`
+	m, err := Parse("001_f.sql", strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(m.Up) != 1 || !strings.Contains(m.Up[0], "RETURN 1;") {
+		t.Errorf("block was split: %q", m.Up)
+	}
+	if m.UseTx {
+		t.Error("NO TRANSACTION was ignored")
+	}
+}
+
+func TestParse_InvalidName(t *testing.T) {
+	if _, err := Parse("users.sql", strings.NewReader("-- +goose Up\nSELECT 1;")); err == nil {
+		t.Error("Parse accepted a file name without a version")
+	}
+}
+
+func TestLoad_EmbeddedSchema(t *testing.T) {
+	migrations, err := Load(schema.FS)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range migrations {
+		if int64(i+1) != m.Version {
+			t.Errorf("migration %s has version %d, want %d", m.Name, m.Version, i+1)
+		}
+		if len(m.Down) == 0 {
+			t.Errorf("migration %s has no down statements", m.Name)
+		}
+	}
+}
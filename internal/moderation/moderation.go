@@ -0,0 +1,194 @@
+// Package moderation checks chirp bodies against a list of word rules.
+// Words are matched after folding case, accents, leetspeak and look-alike
+// characters from other scripts, so "K3rfuffle!" and "kеrfuffle" (with a
+// Cyrillic е) both trip a rule for "kerfuffle". Each rule says what happens
+// to a match: mask it, reject the chirp or flag it for review.
+package moderation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type Action string
+
+const (
+	// ActionMask replaces the word with asterisks.
+	ActionMask Action = "mask"
+	// ActionReject refuses the whole chirp.
+	ActionReject Action = "reject"
+	// ActionFlag lets the chirp through but queues it for review.
+	ActionFlag Action = "flag"
+	// ActionAllow switches off a rule from an earlier layer, so an admin
+	// can lift a word that the config file blocks.
+	ActionAllow Action = "allow"
+)
+
+func (a Action) Valid() bool {
+	switch a {
+	case ActionMask, ActionReject, ActionFlag, ActionAllow:
+		return true
+	}
+	return false
+}
+
+// maxWordLength bounds the words a rule can match.
+const maxWordLength = 50
+
+// mask is what masked words are replaced with, whatever their length.
+const mask = "****"
+
+type Rule struct {
+	Word   string `json:"word"`
+	Action Action `json:"action"`
+}
+
+// DefaultRules are used when no rules file is configured.
+var DefaultRules = []Rule{
+	{Word: "kerfuffle", Action: ActionMask},
+	{Word: "sharbert", Action: ActionMask},
+	{Word: "fornax", Action: ActionMask},
+}
+
+// Validate checks that r names a known action and a single word.
+func (r Rule) Validate() error {
+	if !r.Action.Valid() {
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	if spans := words(r.Word); len(spans) != 1 || spans[0] != (span{0, len(r.Word)}) {
+		return errors.New("a rule must match exactly one word")
+	}
+	if Normalize(r.Word) == "" {
+		return errors.New("a rule must match a visible word")
+	}
+	if len([]rune(r.Word)) > maxWordLength {
+		return fmt.Errorf("a rule word can be at most %d characters", maxWordLength)
+	}
+	return nil
+}
+
+// LoadRules reads a JSON array of rules from path.
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: rule %q: %w", path, r.Word, err)
+		}
+	}
+	return rules, nil
+}
+
+// Filter matches text against a fixed set of rules. It is safe for
+// concurrent use.
+type Filter struct {
+	rules map[string]Rule
+}
+
+// NewFilter builds a filter from layers of rules. A rule in a later layer
+// replaces one for the same word in an earlier layer.
+func NewFilter(layers ...[]Rule) *Filter {
+	f := &Filter{rules: map[string]Rule{}}
+	for _, layer := range layers {
+		for _, r := range layer {
+			key := Normalize(r.Word)
+			if r.Action == ActionAllow {
+				delete(f.rules, key)
+				continue
+			}
+			f.rules[key] = r
+		}
+	}
+	return f
+}
+
+// Hit reports that a rule fired, and how many times.
+type Hit struct {
+	Word   string `json:"word"`
+	Action Action `json:"action"`
+	Count  int    `json:"count"`
+}
+
+// Result is the outcome of checking one text.
+type Result struct {
+	// Text is the input with every masked word replaced.
+	Text string
+	// Hits lists the rules that fired, in the order they first matched.
+	Hits []Hit
+}
+
+func (r Result) has(action Action) bool {
+	for _, h := range r.Hits {
+		if h.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Rejected reports whether a reject rule fired.
+func (r Result) Rejected() bool {
+	return r.has(ActionReject)
+}
+
+// Flagged returns the words of the flag rules that fired.
+func (r Result) Flagged() []string {
+	var flagged []string
+	for _, h := range r.Hits {
+		if h.Action == ActionFlag {
+			flagged = append(flagged, h.Word)
+		}
+	}
+	return flagged
+}
+
+// match finds the rule for the word at s, trying it with and without the
+// symbols around it. It returns the part of the word that matched.
+func (f *Filter) match(text string, s span) (Rule, span, bool) {
+	if r, ok := f.rules[Normalize(text[s.start:s.end])]; ok {
+		return r, s, true
+	}
+	t := s.trimmed(text)
+	if t != s {
+		if r, ok := f.rules[Normalize(text[t.start:t.end])]; ok {
+			return r, t, true
+		}
+	}
+	return Rule{}, span{}, false
+}
+
+// Check applies the filter to text.
+func (f *Filter) Check(text string) Result {
+	var b strings.Builder
+	var hits []Hit
+	index := map[string]int{}
+	pos := 0
+	for _, s := range words(text) {
+		rule, matched, ok := f.match(text, s)
+		if !ok {
+			continue
+		}
+		if i, seen := index[rule.Word]; seen {
+			hits[i].Count++
+		} else {
+			index[rule.Word] = len(hits)
+			hits = append(hits, Hit{Word: rule.Word, Action: rule.Action, Count: 1})
+		}
+		if rule.Action == ActionMask {
+			b.WriteString(text[pos:matched.start])
+			b.WriteString(mask)
+			pos = matched.end
+		}
+	}
+	b.WriteString(text[pos:])
+	return Result{Text: b.String(), Hits: hits}
+}
@@ -0,0 +1,75 @@
+package moderation
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCheck_Masks(t *testing.T) {
+	f := NewFilter(DefaultRules)
+	cases := []struct {
+		in, want string
+	}{
+		{"what a kerfuffle", "what a ****"},
+		{"Kerfuffle! said the sharbert.", "****! said the ****."},
+		{"K3RFUFFL3 and $harbert", "**** and ****"},
+		{"kеrfuffle", "****"},       // Cyrillic е
+		{"ｆｏｒｎａｘ", "****"},          // fullwidth
+		{"ker\u200bfuffle", "****"}, // zero-width space
+		{"fórnax", "****"},          // precomposed accent
+		{"fo\u0301rnax", "****"},    // combining accent
+		{"kerfuffles are fine", "kerfuffles are fine"},
+		{"@fornax", "@****"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := f.Check(c.in).Text; got != c.want {
+			t.Errorf("Check(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestCheck_ReportsHits(t *testing.T) {
+	f := NewFilter(
+		DefaultRules,
+		[]Rule{
+			{Word: "spam", Action: ActionReject},
+			{Word: "crypto", Action: ActionFlag},
+			{Word: "fornax", Action: ActionAllow},
+		},
+	)
+	res := f.Check("crypto kerfuffle, fornax, more CRYPT0 and sp4m")
+	want := []Hit{
+		{Word: "crypto", Action: ActionFlag, Count: 2},
+		{Word: "kerfuffle", Action: ActionMask, Count: 1},
+		{Word: "spam", Action: ActionReject, Count: 1},
+	}
+	if !slices.Equal(res.Hits, want) {
+		t.Errorf("got hits %+v, want %+v", res.Hits, want)
+	}
+	if !res.Rejected() {
+		t.Error("reject rule did not reject")
+	}
+	if got := res.Flagged(); !slices.Equal(got, []string{"crypto"}) {
+		t.Errorf("Flagged() = %v", got)
+	}
+	if res.Text != "crypto ****, fornax, more CRYPT0 and sp4m" {
+		t.Errorf("got text %q", res.Text)
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	for _, r := range []Rule{
+		{Word: "two words", Action: ActionMask},
+		{Word: "", Action: ActionMask},
+		{Word: "word", Action: "delete"},
+		{Word: "\u200b", Action: ActionMask},
+	} {
+		if err := r.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", r)
+		}
+	}
+	if err := (Rule{Word: "sh4rbert", Action: ActionFlag}).Validate(); err != nil {
+		t.Errorf("valid rule rejected: %v", err)
+	}
+}
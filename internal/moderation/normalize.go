@@ -0,0 +1,121 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// leetSymbols are the non-alphanumeric characters people put in words to
+// dodge a filter. They count as part of a word while tokenizing.
+const leetSymbols = "@$!|+"
+
+// lookalikes folds characters that are commonly swapped for one another
+// onto a single representative, so "k3rfuffl3", "kerfuffie" and
+// "kerfuffle" all compare equal. Both rule words and chirp text go through
+// it, which is why letters such as l and i share a class.
+var lookalikes = map[rune]rune{
+	// leetspeak
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '!': 'i', '|': 'i', '+': 't',
+	'l': 'i',
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o',
+	'р': 'p', 'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ї': 'i', 'ј': 'j',
+	'ѕ': 's', 'һ': 'h', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	// Latin letters with diacritics
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'ā': 'a',
+	'ç': 'c', 'č': 'c',
+	'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e', 'ē': 'e', 'ę': 'e',
+	'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i', 'ī': 'i', 'ı': 'i', 'ł': 'i',
+	'ñ': 'n', 'ń': 'n',
+	'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o', 'ø': 'o', 'ō': 'o',
+	'ß': 's', 'š': 's', 'ś': 's',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ū': 'u',
+	'ý': 'y', 'ÿ': 'y',
+	'ž': 'z', 'ź': 'z', 'ż': 'z',
+}
+
+// fold maps one character to its canonical form. ok is false for
+// characters that carry no meaning for matching, such as combining accents
+// and zero-width joiners, which are dropped.
+func fold(r rune) (rune, bool) {
+	if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Cf, r) {
+		return 0, false
+	}
+	// Fullwidth forms such as "ｋ" map onto ASCII.
+	if r >= 0xFF01 && r <= 0xFF5E {
+		r -= 0xFEE0
+	}
+	r = unicode.ToLower(r)
+	if f, ok := lookalikes[r]; ok {
+		return f, true
+	}
+	return r, true
+}
+
+// Normalize returns the canonical form of word that rules are matched on.
+func Normalize(word string) string {
+	var b strings.Builder
+	for _, r := range word {
+		if f, ok := fold(r); ok {
+			b.WriteRune(f)
+		}
+	}
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) ||
+		unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Cf, r) ||
+		strings.ContainsRune(leetSymbols, r)
+}
+
+// isCoreRune reports whether r can start or end a word: a letter, a number
+// or an accent on one.
+func isCoreRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Mn, r)
+}
+
+// span is a word's byte range in the text.
+type span struct {
+	start, end int
+}
+
+// words splits text into runs of word characters. Spaces and punctuation
+// other than leetSymbols separate words.
+func words(text string) []span {
+	var spans []span
+	start := -1
+	for i, r := range text {
+		w := isWordRune(r)
+		switch {
+		case w && start < 0:
+			start = i
+		case !w && start >= 0:
+			spans = append(spans, span{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, span{start, len(text)})
+	}
+	return spans
+}
+
+// trimmed drops leading and trailing leetSymbols and invisible characters,
+// so the "!" in "kerfuffle!" is read as punctuation rather than
+// as part of the word.
+func (s span) trimmed(text string) span {
+	word := text[s.start:s.end]
+	left := strings.IndexFunc(word, isCoreRune)
+	if left < 0 {
+		return span{s.start, s.start}
+	}
+	right := strings.LastIndexFunc(word, isCoreRune)
+	_, size := utf8.DecodeRuneInString(word[right:])
+	return span{s.start + left, s.start + right + size}
+}
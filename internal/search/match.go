@@ -0,0 +1,111 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+)
+
+type token struct {
+	word       string
+	start, end int
+}
+
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsNumber(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			tokens = append(tokens, token{word: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{word: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// matchesAt reports whether t matches the tokens starting at i.
+func (t Term) matchesAt(tokens []token, i int) bool {
+	if i+len(t.Words) > len(tokens) {
+		return false
+	}
+	for j, w := range t.Words {
+		got := tokens[i+j].word
+		last := j == len(t.Words)-1
+		if got != w && !(last && t.Prefix && strings.HasPrefix(got, w)) {
+			return false
+		}
+	}
+	return true
+}
+
+// hits returns the token index of every match of every positive term, or
+// nil if the text does not satisfy the query.
+func (q Query) hits(tokens []token) map[int]int {
+	hits := map[int]int{}
+	for _, t := range q.Terms {
+		found := false
+		for i := range tokens {
+			if t.matchesAt(tokens, i) {
+				found = true
+				if !t.Negate {
+					hits[i] = max(hits[i], len(t.Words))
+				}
+			}
+		}
+		if found == t.Negate {
+			return nil
+		}
+	}
+	return hits
+}
+
+// Match reports whether text satisfies q and scores it. This is a
+// simplified stand-in for ts_rank: words are compared exactly rather than
+// stemmed and the score is the share of words that matched.
+func (q Query) Match(text string) (float32, bool) {
+	tokens := tokenize(text)
+	hits := q.hits(tokens)
+	if hits == nil {
+		return 0, false
+	}
+	matched := 0
+	for _, n := range hits {
+		matched += n
+	}
+	return float32(matched) / float32(len(tokens)), true
+}
+
+// Highlight wraps every match in text with <mark> tags, like the snippets
+// ts_headline produces.
+func (q Query) Highlight(text string) string {
+	tokens := tokenize(text)
+	hits := q.hits(tokens)
+	var b strings.Builder
+	pos := 0
+	for i := 0; i < len(tokens); i++ {
+		n, ok := hits[i]
+		if !ok {
+			continue
+		}
+		end := tokens[i+n-1].end
+		b.WriteString(text[pos:tokens[i].start])
+		b.WriteString(highlightStart)
+		b.WriteString(text[tokens[i].start:end])
+		b.WriteString(highlightStop)
+		pos = end
+		i += n - 1
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
@@ -0,0 +1,141 @@
+// Package search turns user search strings into Postgres tsquery syntax and
+// provides a simplified matcher with the same semantics for the in-memory
+// store. Supported syntax: bare words (all must match), "quoted phrases",
+// trailing * for prefix matches and a leading - to exclude a term.
+package search
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var ErrEmptyQuery = errors.New("search query must contain at least one word to match")
+
+// Term is one condition of a query. More than one word makes it a phrase
+// whose words must appear next to each other.
+type Term struct {
+	Words  []string
+	Prefix bool
+	Negate bool
+}
+
+type Query struct {
+	Terms []Term
+}
+
+// Parse reads a user-supplied search string.
+func Parse(s string) (Query, error) {
+	var q Query
+	for len(s) > 0 {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			break
+		}
+
+		var t Term
+		if s[0] == '-' {
+			t.Negate = true
+			s = s[1:]
+		}
+
+		var raw string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				raw, s = s[1:], ""
+			} else {
+				raw, s = s[1:end+1], s[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(s, unicode.IsSpace)
+			if end < 0 {
+				end = len(s)
+			}
+			raw, s = s[:end], s[end:]
+		}
+
+		raw = strings.TrimSpace(raw)
+		if strings.HasSuffix(raw, "*") {
+			t.Prefix = true
+			raw = strings.TrimRight(raw, "*")
+		}
+		t.Words = words(raw)
+		if len(t.Words) > 0 {
+			q.Terms = append(q.Terms, t)
+		}
+	}
+
+	for _, t := range q.Terms {
+		if !t.Negate {
+			return q, nil
+		}
+	}
+	return Query{}, ErrEmptyQuery
+}
+
+// words lowercases s and splits it on anything that is not a letter or a
+// digit, which also strips tsquery operators out of user input.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// TSQuery renders q for to_tsquery.
+func (q Query) TSQuery() string {
+	parts := make([]string, 0, len(q.Terms))
+	for _, t := range q.Terms {
+		var b strings.Builder
+		if t.Negate {
+			b.WriteString("!")
+		}
+		words := t.Words
+		if t.Prefix {
+			// Only the last word of a phrase is a prefix; to_tsquery
+			// rejects :* after a parenthesised group.
+			words = slices.Clone(words)
+			words[len(words)-1] += ":*"
+		}
+		if len(words) > 1 {
+			b.WriteString("(")
+		}
+		b.WriteString(strings.Join(words, " <-> "))
+		if len(words) > 1 {
+			b.WriteString(")")
+		}
+		parts = append(parts, b.String())
+	}
+	return strings.Join(parts, " & ")
+}
+
+// ParseTSQuery reverses TSQuery. It only understands the subset of tsquery
+// syntax that TSQuery produces.
+func ParseTSQuery(s string) (Query, error) {
+	var q Query
+	for _, part := range strings.Split(s, " & ") {
+		var t Term
+		if strings.HasPrefix(part, "!") {
+			t.Negate = true
+			part = part[1:]
+		}
+		part = strings.TrimSuffix(strings.TrimPrefix(part, "("), ")")
+		if strings.HasSuffix(part, ":*") {
+			t.Prefix = true
+			part = strings.TrimSuffix(part, ":*")
+		}
+		for _, w := range strings.Split(part, " <-> ") {
+			if w = strings.TrimSpace(w); w != "" {
+				t.Words = append(t.Words, w)
+			}
+		}
+		if len(t.Words) > 0 {
+			q.Terms = append(q.Terms, t)
+		}
+	}
+	if len(q.Terms) == 0 {
+		return Query{}, ErrEmptyQuery
+	}
+	return q, nil
+}
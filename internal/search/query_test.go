@@ -0,0 +1,79 @@
+package search
+
+import (
+	"testing"
+)
+
+func TestParse_TSQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"hello world", "hello & world"},
+		{`"big red dog"`, "(big <-> red <-> dog)"},
+		{"chirp* -spam", "chirp:* & !spam"},
+		{"Don't", "(don <-> t)"},
+		{"a&b | !c:*", "(a <-> b) & c:*"},
+		{`"big wor*"`, "(big <-> wor:*)"},
+		{"foo-bar*", "(foo <-> bar:*)"},
+		{`cat -"big wor*"`, "cat & !(big <-> wor:*)"},
+	}
+	for _, tc := range tests {
+		q, err := Parse(tc.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tc.input, err)
+			continue
+		}
+		if got := q.TSQuery(); got != tc.want {
+			t.Errorf("Parse(%q).TSQuery() = %q, want %q", tc.input, got, tc.want)
+		}
+		back, err := ParseTSQuery(q.TSQuery())
+		if err != nil || back.TSQuery() != tc.want {
+			t.Errorf("ParseTSQuery(%q) did not round trip: %q, %v", tc.want, back.TSQuery(), err)
+		}
+	}
+}
+
+func TestParse_OnlyNegated(t *testing.T) {
+	if _, err := Parse("-spam"); err != ErrEmptyQuery {
+		t.Errorf("got %v, want ErrEmptyQuery", err)
+	}
+	if _, err := Parse("  !!! "); err != ErrEmptyQuery {
+		t.Errorf("got %v, want ErrEmptyQuery", err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		query string
+		text  string
+		match bool
+	}{
+		{"dog", "The Dog barked", true},
+		{"dog", "hotdog stand", false},
+		{`"red dog"`, "a big red dog", true},
+		{`"red dog"`, "a red big dog", false},
+		{"chirp*", "Chirpy is great", true},
+		{"dog -cat", "dogs and cats", false},
+		{"dog -cat", "dog and bird", true},
+		{"dog -cat", "dog and cat", false},
+	}
+	for _, tc := range tests {
+		q, err := Parse(tc.query)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", tc.query, err)
+		}
+		if _, ok := q.Match(tc.text); ok != tc.match {
+			t.Errorf("%q matching %q = %v, want %v", tc.query, tc.text, ok, tc.match)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	q, _ := Parse(`"red dog" cat`)
+	got := q.Highlight("A red dog, a Cat.")
+	want := "A <mark>red dog</mark>, a <mark>Cat</mark>."
+	if got != want {
+		t.Errorf("Highlight() = %q, want %q", got, want)
+	}
+}
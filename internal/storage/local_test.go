@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Put(ctx, "media/a.png", strings.NewReader("first")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := store.Put(ctx, "media/a.png", strings.NewReader("second")); err != nil {
+		t.Fatalf("Put over existing blob: %v", err)
+	}
+	blob, err := store.Get(ctx, "media/a.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(blob)
+	blob.Close()
+	if string(data) != "second" {
+		t.Errorf("Get returned %q, want %q", data, "second")
+	}
+
+	if err := store.Delete(ctx, "media/a.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, "media/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, "media/a.png"); err != nil {
+		t.Errorf("Delete of missing blob: %v", err)
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, key := range []string{"a", "media/a.png", "x_y-z/1.2"} {
+		if err := ValidateKey(key); err != nil {
+			t.Errorf("ValidateKey(%q) = %v, want nil", key, err)
+		}
+	}
+	for _, key := range []string{"", "/a", "a/", "../a", "a/../b", "a//b", `a\b`, "a b"} {
+		if err := ValidateKey(key); err == nil {
+			t.Errorf("ValidateKey(%q) = nil, want an error", key)
+		}
+	}
+}
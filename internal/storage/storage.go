@@ -0,0 +1,48 @@
+// Package storage keeps uploaded files out of the database. Handlers talk to
+// a BlobStore, so the local filesystem store used today can be swapped for
+// an object store without touching them.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned by Get when there is no blob under the key.
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores opaque blobs under string keys. A key is one or more
+// '/'-separated segments of letters, digits, '.', '-' and '_'.
+type BlobStore interface {
+	// Put stores the contents of r under key, replacing any blob already
+	// there. Readers never see a partly written blob.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get opens the blob under key. It is seekable so it can be served
+	// with range requests.
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes the blob under key. Deleting a missing blob is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidateKey checks that key has the shape BlobStore accepts, so a key can
+// never reach outside the store.
+func ValidateKey(key string) error {
+	if key == "" {
+		return errors.New("empty blob key")
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+		for _, r := range seg {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_') {
+				return fmt.Errorf("invalid blob key %q", key)
+			}
+		}
+	}
+	return nil
+}
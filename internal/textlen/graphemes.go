@@ -0,0 +1,142 @@
+// Package textlen measures chirp bodies the way people read them: in
+// user-perceived characters (extended grapheme clusters) rather than bytes
+// or code points, with links counted at a fixed weight.
+package textlen
+
+import "unicode"
+
+// class is the grapheme break property of a rune, reduced to the values
+// the rules in Graphemes need.
+type class int
+
+const (
+	other class = iota
+	cr
+	lf
+	control
+	extend
+	zwj
+	spacingMark
+	regional
+	pictographic
+	hangulL
+	hangulV
+	hangulT
+	hangulLV
+	hangulLVT
+)
+
+func classify(r rune) class {
+	switch {
+	case r == '\r':
+		return cr
+	case r == '\n':
+		return lf
+	case r == 0x200D:
+		return zwj
+	// Emoji modifiers, variation selectors and tag characters extend the
+	// emoji before them. Tags are format characters, so check them before
+	// the control case below.
+	case r == 0x200C, r >= 0x1F3FB && r <= 0x1F3FF, r >= 0xFE00 && r <= 0xFE0F,
+		r >= 0xE0020 && r <= 0xE007F, r >= 0xE0100 && r <= 0xE01EF,
+		unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r):
+		return extend
+	case unicode.IsControl(r), unicode.Is(unicode.Cf, r), r == 0x2028, r == 0x2029:
+		return control
+	case unicode.Is(unicode.Mc, r):
+		return spacingMark
+	case r >= 0x1F1E6 && r <= 0x1F1FF:
+		return regional
+	case r >= 0x1100 && r <= 0x115F, r >= 0xA960 && r <= 0xA97C:
+		return hangulL
+	case r >= 0x1160 && r <= 0x11A7, r >= 0xD7B0 && r <= 0xD7C6:
+		return hangulV
+	case r >= 0x11A8 && r <= 0x11FF, r >= 0xD7CB && r <= 0xD7FB:
+		return hangulT
+	case r >= 0xAC00 && r <= 0xD7A3:
+		if (r-0xAC00)%28 == 0 {
+			return hangulLV
+		}
+		return hangulLVT
+	case isPictographic(r):
+		return pictographic
+	}
+	return other
+}
+
+// isPictographic approximates the Extended_Pictographic property with the
+// blocks that hold emoji.
+func isPictographic(r rune) bool {
+	switch {
+	case r == 0x00A9, r == 0x00AE, r == 0x203C, r == 0x2049, r == 0x2122, r == 0x2139,
+		r == 0x24C2, r == 0x3030, r == 0x303D, r == 0x3297, r == 0x3299:
+		return true
+	case r >= 0x2194 && r <= 0x21AA, r >= 0x231A && r <= 0x23FF, r >= 0x25AA && r <= 0x25FE,
+		r >= 0x2600 && r <= 0x27BF, r >= 0x2934 && r <= 0x2935, r >= 0x2B05 && r <= 0x2B55,
+		r >= 0x1F000 && r <= 0x1FAFF, r >= 0x1FC00 && r <= 0x1FFFD:
+		return true
+	}
+	return false
+}
+
+// breaks reports whether there is a grapheme boundary between a rune of
+// class prev and one of class next. riRun is how many regional indicators
+// end at prev, and pictZWJ whether prev is a ZWJ that follows an emoji.
+func breaks(prev, next class, riRun int, pictZWJ bool) bool {
+	switch {
+	case prev == cr && next == lf:
+		return false
+	case prev == cr, prev == lf, prev == control, next == cr, next == lf, next == control:
+		return true
+	case prev == hangulL && (next == hangulL || next == hangulV || next == hangulLV || next == hangulLVT):
+		return false
+	case (prev == hangulLV || prev == hangulV) && (next == hangulV || next == hangulT):
+		return false
+	case (prev == hangulLVT || prev == hangulT) && next == hangulT:
+		return false
+	case next == extend, next == zwj, next == spacingMark:
+		return false
+	case prev == zwj && pictZWJ && next == pictographic:
+		return false
+	case prev == regional && next == regional:
+		// Flags are pairs of regional indicators.
+		return riRun%2 == 0
+	}
+	return true
+}
+
+// Graphemes counts the user-perceived characters in s, following the
+// extended grapheme cluster rules of Unicode Standard Annex #29: a letter
+// with its accents, an emoji with its skin tone, a family joined with ZWJs
+// and a flag each count once.
+func Graphemes(s string) int {
+	n := 0
+	var prev class
+	riRun := 0
+	inPict := false
+	pictZWJ := false
+	for i, r := range s {
+		c := classify(r)
+		if i == 0 || breaks(prev, c, riRun, pictZWJ) {
+			n++
+		}
+
+		switch {
+		case c == pictographic:
+			inPict, pictZWJ = true, false
+		case c == extend && inPict:
+			pictZWJ = false
+		case c == zwj:
+			inPict, pictZWJ = false, inPict
+		default:
+			inPict, pictZWJ = false, false
+		}
+		if c == regional {
+			riRun++
+		} else {
+			riRun = 0
+		}
+		prev = c
+	}
+	return n
+}
@@ -0,0 +1,60 @@
+package textlen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// URLWeight is what a link counts for, however long it is.
+const URLWeight = 23
+
+var urlPrefixes = []string{"http://", "https://", "www."}
+
+// urlTrailers are characters that end a sentence more often than a link,
+// so they are not counted as part of one.
+const urlTrailers = ".,;:!?'\")]}"
+
+// nextURL finds the first link in s and returns its byte range, or -1, -1
+// if there is none. A link starts a word with one of urlPrefixes and runs
+// to the next space.
+func nextURL(s string) (int, int) {
+	wordStart := true
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			wordStart = true
+			continue
+		}
+		if !wordStart {
+			continue
+		}
+		wordStart = false
+		for _, prefix := range urlPrefixes {
+			if len(s)-i < len(prefix) || !strings.EqualFold(s[i:i+len(prefix)], prefix) {
+				continue
+			}
+			end := strings.IndexFunc(s[i:], unicode.IsSpace)
+			if end < 0 {
+				end = len(s) - i
+			}
+			url := strings.TrimRight(s[i:i+end], urlTrailers)
+			if len(url) > len(prefix) {
+				return i, i + len(url)
+			}
+		}
+	}
+	return -1, -1
+}
+
+// Weighted is the length of a chirp body: its grapheme count, with every
+// link counted as URLWeight instead.
+func Weighted(s string) int {
+	n := 0
+	for {
+		start, end := nextURL(s)
+		if start < 0 {
+			return n + Graphemes(s)
+		}
+		n += Graphemes(s[:start]) + URLWeight
+		s = s[end:]
+	}
+}
@@ -0,0 +1,55 @@
+package textlen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGraphemes(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "hello", 5},
+		{"precomposed accent", "café", 4},
+		{"combining accent", "cafe\u0301", 4},
+		{"emoji", "\U0001F600\U0001F600", 2},
+		{"skin tone", "\U0001F44D\U0001F3FD", 1},
+		{"zwj family", "\U0001F468\u200d\U0001F469\u200d\U0001F467", 1},
+		{"variation selector", "\u2764\ufe0f", 1},
+		{"flags", "\U0001F1FA\U0001F1F8\U0001F1EF\U0001F1F5", 2},
+		{"odd regional indicator", "\U0001F1FA\U0001F1F8\U0001F1EF", 2},
+		{"crlf", "a\r\nb", 3},
+		{"hangul jamo", "각", 1},
+		{"hangul syllables", "한글", 2},
+		{"devanagari", "क्षि", 2},
+		{"tag sequence", "\U0001F3F4\U000E0067\U000E0062\U000E0065\U000E006E\U000E0067\U000E007F", 1},
+	}
+	for _, c := range cases {
+		if got := Graphemes(c.in); got != c.want {
+			t.Errorf("%s: Graphemes(%q) = %d, want %d", c.name, c.in, got, c.want)
+		}
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	long := "https://example.com/" + strings.Repeat("a", 100)
+	cases := []struct {
+		in   string
+		want int
+	}{
+		{"no links", 8},
+		{long, URLWeight},
+		{"see " + long + ".", 4 + URLWeight + 1},
+		{"www.example.com and HTTP://x.y", URLWeight + 5 + URLWeight},
+		{"http:// alone", 13},
+		{"nothttp://x", 11},
+	}
+	for _, c := range cases {
+		if got := Weighted(c.in); got != c.want {
+			t.Errorf("Weighted(%q) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// jwksMaxAge is how long verifiers may cache the key set. A key should be
+// listed with an active_from at least this far ahead so every verifier has
+// it before the first token signed with it arrives.
+const jwksMaxAge = 5 * time.Minute
+
+// jwksHandler publishes the public keys access tokens are verified with,
+// so other services can check Chirpy tokens without holding a secret.
+func (cfg *apiConfig) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(jwksMaxAge.Seconds())))
+	respondWithJSON(w, http.StatusOK, cfg.keys.JWKS())
+}
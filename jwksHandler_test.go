@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"chirpy/internal/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestJWKS(t *testing.T) {
+	srv := newTestServer(t)
+	user := signUp(t, srv, "alice@example.com")
+
+	var set auth.JWKS
+	if code := doJSON(t, srv, "GET", "/.well-known/jwks.json", "", nil, &set); code != http.StatusOK {
+		t.Fatalf("GET jwks: got status %d", code)
+	}
+	if len(set.Keys) != 1 || set.Keys[0].KeyType != "OKP" || set.Keys[0].X == "" {
+		t.Fatalf("unexpected key set: %+v", set.Keys)
+	}
+
+	token, _, err := jwt.NewParser().ParseUnverified(user.Token, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := token.Header["kid"]; kid != set.Keys[0].KeyID {
+		t.Errorf("access token kid = %v, want %q", kid, set.Keys[0].KeyID)
+	}
+	if token.Method.Alg() != set.Keys[0].Algorithm {
+		t.Errorf("access token alg = %s, want %s", token.Method.Alg(), set.Keys[0].Algorithm)
+	}
+}
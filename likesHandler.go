@@ -0,0 +1,165 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Like struct {
+	UserID  uuid.UUID `json:"user_id"`
+	LikedAt time.Time `json:"liked_at"`
+}
+
+type likesPage struct {
+	Likes      []Like `json:"likes"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func likeCursor(l Like) pageCursor {
+	return pageCursor{CreatedAt: l.LikedAt, ID: l.UserID}
+}
+
+// likeTarget parses the chirpID path value and makes sure the chirp can be
+// liked. It writes the error response itself and reports false on failure.
+func (cfg *apiConfig) likeTarget(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return uuid.UUID{}, false
+	}
+	_, err = cfg.db.GetOneChirps(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "ID not Found", err)
+		return uuid.UUID{}, false
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching chirp by ID", err)
+		return uuid.UUID{}, false
+	}
+	return chirpID, true
+}
+
+func (cfg *apiConfig) likeChirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	chirpID, ok := cfg.likeTarget(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.db.LikeChirp(r.Context(), database.LikeChirpParams{
+		ChirpID: chirpID,
+		UserID:  userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't like chirp", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unlikeChirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+
+	_, err = cfg.db.UnlikeChirp(r.Context(), database.UnlikeChirpParams{
+		ChirpID: chirpID,
+		UserID:  userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unlike chirp", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) getChirpLikesHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, ok := cfg.likeTarget(w, r)
+	if !ok {
+		return
+	}
+	page, err := parsePageParams(r.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if !page.Desc {
+		respondWithError(w, http.StatusBadRequest, "only sort=desc is supported", nil)
+		return
+	}
+
+	rows, err := cfg.db.ListChirpLikes(r.Context(), database.ListChirpLikesParams{
+		ChirpID:         chirpID,
+		CursorCreatedAt: page.cursorTime(),
+		CursorID:        page.cursorID(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching likes", err)
+		return
+	}
+	likes := make([]Like, 0, len(rows))
+	for _, row := range rows {
+		likes = append(likes, Like{UserID: row.UserID, LikedAt: row.CreatedAt})
+	}
+
+	likes, next := trimPage(page, likes, likeCursor)
+	setLinkHeader(w, r, next)
+	respondWithJSON(w, http.StatusOK, likesPage{Likes: likes, NextCursor: next})
+}
+
+func (cfg *apiConfig) getUserLikesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+	page, err := parsePageParams(r.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if !page.Desc {
+		respondWithError(w, http.StatusBadRequest, "only sort=desc is supported", nil)
+		return
+	}
+
+	rows, err := cfg.db.ListUserLikes(r.Context(), database.ListUserLikesParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorTime(),
+		CursorID:        page.cursorID(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching liked chirps", err)
+		return
+	}
+
+	rows, next := trimPage(page, rows, func(row database.ListUserLikesRow) pageCursor {
+		return pageCursor{CreatedAt: row.LikedAt, ID: row.Chirp.ID}
+	})
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	apiChirps, err := cfg.presentChirps(r, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	setLinkHeader(w, r, next)
+	respondWithJSON(w, http.StatusOK, chirpsPage{Chirps: apiChirps, NextCursor: next})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestLikes(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var chirp Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "like me"}, &chirp)
+	likePath := "/api/chirps/" + chirp.ID.String() + "/like"
+
+	for i := 0; i < 2; i++ {
+		if code := doJSON(t, srv, "POST", likePath, bob.Token, nil, nil); code != http.StatusNoContent {
+			t.Fatalf("POST like: got status %d", code)
+		}
+	}
+
+	var got Chirp
+	doJSON(t, srv, "GET", "/api/chirps/"+chirp.ID.String(), bob.Token, nil, &got)
+	if got.LikeCount != 1 || got.LikedByMe == nil || !*got.LikedByMe {
+		t.Errorf("after liking twice: like_count=%d liked_by_me=%v", got.LikeCount, got.LikedByMe)
+	}
+	doJSON(t, srv, "GET", "/api/chirps/"+chirp.ID.String(), alice.Token, nil, &got)
+	if got.LikedByMe == nil || *got.LikedByMe {
+		t.Errorf("author sees liked_by_me=%v", got.LikedByMe)
+	}
+	var anon Chirp
+	doJSON(t, srv, "GET", "/api/chirps/"+chirp.ID.String(), "", nil, &anon)
+	if anon.LikedByMe != nil {
+		t.Error("anonymous caller got liked_by_me")
+	}
+
+	var likes likesPage
+	doJSON(t, srv, "GET", "/api/chirps/"+chirp.ID.String()+"/likes", "", nil, &likes)
+	if len(likes.Likes) != 1 || likes.Likes[0].UserID != bob.ID {
+		t.Errorf("unexpected likes: %+v", likes.Likes)
+	}
+	var liked chirpsPage
+	doJSON(t, srv, "GET", "/api/users/"+bob.ID.String()+"/likes", "", nil, &liked)
+	if len(liked.Chirps) != 1 || liked.Chirps[0].ID != chirp.ID {
+		t.Errorf("unexpected liked chirps: %+v", liked.Chirps)
+	}
+
+	doJSON(t, srv, "DELETE", likePath, bob.Token, nil, nil)
+	doJSON(t, srv, "GET", "/api/chirps/"+chirp.ID.String(), "", nil, &got)
+	if got.LikeCount != 0 {
+		t.Errorf("after unlike: like_count=%d", got.LikeCount)
+	}
+}
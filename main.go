@@ -1,8 +1,15 @@
 package main
 
 import (
+	"chirpy/internal/auth"
 	"chirpy/internal/database"
+	"chirpy/internal/memstore"
+	"chirpy/internal/moderation"
+	"chirpy/internal/storage"
+	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"os"
@@ -16,67 +23,297 @@ import (
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
-	db             *database.Queries
+	db             database.Store
 	platform       string
-	secret         string
 	polkaKey       string
+	adminKey       string
+	// editWindow is how long after posting a chirp its author may edit it.
+	editWindow time.Duration
+	// restoreWindow is how long a deleted chirp or user can be restored.
+	restoreWindow time.Duration
+	// retention is how long deleted rows are kept before the purger
+	// removes them for good.
+	retention time.Duration
+	// moderationRules come from the rules file. Rules added through the
+	// admin API are layered on top of them in filter.
+	moderationRules []moderation.Rule
+	filter          atomic.Pointer[moderation.Filter]
+	// blobs holds uploaded media and their thumbnails.
+	blobs storage.BlobStore
+	// keys sign and verify access tokens.
+	keys *auth.KeySet
+	// refreshTokenKey is the key refresh tokens are hashed with before they
+	// are stored or looked up.
+	refreshTokenKey []byte
+	// denylist holds the revoked access tokens keys checks tokens against.
+	denylist *tokenDenylist
 }
 
+const (
+	defaultEditWindow    = 15 * time.Minute
+	defaultRestoreWindow = 7 * 24 * time.Hour
+	defaultRetention     = 30 * 24 * time.Hour
+	defaultMediaDir      = "media"
+	purgeInterval        = time.Hour
+	schedulerInterval    = 15 * time.Second
+	// denylistSyncInterval bounds how long an access token revoked
+	// through another server keeps working here.
+	denylistSyncInterval = 15 * time.Second
+	// moderationSyncInterval bounds how long a rule changed through
+	// another server takes to apply here.
+	moderationSyncInterval = 15 * time.Second
+)
+
 type User struct {
 	ID          uuid.UUID `json:"id"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	Email       string    `json:"email"`
 	IsChirpyRed bool      `json:"is_chirpy_red"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name,omitempty"`
+	Bio         string    `json:"bio,omitempty"`
 }
 
 func main() {
 	const filepathRoot = "."
 	const port = "8080"
-	dbURL := os.Getenv("DB_URL")
+	migrateOnStart := flag.Bool("migrate", false, "apply pending database migrations before serving")
+	inMemory := flag.Bool("memstore", false, "serve from the in-memory store when DB_URL is unset; everything is lost on restart")
+	flag.Parse()
 	godotenv.Load()
-	db, err := sql.Open("postgres", dbURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to database. Err: %s", err)
+	dbURL := os.Getenv("DB_URL")
+
+	if args := flag.Args(); len(args) > 0 && args[0] == "migrate" {
+		if dbURL == "" {
+			log.Fatal("DB_URL must be set to run migrations")
+		}
+		db, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			log.Fatalf("Failed to connect to database. Err: %s", err)
+		}
+		defer db.Close()
+		if err := runMigrateCommand(context.Background(), db, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
-	defer db.Close()
 
-	apiCfg := apiConfig{
-		fileserverHits: atomic.Int32{},
-		db:             database.New(db),
-		platform:       os.Getenv("PLATFORM"),
-		secret:         os.Getenv("SECRET"),
-		polkaKey:       os.Getenv("POLKA_KEY"),
+	var store database.Store
+	if dbURL == "" {
+		// Falling back silently would let a misconfigured deploy take
+		// writes and lose them on restart.
+		if !*inMemory && os.Getenv("PLATFORM") != "dev" {
+			log.Fatal("DB_URL must be set; use -memstore or PLATFORM=dev to run without a database")
+		}
+		log.Println("DB_URL is not set, using the in-memory store")
+		store = memstore.New()
+	} else {
+		db, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			log.Fatalf("Failed to connect to database. Err: %s", err)
+		}
+		defer db.Close()
+		if err := prepareSchema(context.Background(), db, *migrateOnStart); err != nil {
+			log.Fatal(err)
+		}
+		store = database.NewStore(db)
 	}
 
-	mux := http.NewServeMux()
-	apiCfg.db = database.New(db)
-	fsHandler := apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot))))
-	mux.Handle("/app/", fsHandler)
+	restoreWindow := durationEnv("RESTORE_GRACE_PERIOD", defaultRestoreWindow)
+	retention := durationEnv("DELETED_RETENTION", defaultRetention)
+	if retention < restoreWindow {
+		log.Fatal("DELETED_RETENTION must not be shorter than RESTORE_GRACE_PERIOD")
+	}
 
-	mux.HandleFunc("GET /api/healthz", handlerReadiness)
-	mux.HandleFunc("POST /api/chirps", apiCfg.postChirpsHandler)
-	mux.HandleFunc("POST /api/users", apiCfg.usersHandler)
-	mux.HandleFunc("GET /admin/metrics", apiCfg.fileserverHitsHandler)
-	mux.HandleFunc("POST /admin/reset", apiCfg.fileserverResetHandler)
-	mux.HandleFunc("GET /api/chirps", apiCfg.getAllChirpsHandler)
-	mux.HandleFunc("POST /api/login", apiCfg.usersLoginHandler)
-	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
-	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateUser)
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.deleteChirpHandler)
-	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.getOneChirpHandler)
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerMakeRed)
+	moderationRules := moderation.DefaultRules
+	if path := os.Getenv("MODERATION_RULES_FILE"); path != "" {
+		rules, err := moderation.LoadRules(path)
+		if err != nil {
+			log.Fatalf("Failed to load moderation rules. Err: %s", err)
+		}
+		moderationRules = rules
+	}
+
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = defaultMediaDir
+	}
+	blobs, err := storage.NewLocal(mediaDir)
+	if err != nil {
+		log.Fatalf("Failed to open media directory. Err: %s", err)
+	}
+
+	keys, err := loadKeySet()
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys. Err: %s", err)
+	}
+	refreshTokenKey, err := loadRefreshTokenKey()
+	if err != nil {
+		log.Fatalf("Failed to load the refresh token key. Err: %s", err)
+	}
+
+	apiCfg := apiConfig{
+		fileserverHits:  atomic.Int32{},
+		db:              store,
+		platform:        os.Getenv("PLATFORM"),
+		polkaKey:        os.Getenv("POLKA_KEY"),
+		adminKey:        os.Getenv("ADMIN_KEY"),
+		editWindow:      durationEnv("CHIRP_EDIT_WINDOW", defaultEditWindow),
+		restoreWindow:   restoreWindow,
+		retention:       retention,
+		moderationRules: moderationRules,
+		blobs:           blobs,
+		keys:            keys,
+		refreshTokenKey: refreshTokenKey,
+		denylist:        newTokenDenylist(store),
+	}
+	keys.SetDenylist(apiCfg.denylist)
+	if err := apiCfg.denylist.reload(context.Background()); err != nil {
+		log.Printf("Failed to load revoked access tokens. Err: %s", err)
+	}
+	if n, err := apiCfg.hashLegacyRefreshTokens(context.Background()); err != nil {
+		log.Printf("Failed to hash stored refresh tokens. Err: %s", err)
+	} else if n > 0 {
+		log.Printf("Hashed %d stored refresh tokens", n)
+	}
+	if err := apiCfg.reloadModeration(context.Background()); err != nil {
+		log.Fatalf("Failed to load moderation rules. Err: %s", err)
+	}
+	go apiCfg.runPurger(context.Background(), purgeInterval)
+	go apiCfg.runScheduler(context.Background(), schedulerInterval)
+	go apiCfg.runDenylistSync(context.Background(), denylistSyncInterval)
+	go apiCfg.runModerationSync(context.Background(), moderationSyncInterval)
 
 	srv := &http.Server{
 		Addr:    ":" + port,
-		Handler: mux,
+		Handler: apiCfg.routes(filepathRoot),
 	}
 
 	log.Printf("Serving files from %s on port: %s\n", filepathRoot, port)
 	log.Fatal(srv.ListenAndServe())
 }
 
+// loadKeySet reads the JWT keys from JWT_KEYS_FILE. Without one it falls
+// back to signing with SECRET using HS256, which other services can only
+// verify by sharing the secret.
+func loadKeySet() (*auth.KeySet, error) {
+	var keys *auth.KeySet
+	var err error
+	if path := os.Getenv("JWT_KEYS_FILE"); path != "" {
+		keys, err = auth.LoadKeySet(path)
+	} else if secret := os.Getenv("SECRET"); secret != "" {
+		log.Println("JWT_KEYS_FILE is not set, signing tokens with SECRET")
+		keys, err = auth.NewKeySet(auth.NewHMACKey("", []byte(secret)))
+	} else {
+		return nil, errors.New("JWT_KEYS_FILE or SECRET must be set")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return keys, keys.Check()
+}
+
+// loadRefreshTokenKey reads the key refresh tokens are hashed with from
+// REFRESH_TOKEN_KEY, falling back to SECRET. Changing it logs everyone out.
+func loadRefreshTokenKey() ([]byte, error) {
+	if key := os.Getenv("REFRESH_TOKEN_KEY"); key != "" {
+		return []byte(key), nil
+	}
+	if secret := os.Getenv("SECRET"); secret != "" {
+		log.Println("REFRESH_TOKEN_KEY is not set, hashing refresh tokens with SECRET")
+		return []byte(secret), nil
+	}
+	return nil, errors.New("REFRESH_TOKEN_KEY or SECRET must be set")
+}
+
+// durationEnv reads a non-negative duration such as 15m or 168h from the
+// environment, falling back to def when the variable is unset.
+func durationEnv(name string, def time.Duration) time.Duration {
+	s := os.Getenv(name)
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		log.Fatalf("%s must be a duration such as 15m or 168h, got %q", name, s)
+	}
+	return d
+}
+
+func (cfg *apiConfig) routes(filepathRoot string) *http.ServeMux {
+	mux := http.NewServeMux()
+	fsHandler := cfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot))))
+	mux.Handle("/app/", fsHandler)
+
+	mux.HandleFunc("GET /api/healthz", handlerReadiness)
+	mux.HandleFunc("GET /.well-known/jwks.json", cfg.jwksHandler)
+	mux.HandleFunc("POST /api/chirps", cfg.postChirpsHandler)
+	mux.HandleFunc("POST /api/media", cfg.uploadMediaHandler)
+	mux.HandleFunc("POST /api/drafts", cfg.createDraftHandler)
+	mux.HandleFunc("GET /api/drafts", cfg.getDraftsHandler)
+	mux.HandleFunc("GET /api/drafts/{draftID}", cfg.getDraftHandler)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", cfg.updateDraftHandler)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", cfg.deleteDraftHandler)
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", cfg.publishDraftHandler)
+	mux.HandleFunc("GET /media/{mediaID}", cfg.serveMediaHandler)
+	mux.HandleFunc("GET /media/{mediaID}/thumbnail", cfg.serveThumbnailHandler)
+	mux.HandleFunc("POST /api/users", cfg.usersHandler)
+	mux.HandleFunc("GET /admin/metrics", cfg.fileserverHitsHandler)
+	mux.HandleFunc("POST /admin/reset", cfg.fileserverResetHandler)
+	mux.HandleFunc("POST /admin/users/{userID}/restore", cfg.restoreUserHandler)
+	mux.HandleFunc("POST /admin/users/{userID}/suspend", cfg.suspendUserHandler)
+	mux.HandleFunc("POST /admin/users/{userID}/unsuspend", cfg.unsuspendUserHandler)
+	mux.HandleFunc("GET /admin/moderation/rules", cfg.getModerationRulesHandler)
+	mux.HandleFunc("PUT /admin/moderation/rules/{word}", cfg.putModerationRuleHandler)
+	mux.HandleFunc("DELETE /admin/moderation/rules/{word}", cfg.deleteModerationRuleHandler)
+	mux.HandleFunc("GET /admin/moderation/flags", cfg.getChirpFlagsHandler)
+	mux.HandleFunc("DELETE /admin/moderation/flags/{chirpID}", cfg.dismissChirpFlagHandler)
+	mux.HandleFunc("GET /api/chirps", cfg.getAllChirpsHandler)
+	mux.HandleFunc("GET /api/chirps/search", cfg.searchChirpsHandler)
+	mux.HandleFunc("POST /api/login", cfg.usersLoginHandler)
+	mux.HandleFunc("POST /api/refresh", cfg.handlerRefresh)
+	mux.HandleFunc("POST /api/revoke", cfg.handlerRevoke)
+	mux.HandleFunc("GET /api/sessions", cfg.getSessionsHandler)
+	mux.HandleFunc("DELETE /api/sessions/others", cfg.deleteOtherSessionsHandler)
+	mux.HandleFunc("DELETE /api/sessions/{sessionID}", cfg.deleteSessionHandler)
+	mux.HandleFunc("PUT /api/users", cfg.handlerUpdateUser)
+	mux.HandleFunc("DELETE /api/users", cfg.deleteUserHandler)
+	mux.HandleFunc("GET /api/users/{handle}", cfg.getProfileHandler)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.deleteChirpHandler)
+	mux.HandleFunc("GET /api/chirps/{chirpID}", cfg.getOneChirpHandler)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", cfg.editChirpHandler)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/history", cfg.getChirpHistoryHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/restore", cfg.restoreChirpHandler)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/replies", cfg.getRepliesHandler)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", cfg.getThreadHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/like", cfg.likeChirpHandler)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", cfg.unlikeChirpHandler)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/likes", cfg.getChirpLikesHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", cfg.bookmarkChirpHandler)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", cfg.unbookmarkChirpHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", cfg.pinChirpHandler)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", cfg.unpinChirpHandler)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/poll/votes", cfg.votePollHandler)
+	mux.HandleFunc("POST /api/polka/webhooks", cfg.handlerMakeRed)
+	mux.HandleFunc("POST /api/users/{userID}/follow", cfg.followHandler)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", cfg.unfollowHandler)
+	mux.HandleFunc("GET /api/users/{userID}/followers", cfg.getFollowersHandler)
+	mux.HandleFunc("GET /api/users/{userID}/following", cfg.getFollowingHandler)
+	mux.HandleFunc("GET /api/users/{userID}/likes", cfg.getUserLikesHandler)
+	mux.HandleFunc("GET /api/feed", cfg.getFeedHandler)
+	mux.HandleFunc("GET /api/mentions", cfg.getMentionsHandler)
+	mux.HandleFunc("GET /api/bookmarks", cfg.getBookmarksHandler)
+	mux.HandleFunc("POST /api/collections", cfg.createCollectionHandler)
+	mux.HandleFunc("GET /api/collections", cfg.getCollectionsHandler)
+	mux.HandleFunc("PUT /api/collections/{collectionID}", cfg.renameCollectionHandler)
+	mux.HandleFunc("DELETE /api/collections/{collectionID}", cfg.deleteCollectionHandler)
+	mux.HandleFunc("GET /api/collections/{collectionID}/bookmarks", cfg.getCollectionBookmarksHandler)
+	mux.HandleFunc("GET /api/tags/trending", cfg.getTrendingTagsHandler)
+	mux.HandleFunc("GET /api/tags/{tag}/chirps", cfg.getTagChirpsHandler)
+	return mux
+}
+
 func handlerReadiness(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"chirpy/internal/auth"
+	"chirpy/internal/memstore"
+	"chirpy/internal/moderation"
+	"chirpy/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return newTestServerWithConfig(t, func(*apiConfig) {})
+}
+
+// newTestServerWithConfig is newTestServer with a hook to adjust the config
+// before the server starts.
+func newTestServerWithConfig(t *testing.T, configure func(*apiConfig)) *httptest.Server {
+	t.Helper()
+	blobs, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := auth.NewKey("test", private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := auth.NewKeySet(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := memstore.New()
+	cfg := &apiConfig{
+		db:              store,
+		platform:        "dev",
+		polkaKey:        "test-polka-key",
+		adminKey:        "test-admin-key",
+		editWindow:      defaultEditWindow,
+		restoreWindow:   defaultRestoreWindow,
+		retention:       defaultRetention,
+		moderationRules: moderation.DefaultRules,
+		blobs:           blobs,
+		keys:            keys,
+		refreshTokenKey: []byte("test-refresh-token-key"),
+		denylist:        newTokenDenylist(store),
+	}
+	keys.SetDenylist(cfg.denylist)
+	configure(cfg)
+	srv := httptest.NewServer(cfg.routes("."))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func doJSON(t *testing.T, srv *httptest.Server, method, path, token string, body, out interface{}) int {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encoding body: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, srv.URL+path, &buf)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decoding %s %s response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// doAdmin is doJSON for admin endpoints, which take the admin key instead of
+// a JWT.
+func doAdmin(t *testing.T, srv *httptest.Server, method, path string, body, out interface{}) int {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encoding body: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, srv.URL+path, &buf)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+	req.Header.Set("Authorization", "ApiKey test-admin-key")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decoding %s %s response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+type loginResponse struct {
+	User
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// signUp creates a user and logs them in.
+func signUp(t *testing.T, srv *httptest.Server, email string) loginResponse {
+	t.Helper()
+	return signUpWithHandle(t, srv, email, "")
+}
+
+func signUpWithHandle(t *testing.T, srv *httptest.Server, email, handle string) loginResponse {
+	t.Helper()
+	creds := map[string]string{"email": email, "password": "hunter2"}
+	signup := map[string]string{"email": email, "password": "hunter2", "handle": handle}
+	if code := doJSON(t, srv, "POST", "/api/users", "", signup, nil); code != http.StatusCreated {
+		t.Fatalf("POST /api/users: got status %d", code)
+	}
+	var login loginResponse
+	if code := doJSON(t, srv, "POST", "/api/login", "", creds, &login); code != http.StatusOK {
+		t.Fatalf("POST /api/login: got status %d", code)
+	}
+	return login
+}
+
+func TestPostChirp_CleansBody(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+
+	var chirp Chirp
+	code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "what a kerfuffle"}, &chirp)
+	if code != http.StatusCreated {
+		t.Fatalf("POST /api/chirps: got status %d", code)
+	}
+	if chirp.Body != "what a ****" || chirp.UserId != alice.ID {
+		t.Errorf("unexpected chirp: %+v", chirp)
+	}
+
+	var page chirpsPage
+	doJSON(t, srv, "GET", "/api/chirps?author_id="+alice.ID.String(), "", nil, &page)
+	if len(page.Chirps) != 1 || page.Chirps[0].ID != chirp.ID {
+		t.Errorf("GET /api/chirps returned %+v", page)
+	}
+}
+
+func TestDeleteChirp_OnlyAuthor(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var chirp Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "hello"}, &chirp)
+
+	if code := doJSON(t, srv, "DELETE", "/api/chirps/"+chirp.ID.String(), bob.Token, nil, nil); code != http.StatusForbidden {
+		t.Errorf("DELETE by non-author: got status %d, want %d", code, http.StatusForbidden)
+	}
+	if code := doJSON(t, srv, "DELETE", "/api/chirps/"+chirp.ID.String(), alice.Token, nil, nil); code != http.StatusNoContent {
+		t.Errorf("DELETE by author: got status %d, want %d", code, http.StatusNoContent)
+	}
+}
+
+// upgradeToRed sends the Polka webhook that makes a user a Chirpy Red
+// member.
+func upgradeToRed(t *testing.T, srv *httptest.Server, userID uuid.UUID) {
+	t.Helper()
+	req, _ := http.NewRequest("POST", srv.URL+"/api/polka/webhooks",
+		bytes.NewBufferString(`{"event":"user.upgraded","data":{"user_id":"`+userID.String()+`"}}`))
+	req.Header.Set("Authorization", "ApiKey test-polka-key")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("POST /api/polka/webhooks: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("POST /api/polka/webhooks: got status %d", resp.StatusCode)
+	}
+}
+
+func TestMakeRed(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+
+	upgradeToRed(t, srv, alice.ID)
+
+	var login loginResponse
+	doJSON(t, srv, "POST", "/api/login", "", map[string]string{"email": "alice@example.com", "password": "hunter2"}, &login)
+	if !login.IsChirpyRed {
+		t.Error("user was not upgraded to Chirpy Red")
+	}
+}
+
+func TestGetChirps_Pagination(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	for i := 0; i < 5; i++ {
+		doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": fmt.Sprintf("chirp %d", i)}, nil)
+	}
+
+	var seen []string
+	path := "/api/chirps?limit=2&sort=desc"
+	for pages := 0; path != ""; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not terminate")
+		}
+		var page chirpsPage
+		if code := doJSON(t, srv, "GET", path, "", nil, &page); code != http.StatusOK {
+			t.Fatalf("GET %s: got status %d", path, code)
+		}
+		for _, c := range page.Chirps {
+			seen = append(seen, c.Body)
+		}
+		path = ""
+		if page.NextCursor != "" {
+			path = "/api/chirps?limit=2&sort=desc&cursor=" + page.NextCursor
+		}
+	}
+
+	want := []string{"chirp 4", "chirp 3", "chirp 2", "chirp 1", "chirp 0"}
+	if !slices.Equal(seen, want) {
+		t.Errorf("got %v, want %v", seen, want)
+	}
+}
+
+func TestGetChirps_InvalidCursor(t *testing.T) {
+	srv := newTestServer(t)
+	if code := doJSON(t, srv, "GET", "/api/chirps?cursor=nope", "", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d", code, http.StatusBadRequest)
+	}
+}
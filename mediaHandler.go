@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"chirpy/internal/database"
+	"chirpy/internal/media"
+	"chirpy/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxMediaBytes bounds the size of one uploaded file.
+	maxMediaBytes = 5 << 20
+	// maxMediaPerChirp is how many uploads a chirp can carry.
+	maxMediaPerChirp = 4
+	// thumbnailSize is the largest side of a thumbnail, in pixels.
+	thumbnailSize = 320
+	// orphanedMediaTTL is how long an upload may sit unattached before the
+	// purger removes it.
+	orphanedMediaTTL = 24 * time.Hour
+	// mediaMaxAge is how long clients and caches may reuse media without
+	// revalidating. The bytes behind an ID never change, but media stop
+	// being served once their chirp is deleted, so this is kept short of
+	// "immutable".
+	mediaMaxAge = 24 * time.Hour
+)
+
+// errMediaUnavailable aborts posting a chirp whose media IDs don't name
+// unattached uploads of the author's.
+var errMediaUnavailable = errors.New("media not found or already attached")
+
+type Media struct {
+	ID           uuid.UUID `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Width        int32     `json:"width"`
+	Height       int32     `json:"height"`
+	SizeBytes    int64     `json:"size_bytes"`
+}
+
+func mediaFromModel(m database.MediaItem) Media {
+	return Media{
+		ID:           m.ID,
+		URL:          "/media/" + m.ID.String(),
+		ThumbnailURL: "/media/" + m.ID.String() + "/thumbnail",
+		ContentType:  m.ContentType,
+		Width:        m.Width,
+		Height:       m.Height,
+		SizeBytes:    m.SizeBytes,
+	}
+}
+
+func mediaKey(id uuid.UUID) string {
+	return "media/" + id.String()
+}
+
+func thumbnailKey(id uuid.UUID) string {
+	return "media/" + id.String() + ".thumbnail"
+}
+
+// uploadMediaHandler accepts one image in the "file" field of a multipart
+// form. The upload is checked, stored with a thumbnail and returned unattached;
+// it is tied to a chirp by listing its ID in media_ids when posting.
+func (cfg *apiConfig) uploadMediaHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart framing around the file.
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaBytes+64<<10)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Expected a multipart/form-data upload", err)
+		return
+	}
+	var data []byte
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			respondWithUploadError(w, err)
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		data, err = io.ReadAll(io.LimitReader(part, maxMediaBytes+1))
+		if err != nil {
+			respondWithUploadError(w, err)
+			return
+		}
+		break
+	}
+	if data == nil {
+		respondWithError(w, http.StatusBadRequest, "Missing file field", nil)
+		return
+	}
+	if len(data) > maxMediaBytes {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Media can be at most %d bytes", maxMediaBytes), nil)
+		return
+	}
+
+	img, err := media.Decode(data)
+	if errors.Is(err, media.ErrUnsupported) {
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only JPEG, PNG and GIF images can be uploaded", err)
+		return
+	}
+	if errors.Is(err, media.ErrTooManyPixels) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, err.Error(), err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't read image", err)
+		return
+	}
+	thumbnail, err := img.Thumbnail(thumbnailSize)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't make thumbnail", err)
+		return
+	}
+
+	id := uuid.New()
+	if err := cfg.blobs.Put(r.Context(), mediaKey(id), bytes.NewReader(data)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store media", err)
+		return
+	}
+	if err := cfg.blobs.Put(r.Context(), thumbnailKey(id), bytes.NewReader(thumbnail)); err != nil {
+		cfg.deleteMediaBlobs(r.Context(), id)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store media", err)
+		return
+	}
+	item, err := cfg.db.CreateMediaItem(r.Context(), database.CreateMediaItemParams{
+		ID:          id,
+		UserID:      userID,
+		ContentType: img.ContentType,
+		SizeBytes:   int64(len(data)),
+		Width:       int32(img.Width),
+		Height:      int32(img.Height),
+	})
+	if err != nil {
+		cfg.deleteMediaBlobs(r.Context(), id)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save media", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, mediaFromModel(item))
+}
+
+// respondWithUploadError reports a failure to read the request body,
+// telling an oversized body apart from a malformed one.
+func respondWithUploadError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Media can be at most %d bytes", maxMediaBytes), err)
+		return
+	}
+	respondWithError(w, http.StatusBadRequest, "Couldn't read upload", err)
+}
+
+func (cfg *apiConfig) deleteMediaBlobs(ctx context.Context, id uuid.UUID) error {
+	if err := cfg.blobs.Delete(ctx, mediaKey(id)); err != nil {
+		return err
+	}
+	return cfg.blobs.Delete(ctx, thumbnailKey(id))
+}
+
+func (cfg *apiConfig) serveMediaHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, mediaKey, func(m database.MediaItem) string { return m.ContentType })
+}
+
+func (cfg *apiConfig) serveThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, thumbnailKey, func(database.MediaItem) string { return media.ThumbnailContentType })
+}
+
+// serveMedia writes the blob under key(id) with caching headers. The
+// content type comes from what the upload was sniffed as, never from the
+// client, and nosniff stops browsers from second-guessing it.
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request, key func(uuid.UUID) string, contentType func(database.MediaItem) string) {
+	id, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Media not found", err)
+		return
+	}
+	item, err := cfg.db.GetMediaItem(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Media not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't fetch media", err)
+		return
+	}
+	blob, err := cfg.blobs.Get(r.Context(), key(id))
+	if errors.Is(err, storage.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Media not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't open media", err)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", contentType(item))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(mediaMaxAge.Seconds())))
+	w.Header().Set("ETag", `"`+key(id)+`"`)
+	http.ServeContent(w, r, "", item.CreatedAt, blob)
+}
+
+// attachMedia ties the uploads in mediaIDs to a new chirp, in order.
+func attachMedia(ctx context.Context, q database.Querier, chirp database.Chirp, mediaIDs []uuid.UUID) error {
+	for i, id := range mediaIDs {
+		n, err := q.AttachMediaItem(ctx, database.AttachMediaItemParams{
+			ChirpID:  chirp.ID,
+			Position: int16(i),
+			ID:       id,
+			UserID:   chirp.UserID,
+		})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errMediaUnavailable
+		}
+	}
+	return nil
+}
+
+// validateMediaIDs checks the media_ids of a new chirp before anything is
+// written.
+func validateMediaIDs(ids []uuid.UUID) error {
+	if len(ids) > maxMediaPerChirp {
+		return fmt.Errorf("A chirp can have at most %d media", maxMediaPerChirp)
+	}
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return errors.New("media_ids contains duplicates")
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// setMedia fills in the attachments of chirps, skipping deleted ones.
+func (cfg *apiConfig) setMedia(ctx context.Context, chirps []*Chirp) error {
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, c := range chirps {
+		if !c.Deleted {
+			ids = append(ids, c.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	rows, err := cfg.db.ListMediaItemsForChirps(ctx, ids)
+	if err != nil {
+		return err
+	}
+	attached := map[uuid.UUID][]Media{}
+	for _, row := range rows {
+		attached[row.ChirpID.UUID] = append(attached[row.ChirpID.UUID], mediaFromModel(row))
+	}
+	for _, c := range chirps {
+		if m, ok := attached[c.ID]; ok {
+			c.Media = m
+		}
+	}
+	return nil
+}
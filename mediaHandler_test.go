@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// uploadMedia posts data as the file field of a multipart form.
+func uploadMedia(t *testing.T, srv *httptest.Server, token string, data []byte) (Media, int) {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "upload")
+	fw.Write(data)
+	mw.Close()
+
+	req, _ := http.NewRequest("POST", srv.URL+"/api/media", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("POST /api/media: %v", err)
+	}
+	defer resp.Body.Close()
+	var m Media
+	if resp.StatusCode == http.StatusCreated {
+		if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+			t.Fatalf("decoding media: %v", err)
+		}
+	}
+	return m, resp.StatusCode
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMedia(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	if _, code := uploadMedia(t, srv, alice.Token, []byte("<html>not an image</html>")); code != http.StatusUnsupportedMediaType {
+		t.Errorf("upload of HTML: got status %d, want %d", code, http.StatusUnsupportedMediaType)
+	}
+	if _, code := uploadMedia(t, srv, alice.Token, make([]byte, maxMediaBytes+1)); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized upload: got status %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+
+	original := testPNG(t, 800, 400)
+	uploaded, code := uploadMedia(t, srv, alice.Token, original)
+	if code != http.StatusCreated {
+		t.Fatalf("upload: got status %d", code)
+	}
+	if uploaded.ContentType != "image/png" || uploaded.Width != 800 || uploaded.Height != 400 {
+		t.Errorf("unexpected media: %+v", uploaded)
+	}
+
+	// Only the uploader can attach it, and only to one chirp.
+	if code := doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]interface{}{"body": "mine", "media_ids": []uuid.UUID{uploaded.ID}}, nil); code != http.StatusBadRequest {
+		t.Errorf("attaching someone else's media: got status %d, want %d", code, http.StatusBadRequest)
+	}
+	five := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{"body": "five", "media_ids": five}, nil); code != http.StatusBadRequest {
+		t.Errorf("five media: got status %d, want %d", code, http.StatusBadRequest)
+	}
+	var chirp Chirp
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{"body": "look", "media_ids": []uuid.UUID{uploaded.ID}}, &chirp); code != http.StatusCreated {
+		t.Fatalf("POST chirp with media: got status %d", code)
+	}
+	if len(chirp.Media) != 1 || chirp.Media[0].ID != uploaded.ID {
+		t.Errorf("chirp media = %+v", chirp.Media)
+	}
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{"body": "again", "media_ids": []uuid.UUID{uploaded.ID}}, nil); code != http.StatusBadRequest {
+		t.Errorf("reusing attached media: got status %d, want %d", code, http.StatusBadRequest)
+	}
+
+	resp, err := http.Get(srv.URL + uploaded.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(got, original) {
+		t.Fatalf("GET %s: status %d, %d bytes", uploaded.URL, resp.StatusCode, len(got))
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if resp.Header.Get("Cache-Control") == "" || resp.Header.Get("ETag") == "" || resp.Header.Get("Last-Modified") == "" {
+		t.Errorf("missing caching headers: %v", resp.Header)
+	}
+
+	req, _ := http.NewRequest("GET", srv.URL+uploaded.URL, nil)
+	req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+	revalidated, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revalidated.Body.Close()
+	if revalidated.StatusCode != http.StatusNotModified {
+		t.Errorf("conditional GET: got status %d, want %d", revalidated.StatusCode, http.StatusNotModified)
+	}
+
+	thumb, err := http.Get(srv.URL + uploaded.ThumbnailURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, _, err := image.Decode(thumb.Body)
+	thumb.Body.Close()
+	if err != nil || thumb.Header.Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("thumbnail: %v, Content-Type %q", err, thumb.Header.Get("Content-Type"))
+	}
+	if b := img.Bounds(); b.Dx() != thumbnailSize || b.Dy() != thumbnailSize/2 {
+		t.Errorf("thumbnail is %dx%d", b.Dx(), b.Dy())
+	}
+
+	doJSON(t, srv, "DELETE", "/api/chirps/"+chirp.ID.String(), alice.Token, nil, nil)
+	gone, err := http.Get(srv.URL + uploaded.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gone.Body.Close()
+	if gone.StatusCode != http.StatusNotFound {
+		t.Errorf("media of a deleted chirp: got status %d, want %d", gone.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestPurgeOrphanedMedia(t *testing.T) {
+	var cfg *apiConfig
+	srv := newTestServerWithConfig(t, func(c *apiConfig) { cfg = c })
+	alice := signUp(t, srv, "alice@example.com")
+
+	unused, _ := uploadMedia(t, srv, alice.Token, testPNG(t, 10, 10))
+	used, _ := uploadMedia(t, srv, alice.Token, testPNG(t, 10, 10))
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{"body": "pic", "media_ids": []uuid.UUID{used.ID}}, nil)
+
+	ctx := context.Background()
+	if n, err := cfg.purgeOrphanedMedia(ctx, time.Now()); err != nil || n != 0 {
+		t.Fatalf("purge before the TTL removed %d media, err %v", n, err)
+	}
+	n, err := cfg.purgeOrphanedMedia(ctx, time.Now().Add(orphanedMediaTTL+time.Minute))
+	if err != nil || n != 1 {
+		t.Fatalf("purge removed %d media, err %v; want only the unused upload", n, err)
+	}
+	if _, err := cfg.blobs.Get(ctx, mediaKey(unused.ID)); err == nil {
+		t.Error("blob of purged media is still stored")
+	}
+	if _, err := cfg.db.GetMediaItem(ctx, used.ID); err != nil {
+		t.Errorf("attached media was purged: %v", err)
+	}
+}
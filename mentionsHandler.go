@@ -0,0 +1,160 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+type Entities struct {
+	Mentions []Mention `json:"mentions,omitempty"`
+}
+
+// Mention is an @handle in a chirp body that resolved to a user. Start and
+// End are offsets in Unicode code points, End exclusive, and cover the @.
+type Mention struct {
+	UserID uuid.UUID `json:"user_id"`
+	Handle string    `json:"handle"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+type mentionMatch struct {
+	handle     string
+	start, end int32
+}
+
+// extractMentions finds every @handle in body. An @ only starts a mention
+// at the beginning of the body or after a character that can't be part of
+// a handle, so email addresses are left alone. Handles are lowercased.
+func extractMentions(body string) []mentionMatch {
+	var matches []mentionMatch
+	var prev rune
+	pos := int32(0)
+	for i, r := range body {
+		if r == '@' && !isHandleRune(prev) && prev != '@' {
+			rest := body[i+1:]
+			end := strings.IndexFunc(rest, func(r rune) bool { return !isHandleRune(r) })
+			if end < 0 {
+				end = len(rest)
+			}
+			if handle := rest[:end]; validHandle(handle) {
+				matches = append(matches, mentionMatch{
+					handle: strings.ToLower(handle),
+					start:  pos,
+					end:    pos + 1 + int32(utf8.RuneCountInString(handle)),
+				})
+			}
+		}
+		prev = r
+		pos++
+	}
+	return matches
+}
+
+// resolveMentions looks up the users mentioned in body. Handles that don't
+// belong to anyone are dropped and stay plain text.
+func resolveMentions(ctx context.Context, q database.Querier, body string) (database.CreateChirpMentionsParams, error) {
+	var params database.CreateChirpMentionsParams
+	matches := extractMentions(body)
+	if len(matches) == 0 {
+		return params, nil
+	}
+	handles := make([]string, 0, len(matches))
+	for _, m := range matches {
+		handles = append(handles, m.handle)
+	}
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return params, err
+	}
+	byHandle := make(map[string]uuid.UUID, len(users))
+	for _, u := range users {
+		byHandle[strings.ToLower(u.Handle.String)] = u.ID
+	}
+	for _, m := range matches {
+		userID, ok := byHandle[m.handle]
+		if !ok {
+			continue
+		}
+		params.UserIds = append(params.UserIds, userID)
+		params.StartOffsets = append(params.StartOffsets, m.start)
+		params.EndOffsets = append(params.EndOffsets, m.end)
+	}
+	return params, nil
+}
+
+// setMentions fills in the mention entities of chirps that have any.
+func (cfg *apiConfig) setMentions(ctx context.Context, chirps []*Chirp) error {
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, c := range chirps {
+		if !c.Deleted {
+			ids = append(ids, c.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	rows, err := cfg.db.ListMentionsForChirps(ctx, ids)
+	if err != nil {
+		return err
+	}
+	mentions := map[uuid.UUID][]Mention{}
+	for _, row := range rows {
+		mentions[row.ChirpID] = append(mentions[row.ChirpID], Mention{
+			UserID: row.UserID,
+			Handle: row.Handle.String,
+			Start:  row.StartOffset,
+			End:    row.EndOffset,
+		})
+	}
+	for _, c := range chirps {
+		if m, ok := mentions[c.ID]; ok {
+			c.Entities = &Entities{Mentions: m}
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) getMentionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	page, err := parsePageParams(r.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if !page.Desc {
+		respondWithError(w, http.StatusBadRequest, "only sort=desc is supported", nil)
+		return
+	}
+
+	chirps, err := cfg.db.ListMentioningChirps(r.Context(), database.ListMentioningChirpsParams{
+		UserID:          userID,
+		CursorCreatedAt: page.cursorTime(),
+		CursorID:        page.cursorID(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching mentions", err)
+		return
+	}
+
+	chirps, next := trimPage(page, chirps, chirpCursor)
+	apiChirps, err := cfg.presentChirps(r, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	setLinkHeader(w, r, next)
+	respondWithJSON(w, http.StatusOK, chirpsPage{
+		Chirps:     apiChirps,
+		NextCursor: next,
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		body string
+		want []mentionMatch
+	}{
+		{"hi @Alice!", []mentionMatch{{handle: "alice", start: 3, end: 9}}},
+		{"mail bob@example.com", nil},
+		{"@a and @b", []mentionMatch{{handle: "a", start: 0, end: 2}, {handle: "b", start: 7, end: 9}}},
+		{"café @bob", []mentionMatch{{handle: "bob", start: 5, end: 9}}},
+		{"@@double and @waytoolongforahandle", nil},
+	}
+	for _, tt := range tests {
+		if got := extractMentions(tt.body); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("extractMentions(%q) = %+v, want %+v", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUpWithHandle(t, srv, "alice@example.com", "Alice")
+	bob := signUpWithHandle(t, srv, "bob@example.com", "bob")
+
+	if code := doJSON(t, srv, "POST", "/api/users", "", map[string]string{"email": "eve@example.com", "password": "x", "handle": "ALICE"}, nil); code != http.StatusConflict {
+		t.Errorf("duplicate handle: got status %d, want %d", code, http.StatusConflict)
+	}
+	if code := doJSON(t, srv, "POST", "/api/users", "", map[string]string{"email": "eve@example.com", "password": "x", "handle": "not ok"}, nil); code != http.StatusBadRequest {
+		t.Errorf("invalid handle: got status %d, want %d", code, http.StatusBadRequest)
+	}
+
+	var chirp Chirp
+	doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]string{"body": "hey @alice, meet @nobody"}, &chirp)
+	if chirp.Entities == nil || len(chirp.Entities.Mentions) != 1 {
+		t.Fatalf("expected one resolved mention, got %+v", chirp.Entities)
+	}
+	m := chirp.Entities.Mentions[0]
+	if m.UserID != alice.ID || m.Handle != "Alice" || m.Start != 4 || m.End != 10 {
+		t.Errorf("unexpected mention: %+v", m)
+	}
+	doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]string{"body": "no mentions here"}, nil)
+
+	var inbox chirpsPage
+	if code := doJSON(t, srv, "GET", "/api/mentions", alice.Token, nil, &inbox); code != http.StatusOK {
+		t.Fatalf("GET /api/mentions: got status %d", code)
+	}
+	if len(inbox.Chirps) != 1 || inbox.Chirps[0].ID != chirp.ID {
+		t.Errorf("unexpected mentions inbox: %+v", inbox.Chirps)
+	}
+	if code := doJSON(t, srv, "GET", "/api/mentions", "", nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("anonymous inbox: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"chirpy/internal/migrate"
+	"chirpy/sql/schema"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// prepareSchema refuses to continue if the database is ahead of the binary
+// and, when apply is set, brings the schema up to date.
+func prepareSchema(ctx context.Context, db *sql.DB, apply bool) error {
+	m, err := migrate.New(db, schema.FS)
+	if err != nil {
+		return err
+	}
+	if err := m.Check(ctx); err != nil {
+		return err
+	}
+	if !apply {
+		pending, err := m.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			log.Printf("%d pending migrations, start with -migrate or run `chirpy migrate up`", len(pending))
+		}
+		return nil
+	}
+	applied, err := m.Up(ctx)
+	for _, mig := range applied {
+		log.Printf("Applied migration %s", mig.Name)
+	}
+	return err
+}
+
+func runMigrateCommand(ctx context.Context, db *sql.DB, args []string) error {
+	m, err := migrate.New(db, schema.FS)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: chirpy migrate up|down|status|redo")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			log.Printf("Applied migration %s", mig.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Println("No pending migrations")
+		}
+	case "down":
+		mig, err := m.Down(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Rolled back migration %s", mig.Name)
+	case "redo":
+		mig, err := m.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Reapplied migration %s", mig.Name)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "Applied At\tMigration")
+		for _, st := range statuses {
+			appliedAt := "Pending"
+			if st.Applied {
+				appliedAt = st.AppliedAt.Format(time.ANSIC)
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", appliedAt, st.Name)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q, want up|down|status|redo", args[0])
+	}
+	return nil
+}
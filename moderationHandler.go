@@ -0,0 +1,275 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/moderation"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// reloadModeration rebuilds the filter from the config rules with the rules
+// stored in the database layered on top. It runs at startup, after every
+// change made through the admin API and periodically, to pick up changes
+// made through other servers.
+func (cfg *apiConfig) reloadModeration(ctx context.Context) error {
+	rows, err := cfg.db.ListModerationRules(ctx)
+	if err != nil {
+		return err
+	}
+	stored := make([]moderation.Rule, 0, len(rows))
+	for _, row := range rows {
+		stored = append(stored, moderation.Rule{Word: row.Word, Action: moderation.Action(row.Action)})
+	}
+	cfg.filter.Store(moderation.NewFilter(cfg.moderationRules, stored))
+	return nil
+}
+
+// runModerationSync reloads the moderation rules every interval until ctx
+// is cancelled.
+func (cfg *apiConfig) runModerationSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := cfg.reloadModeration(ctx); err != nil {
+			log.Printf("Reloading moderation rules failed: %s", err)
+		}
+	}
+}
+
+// moderationFilter returns the current filter, falling back to the config
+// rules alone if the stored rules have not been loaded.
+func (cfg *apiConfig) moderationFilter() *moderation.Filter {
+	if f := cfg.filter.Load(); f != nil {
+		return f
+	}
+	return moderation.NewFilter(cfg.moderationRules)
+}
+
+// moderateBody runs a chirp body through the filter. A body that trips a
+// reject rule gets a 400 listing the rules that fired, and ok is false.
+func (cfg *apiConfig) moderateBody(w http.ResponseWriter, body string) (moderation.Result, bool) {
+	result := cfg.moderationFilter().Check(body)
+	if result.Rejected() {
+		type rejection struct {
+			Error      string           `json:"error"`
+			Moderation []moderation.Hit `json:"moderation"`
+		}
+		respondWithJSON(w, http.StatusBadRequest, rejection{
+			Error:      "Chirp contains words that aren't allowed",
+			Moderation: result.Hits,
+		})
+		return result, false
+	}
+	return result, true
+}
+
+// flagForReview queues a chirp for moderators if any of its text, the body
+// or a poll option, tripped a flag rule.
+func flagForReview(ctx context.Context, q database.Querier, chirpID uuid.UUID, results ...moderation.Result) error {
+	var words []string
+	for _, result := range results {
+		for _, word := range result.Flagged() {
+			if !slices.Contains(words, word) {
+				words = append(words, word)
+			}
+		}
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	return q.FlagChirp(ctx, database.FlagChirpParams{
+		ChirpID: chirpID,
+		Words:   words,
+	})
+}
+
+type ModerationRule struct {
+	Word   string            `json:"word"`
+	Action moderation.Action `json:"action"`
+	// Source is "config" for rules from the rules file and "admin" for
+	// rules added through the API, which take precedence.
+	Source    string     `json:"source"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+type moderationRulesResponse struct {
+	Rules []ModerationRule `json:"rules"`
+}
+
+func moderationRuleFromModel(m database.ModerationRule) ModerationRule {
+	return ModerationRule{
+		Word:      m.Word,
+		Action:    moderation.Action(m.Action),
+		Source:    "admin",
+		UpdatedAt: &m.UpdatedAt,
+	}
+}
+
+func (cfg *apiConfig) getModerationRulesHandler(w http.ResponseWriter, r *http.Request) {
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin key required", nil)
+		return
+	}
+	rows, err := cfg.db.ListModerationRules(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching moderation rules", err)
+		return
+	}
+	rules := make([]ModerationRule, 0, len(cfg.moderationRules)+len(rows))
+	for _, rule := range cfg.moderationRules {
+		rules = append(rules, ModerationRule{Word: rule.Word, Action: rule.Action, Source: "config"})
+	}
+	for _, row := range rows {
+		rules = append(rules, moderationRuleFromModel(row))
+	}
+	respondWithJSON(w, http.StatusOK, moderationRulesResponse{Rules: rules})
+}
+
+func (cfg *apiConfig) putModerationRuleHandler(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Action moderation.Action `json:"action"`
+	}
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin key required", nil)
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	rule := moderation.Rule{Word: strings.ToLower(r.PathValue("word")), Action: params.Action}
+	if err := rule.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	row, err := cfg.db.UpsertModerationRule(r.Context(), database.UpsertModerationRuleParams{
+		Word:   rule.Word,
+		Action: string(rule.Action),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save moderation rule", err)
+		return
+	}
+	if err := cfg.reloadModeration(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload moderation rules", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, moderationRuleFromModel(row))
+}
+
+func (cfg *apiConfig) deleteModerationRuleHandler(w http.ResponseWriter, r *http.Request) {
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin key required", nil)
+		return
+	}
+	n, err := cfg.db.DeleteModerationRule(r.Context(), strings.ToLower(r.PathValue("word")))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete moderation rule", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "No admin rule for that word", nil)
+		return
+	}
+	if err := cfg.reloadModeration(r.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload moderation rules", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type FlaggedChirp struct {
+	Chirp     Chirp     `json:"chirp"`
+	Words     []string  `json:"words"`
+	FlaggedAt time.Time `json:"flagged_at"`
+}
+
+type flagsPage struct {
+	Flags      []FlaggedChirp `json:"flags"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// getChirpFlagsHandler lists the chirps waiting for review, most recently
+// flagged first.
+func (cfg *apiConfig) getChirpFlagsHandler(w http.ResponseWriter, r *http.Request) {
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin key required", nil)
+		return
+	}
+	page, err := parsePageParams(r.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if !page.Desc {
+		respondWithError(w, http.StatusBadRequest, "only sort=desc is supported", nil)
+		return
+	}
+
+	rows, err := cfg.db.ListChirpFlags(r.Context(), database.ListChirpFlagsParams{
+		CursorCreatedAt: page.cursorTime(),
+		CursorID:        page.cursorID(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching flagged chirps", err)
+		return
+	}
+	rows, next := trimPage(page, rows, func(row database.ListChirpFlagsRow) pageCursor {
+		return pageCursor{CreatedAt: row.FlaggedAt, ID: row.Chirp.ID}
+	})
+
+	models := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		models = append(models, row.Chirp)
+	}
+	apiChirps, err := cfg.presentChirps(r, models)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	flags := make([]FlaggedChirp, 0, len(rows))
+	for i, row := range rows {
+		flags = append(flags, FlaggedChirp{Chirp: apiChirps[i], Words: row.Words, FlaggedAt: row.FlaggedAt})
+	}
+	setLinkHeader(w, r, next)
+	respondWithJSON(w, http.StatusOK, flagsPage{Flags: flags, NextCursor: next})
+}
+
+// dismissChirpFlagHandler takes a chirp off the review queue.
+func (cfg *apiConfig) dismissChirpFlagHandler(w http.ResponseWriter, r *http.Request) {
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin key required", nil)
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+	n, err := cfg.db.DeleteChirpFlag(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't dismiss flag", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Chirp is not flagged", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
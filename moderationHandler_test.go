@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"chirpy/internal/database"
+	"chirpy/internal/moderation"
+)
+
+func TestModeration(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+
+	var chirp Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "What a K3rfuffle!"}, &chirp)
+	if chirp.Body != "What a ****!" {
+		t.Errorf("got body %q", chirp.Body)
+	}
+	if len(chirp.Moderation) != 1 || chirp.Moderation[0].Word != "kerfuffle" {
+		t.Errorf("unexpected moderation report: %+v", chirp.Moderation)
+	}
+
+	if code := doJSON(t, srv, "PUT", "/admin/moderation/rules/spam", alice.Token, map[string]string{"action": "reject"}, nil); code != http.StatusUnauthorized {
+		t.Errorf("PUT rule without admin key: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+	for word, action := range map[string]string{"spam": "reject", "crypto": "flag", "fornax": "allow"} {
+		if code := doAdmin(t, srv, "PUT", "/admin/moderation/rules/"+word, map[string]string{"action": action}, nil); code != http.StatusOK {
+			t.Fatalf("PUT rule %s: got status %d", word, code)
+		}
+	}
+	if code := doAdmin(t, srv, "PUT", "/admin/moderation/rules/two%20words", map[string]string{"action": "mask"}, nil); code != http.StatusBadRequest {
+		t.Errorf("PUT rule with two words: got status %d, want %d", code, http.StatusBadRequest)
+	}
+
+	var rejected struct {
+		Error      string           `json:"error"`
+		Moderation []moderation.Hit `json:"moderation"`
+	}
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "buy sp4m"}, &rejected); code != http.StatusBadRequest {
+		t.Fatalf("POST rejected chirp: got status %d", code)
+	}
+	if len(rejected.Moderation) != 1 || rejected.Moderation[0].Action != moderation.ActionReject {
+		t.Errorf("unexpected rejection: %+v", rejected)
+	}
+
+	var flagged Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "crypto fornax"}, &flagged)
+	if flagged.Body != "crypto fornax" {
+		t.Errorf("allowed and flagged words were changed: %q", flagged.Body)
+	}
+	var flags flagsPage
+	if code := doAdmin(t, srv, "GET", "/admin/moderation/flags", nil, &flags); code != http.StatusOK {
+		t.Fatalf("GET flags: got status %d", code)
+	}
+	if len(flags.Flags) != 1 || flags.Flags[0].Chirp.ID != flagged.ID || flags.Flags[0].Words[0] != "crypto" {
+		t.Fatalf("unexpected flags: %+v", flags.Flags)
+	}
+	if code := doAdmin(t, srv, "DELETE", "/admin/moderation/flags/"+flagged.ID.String(), nil, nil); code != http.StatusNoContent {
+		t.Errorf("dismiss flag: got status %d", code)
+	}
+
+	if code := doAdmin(t, srv, "DELETE", "/admin/moderation/rules/spam", nil, nil); code != http.StatusNoContent {
+		t.Errorf("DELETE rule: got status %d", code)
+	}
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "buy spam"}, nil); code != http.StatusCreated {
+		t.Errorf("POST after removing rule: got status %d", code)
+	}
+}
+
+func TestModeration_SyncsRulesFromOtherServers(t *testing.T) {
+	var cfg *apiConfig
+	srv := newTestServerWithConfig(t, func(c *apiConfig) { cfg = c })
+	alice := signUp(t, srv, "alice@example.com")
+
+	// Another server sharing the database adds a rule.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cfg.runModerationSync(ctx, 10*time.Millisecond)
+	_, err := cfg.db.UpsertModerationRule(ctx, database.UpsertModerationRuleParams{Word: "spam", Action: string(moderation.ActionReject)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !cfg.moderationFilter().Check("spam").Rejected() {
+		if time.Now().After(deadline) {
+			t.Fatal("rule added through another server was never loaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "buy spam"}, nil); code != http.StatusBadRequest {
+		t.Errorf("POST chirp after the sync: got status %d, want %d", code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+)
+
+// pageCursor is the keyset position a page continues from. Clients only
+// ever see it as an opaque string.
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type pageParams struct {
+	Limit  int
+	Desc   bool
+	Cursor *pageCursor
+}
+
+func encodeCursor(c pageCursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return pageCursor{}, errors.New("invalid cursor")
+	}
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return pageCursor{}, errors.New("invalid cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return pageCursor{}, errors.New("invalid cursor")
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return pageCursor{}, errors.New("invalid cursor")
+	}
+	return pageCursor{CreatedAt: createdAt, ID: parsedID}, nil
+}
+
+// parsePageParams reads limit, cursor and sort from the query string.
+// defaultSort is used when sort is omitted.
+func parsePageParams(q url.Values, defaultSort string) (pageParams, error) {
+	p := pageParams{Limit: defaultPageLimit}
+
+	if s := q.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			return pageParams{}, errors.New("limit must be a positive integer")
+		}
+		p.Limit = min(limit, maxPageLimit)
+	}
+
+	sortOrder := q.Get("sort")
+	if sortOrder == "" {
+		sortOrder = defaultSort
+	}
+	switch sortOrder {
+	case "asc":
+	case "desc":
+		p.Desc = true
+	default:
+		return pageParams{}, errors.New("sort must be asc or desc")
+	}
+
+	if s := q.Get("cursor"); s != "" {
+		c, err := decodeCursor(s)
+		if err != nil {
+			return pageParams{}, err
+		}
+		p.Cursor = &c
+	}
+	return p, nil
+}
+
+// queryLimit is one more than the page size so we can tell whether another
+// page exists without a second query.
+func (p pageParams) queryLimit() int32 {
+	return int32(p.Limit + 1)
+}
+
+func (p pageParams) cursorTime() sql.NullTime {
+	if p.Cursor == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true}
+}
+
+func (p pageParams) cursorID() uuid.NullUUID {
+	if p.Cursor == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+// trimPage drops the look-ahead row fetched by queryLimit and returns the
+// cursor for the next page, or "" on the last page.
+func trimPage[T any](p pageParams, rows []T, key func(T) pageCursor) ([]T, string) {
+	if len(rows) <= p.Limit {
+		return rows, ""
+	}
+	rows = rows[:p.Limit]
+	return rows, encodeCursor(key(rows[len(rows)-1]))
+}
+
+// setLinkHeader advertises the next page using the request's own query
+// string with the cursor replaced.
+func setLinkHeader(w http.ResponseWriter, r *http.Request, nextCursor string) {
+	if nextCursor == "" {
+		return
+	}
+	q := r.URL.Query()
+	q.Set("cursor", nextCursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+	w.Header().Add("Link", "<"+next.String()+`>; rel="next"`)
+}
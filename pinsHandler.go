@@ -0,0 +1,93 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxPinnedChirps    = 1
+	maxRedPinnedChirps = 3
+)
+
+// errTooManyPins rolls back a pin that would take its author over their
+// limit.
+var errTooManyPins = errors.New("too many pinned chirps")
+
+func pinLimit(user database.User) int {
+	if user.IsChirpyRed {
+		return maxRedPinnedChirps
+	}
+	return maxPinnedChirps
+}
+
+// pinChirpHandler pins one of the caller's own chirps to their profile.
+// Pinning a chirp that is already pinned does nothing; pinning past the
+// caller's limit is refused rather than unpinning an older chirp.
+func (cfg *apiConfig) pinChirpHandler(w http.ResponseWriter, r *http.Request) {
+	chirp, ok := cfg.ownChirp(w, r)
+	if !ok {
+		return
+	}
+
+	limit := maxPinnedChirps
+	err := cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		// Locking the author keeps concurrent pins from both slipping under
+		// the limit.
+		user, err := q.LockUser(r.Context(), chirp.UserID)
+		if err != nil {
+			return err
+		}
+		limit = pinLimit(user)
+		n, err := q.PinChirp(r.Context(), database.PinChirpParams{
+			UserID:  chirp.UserID,
+			ChirpID: chirp.ID,
+		})
+		if err != nil || n == 0 {
+			return err
+		}
+		count, err := q.CountPinnedChirps(r.Context(), chirp.UserID)
+		if err != nil {
+			return err
+		}
+		if count > int64(limit) {
+			return errTooManyPins
+		}
+		return nil
+	})
+	if errors.Is(err, errTooManyPins) {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("You can pin at most %d chirps; unpin one first", limit), err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't pin chirp", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) unpinChirpHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+
+	_, err = cfg.db.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unpin chirp", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPins(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUpWithHandle(t, srv, "alice@example.com", "alice")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var first, second Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "pin me"}, &first)
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "newer"}, &second)
+	pin := func(c Chirp, token string) int {
+		return doJSON(t, srv, "POST", "/api/chirps/"+c.ID.String()+"/pin", token, nil, nil)
+	}
+
+	if code := pin(first, bob.Token); code != http.StatusForbidden {
+		t.Errorf("pinning someone else's chirp: got status %d", code)
+	}
+	for i := 0; i < 2; i++ {
+		if code := pin(first, alice.Token); code != http.StatusNoContent {
+			t.Fatalf("pin: got status %d", code)
+		}
+	}
+	if code := pin(second, alice.Token); code != http.StatusConflict {
+		t.Errorf("pinning past the limit: got status %d", code)
+	}
+
+	var profile Profile
+	doJSON(t, srv, "GET", "/api/users/alice", "", nil, &profile)
+	if len(profile.Pinned) != 1 || profile.Pinned[0].ID != first.ID {
+		t.Fatalf("profile pinned: %+v", profile.Pinned)
+	}
+
+	var page chirpsPage
+	doJSON(t, srv, "GET", "/api/chirps?sort=desc&pinned_first=true&author_id="+alice.ID.String(), "", nil, &page)
+	if len(page.Chirps) != 3 || page.Chirps[0].ID != first.ID || !page.Chirps[0].Pinned {
+		t.Fatalf("pinned_first: %+v", page.Chirps)
+	}
+	if page.Chirps[1].ID != second.ID || page.Chirps[1].Pinned || page.Chirps[2].ID != first.ID {
+		t.Errorf("listing after the pinned chirp: %+v", page.Chirps[1:])
+	}
+	doJSON(t, srv, "GET", "/api/chirps?author_id="+alice.ID.String(), "", nil, &page)
+	if len(page.Chirps) != 2 {
+		t.Errorf("pinned chirps without pinned_first: %+v", page.Chirps)
+	}
+	if code := doJSON(t, srv, "GET", "/api/chirps?pinned_first=true", "", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("pinned_first without author_id: got status %d", code)
+	}
+
+	// Chirpy Red members can pin three.
+	upgradeToRed(t, srv, alice.ID)
+	if code := pin(second, alice.Token); code != http.StatusNoContent {
+		t.Errorf("red pin: got status %d", code)
+	}
+	doJSON(t, srv, "GET", "/api/users/alice", "", nil, &profile)
+	if len(profile.Pinned) != 2 || profile.Pinned[0].ID != second.ID {
+		t.Errorf("profile pinned after second pin: %+v", profile.Pinned)
+	}
+
+	doJSON(t, srv, "DELETE", "/api/chirps/"+second.ID.String()+"/pin", alice.Token, nil, nil)
+	doJSON(t, srv, "GET", "/api/users/alice", "", nil, &profile)
+	if len(profile.Pinned) != 1 || profile.Pinned[0].ID != first.ID {
+		t.Errorf("profile pinned after unpin: %+v", profile.Pinned)
+	}
+
+	// Deleting a chirp unpins it, and restoring it doesn't pin it again.
+	doJSON(t, srv, "DELETE", "/api/chirps/"+first.ID.String(), alice.Token, nil, nil)
+	doJSON(t, srv, "POST", "/api/chirps/"+first.ID.String()+"/restore", alice.Token, nil, nil)
+	doJSON(t, srv, "GET", "/api/users/alice", "", nil, &profile)
+	if len(profile.Pinned) != 0 {
+		t.Errorf("deleted chirp still pinned: %+v", profile.Pinned)
+	}
+}
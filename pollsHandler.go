@@ -0,0 +1,274 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/moderation"
+	"chirpy/internal/textlen"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions = 2
+	maxPollOptions = 4
+	// maxPollOptionLength is in user-perceived characters.
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+type Poll struct {
+	Options        []PollOption `json:"options"`
+	MultipleChoice bool         `json:"multiple_choice"`
+	HideResults    bool         `json:"hide_results"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	Expired        bool         `json:"expired"`
+	// VoterCount and the options' votes are left out while the results are
+	// hidden from the caller.
+	VoterCount *int64 `json:"voter_count,omitempty"`
+	// MyChoices holds the options the caller voted for, if they voted.
+	MyChoices []int32 `json:"my_choices,omitempty"`
+}
+
+type PollOption struct {
+	Text  string `json:"text"`
+	Votes *int64 `json:"votes,omitempty"`
+}
+
+// pollParams is the poll part of a new chirp.
+type pollParams struct {
+	Options        []string  `json:"options"`
+	MultipleChoice bool      `json:"multiple_choice"`
+	HideResults    bool      `json:"hide_results"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// preparePoll checks the poll of a new chirp and runs its options through
+// moderation, returning their results so flagged options reach the review
+// queue with the chirp. On failure it writes the error response itself and
+// ok is false. The returned params lack the chirp ID.
+func (cfg *apiConfig) preparePoll(w http.ResponseWriter, p pollParams) (database.CreatePollParams, []moderation.Result, bool) {
+	if len(p.Options) < minPollOptions || len(p.Options) > maxPollOptions {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("A poll needs %d to %d options", minPollOptions, maxPollOptions), nil)
+		return database.CreatePollParams{}, nil, false
+	}
+	options := make([]string, 0, len(p.Options))
+	results := make([]moderation.Result, 0, len(p.Options))
+	seen := map[string]bool{}
+	for _, option := range p.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || textlen.Graphemes(option) > maxPollOptionLength {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Poll options must be 1 to %d characters", maxPollOptionLength), nil)
+			return database.CreatePollParams{}, nil, false
+		}
+		if seen[strings.ToLower(option)] {
+			respondWithError(w, http.StatusBadRequest, "Poll options must be different", nil)
+			return database.CreatePollParams{}, nil, false
+		}
+		seen[strings.ToLower(option)] = true
+		moderated, ok := cfg.moderateBody(w, option)
+		if !ok {
+			return database.CreatePollParams{}, nil, false
+		}
+		options = append(options, moderated.Text)
+		results = append(results, moderated)
+	}
+
+	duration := time.Until(p.ExpiresAt)
+	if duration < minPollDuration || duration > maxPollDuration {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("A poll must expire between %s and %s from now", minPollDuration, maxPollDuration), nil)
+		return database.CreatePollParams{}, nil, false
+	}
+
+	return database.CreatePollParams{
+		Options:        options,
+		MultipleChoice: p.MultipleChoice,
+		HideResults:    p.HideResults,
+		ExpiresAt:      p.ExpiresAt.UTC(),
+	}, results, true
+}
+
+// validateChoices checks a ballot against the poll it is cast in.
+func validateChoices(poll database.Poll, choices []int32) error {
+	if len(choices) == 0 {
+		return errors.New("Pick at least one option")
+	}
+	if !poll.MultipleChoice && len(choices) > 1 {
+		return errors.New("This poll allows only one choice")
+	}
+	seen := map[int32]bool{}
+	for _, c := range choices {
+		if c < 0 || int(c) >= len(poll.Options) {
+			return fmt.Errorf("Choice %d is not an option of this poll", c)
+		}
+		if seen[c] {
+			return errors.New("Choices must be different")
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// votePollHandler casts the caller's ballot in a chirp's poll. Each user
+// votes once, and the ballot can't be changed. It responds with the chirp
+// and its updated tallies.
+func (cfg *apiConfig) votePollHandler(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Choices []int32 `json:"choices"`
+	}
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetOneChirps(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "ID not Found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching chirp by ID", err)
+		return
+	}
+	poll, err := cfg.db.GetPoll(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Chirp has no poll", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't fetch poll", err)
+		return
+	}
+	if !poll.ExpiresAt.After(time.Now()) {
+		respondWithError(w, http.StatusForbidden, "The poll has closed", nil)
+		return
+	}
+	if err := validateChoices(poll, params.Choices); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	n, err := cfg.db.CastPollVote(r.Context(), database.CastPollVoteParams{
+		UserID:  userID,
+		Choices: params.Choices,
+		ChirpID: chirpID,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You already voted in this poll", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't cast vote", err)
+		return
+	}
+	// The poll can expire between the check above and the insert.
+	if n == 0 {
+		respondWithError(w, http.StatusForbidden, "The poll has closed", nil)
+		return
+	}
+
+	apiChirp, err := cfg.presentChirp(r, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, apiChirp)
+}
+
+// setPolls fills in the polls of chirps, skipping deleted ones. The tallies
+// are left out of a poll that hides its results until the viewer has voted
+// or the poll has expired.
+func (cfg *apiConfig) setPolls(ctx context.Context, viewer uuid.NullUUID, chirps []*Chirp) error {
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, c := range chirps {
+		if !c.Deleted {
+			ids = append(ids, c.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	rows, err := cfg.db.ListPollsForChirps(ctx, ids)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+
+	pollIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		pollIDs = append(pollIDs, row.Poll.ChirpID)
+	}
+	tallies, err := cfg.db.ListPollTallies(ctx, pollIDs)
+	if err != nil {
+		return err
+	}
+	votes := map[uuid.UUID]map[int32]int64{}
+	for _, t := range tallies {
+		if votes[t.ChirpID] == nil {
+			votes[t.ChirpID] = map[int32]int64{}
+		}
+		votes[t.ChirpID][t.Choice] = t.Votes
+	}
+	ballots := map[uuid.UUID][]int32{}
+	if viewer.Valid {
+		mine, err := cfg.db.ListPollVotesByUser(ctx, database.ListPollVotesByUserParams{
+			UserID:   viewer.UUID,
+			ChirpIds: pollIDs,
+		})
+		if err != nil {
+			return err
+		}
+		for _, b := range mine {
+			ballots[b.ChirpID] = b.Choices
+		}
+	}
+
+	now := time.Now()
+	polls := make(map[uuid.UUID]*Poll, len(rows))
+	for _, row := range rows {
+		p := row.Poll
+		poll := &Poll{
+			MultipleChoice: p.MultipleChoice,
+			HideResults:    p.HideResults,
+			ExpiresAt:      p.ExpiresAt,
+			Expired:        !p.ExpiresAt.After(now),
+			MyChoices:      ballots[p.ChirpID],
+		}
+		showResults := !p.HideResults || poll.Expired || poll.MyChoices != nil
+		if showResults {
+			poll.VoterCount = &row.VoterCount
+		}
+		for i, text := range p.Options {
+			option := PollOption{Text: text}
+			if showResults {
+				n := votes[p.ChirpID][int32(i)]
+				option.Votes = &n
+			}
+			poll.Options = append(poll.Options, option)
+		}
+		polls[p.ChirpID] = poll
+	}
+	for _, c := range chirps {
+		if poll, ok := polls[c.ID]; ok {
+			c.Poll = poll
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"chirpy/internal/database"
+)
+
+func TestPolls(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+	carol := signUp(t, srv, "carol@example.com")
+
+	expires := time.Now().Add(time.Hour)
+	bad := []map[string]interface{}{
+		{"options": []string{"only one"}, "expires_at": expires},
+		{"options": []string{"a", "b", "c", "d", "e"}, "expires_at": expires},
+		{"options": []string{"same", "Same"}, "expires_at": expires},
+		{"options": []string{"a", "b"}, "expires_at": time.Now().Add(time.Minute)},
+		{"options": []string{"a", "b"}, "expires_at": time.Now().Add(maxPollDuration + time.Hour)},
+	}
+	for _, poll := range bad {
+		if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{"body": "vote", "poll": poll}, nil); code != http.StatusBadRequest {
+			t.Errorf("poll %v: got status %d, want %d", poll, code, http.StatusBadRequest)
+		}
+	}
+
+	var chirp Chirp
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{
+		"body": "tabs or spaces?",
+		"poll": map[string]interface{}{"options": []string{"tabs", "spaces", "kerfuffle"}, "hide_results": true, "expires_at": expires},
+	}, &chirp); code != http.StatusCreated {
+		t.Fatalf("POST chirp with poll: got status %d", code)
+	}
+	if chirp.Poll == nil || len(chirp.Poll.Options) != 3 || chirp.Poll.Options[2].Text != "****" {
+		t.Fatalf("unexpected poll: %+v", chirp.Poll)
+	}
+	if chirp.Poll.VoterCount != nil || chirp.Poll.Options[0].Votes != nil {
+		t.Error("hidden results were shown to a non-voter")
+	}
+
+	votes := "/api/chirps/" + chirp.ID.String() + "/poll/votes"
+	if code := doJSON(t, srv, "POST", votes, bob.Token, map[string]interface{}{"choices": []int{0, 1}}, nil); code != http.StatusBadRequest {
+		t.Errorf("two choices in a single-choice poll: got status %d, want %d", code, http.StatusBadRequest)
+	}
+	if code := doJSON(t, srv, "POST", votes, bob.Token, map[string]interface{}{"choices": []int{3}}, nil); code != http.StatusBadRequest {
+		t.Errorf("out of range choice: got status %d, want %d", code, http.StatusBadRequest)
+	}
+	var voted Chirp
+	if code := doJSON(t, srv, "POST", votes, bob.Token, map[string]interface{}{"choices": []int{1}}, &voted); code != http.StatusOK {
+		t.Fatalf("vote: got status %d", code)
+	}
+	if p := voted.Poll; p.VoterCount == nil || *p.VoterCount != 1 || *p.Options[1].Votes != 1 || len(p.MyChoices) != 1 {
+		t.Errorf("voter doesn't see the results: %+v", p)
+	}
+	if code := doJSON(t, srv, "POST", votes, bob.Token, map[string]interface{}{"choices": []int{0}}, nil); code != http.StatusConflict {
+		t.Errorf("second vote: got status %d, want %d", code, http.StatusConflict)
+	}
+
+	var seen Chirp
+	doJSON(t, srv, "GET", "/api/chirps/"+chirp.ID.String(), carol.Token, nil, &seen)
+	if seen.Poll == nil || seen.Poll.VoterCount != nil {
+		t.Errorf("hidden results were shown to a non-voter: %+v", seen.Poll)
+	}
+}
+
+func TestPolls_FlaggedOption(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	if code := doAdmin(t, srv, "PUT", "/admin/moderation/rules/crypto", map[string]string{"action": "flag"}, nil); code != http.StatusOK {
+		t.Fatalf("PUT rule: got status %d", code)
+	}
+
+	var chirp Chirp
+	if code := doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{
+		"body": "where should I put my savings?",
+		"poll": map[string]interface{}{"options": []string{"bank", "crypto"}, "expires_at": time.Now().Add(time.Hour)},
+	}, &chirp); code != http.StatusCreated {
+		t.Fatalf("POST chirp with poll: got status %d", code)
+	}
+	var flags flagsPage
+	doAdmin(t, srv, "GET", "/admin/moderation/flags", nil, &flags)
+	if len(flags.Flags) != 1 || flags.Flags[0].Chirp.ID != chirp.ID || len(flags.Flags[0].Words) != 1 || flags.Flags[0].Words[0] != "crypto" {
+		t.Errorf("flagged poll option not queued for review: %+v", flags.Flags)
+	}
+}
+
+func TestPolls_Expired(t *testing.T) {
+	var cfg *apiConfig
+	srv := newTestServerWithConfig(t, func(c *apiConfig) { cfg = c })
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var chirp Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "closed poll"}, &chirp)
+	// The API won't create a poll that has already expired, so go around it.
+	if _, err := cfg.db.CreatePoll(context.Background(), database.CreatePollParams{
+		ChirpID:     chirp.ID,
+		Options:     []string{"yes", "no"},
+		HideResults: true,
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := doJSON(t, srv, "POST", "/api/chirps/"+chirp.ID.String()+"/poll/votes", bob.Token, map[string]interface{}{"choices": []int{0}}, nil); code != http.StatusForbidden {
+		t.Errorf("vote in expired poll: got status %d, want %d", code, http.StatusForbidden)
+	}
+	var got Chirp
+	doJSON(t, srv, "GET", "/api/chirps/"+chirp.ID.String(), "", nil, &got)
+	if got.Poll == nil || !got.Poll.Expired || got.Poll.VoterCount == nil {
+		t.Errorf("expired poll should show its results to everyone: %+v", got.Poll)
+	}
+}
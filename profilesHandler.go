@@ -0,0 +1,155 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxHandleLength      = 15
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+)
+
+// reservedHandles can't be registered because they would be confusing in
+// mentions or collide with names the site uses itself.
+var reservedHandles = map[string]struct{}{
+	"admin":     {},
+	"api":       {},
+	"chirpy":    {},
+	"help":      {},
+	"me":        {},
+	"mentions":  {},
+	"moderator": {},
+	"null":      {},
+	"root":      {},
+	"settings":  {},
+	"support":   {},
+	"system":    {},
+}
+
+func isHandleRune(r rune) bool {
+	return r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
+
+// validHandle reports whether handle is syntactically a handle. It doesn't
+// check reservedHandles, so existing mentions keep resolving.
+func validHandle(handle string) bool {
+	if handle == "" || len(handle) > maxHandleLength {
+		return false
+	}
+	for _, r := range handle {
+		if !isHandleRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateHandle(handle string) error {
+	if !validHandle(handle) {
+		return errors.New("Handles are 1-15 letters, digits or underscores")
+	}
+	if _, ok := reservedHandles[strings.ToLower(handle)]; ok {
+		return errors.New("That handle is reserved")
+	}
+	return nil
+}
+
+func validateDisplayName(name string) error {
+	if utf8.RuneCountInString(name) > maxDisplayNameLength {
+		return errors.New("Display name is too long")
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return errors.New("Display name can't contain control characters")
+		}
+	}
+	return nil
+}
+
+func validateBio(bio string) error {
+	if utf8.RuneCountInString(bio) > maxBioLength {
+		return errors.New("Bio is too long")
+	}
+	return nil
+}
+
+// validateProfile checks the profile fields a user can set. An empty handle
+// means no handle.
+func validateProfile(handle, displayName, bio string) error {
+	if handle != "" {
+		if err := validateHandle(handle); err != nil {
+			return err
+		}
+	}
+	if err := validateDisplayName(displayName); err != nil {
+		return err
+	}
+	return validateBio(bio)
+}
+
+func nullHandle(handle string) sql.NullString {
+	return sql.NullString{String: handle, Valid: handle != ""}
+}
+
+// Profile is the public view of a user. It deliberately has no email.
+type Profile struct {
+	ID             uuid.UUID `json:"id"`
+	Handle         string    `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	CreatedAt      time.Time `json:"created_at"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	ChirpCount     int64     `json:"chirp_count"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	// Pinned holds the user's pinned chirps, most recently pinned first.
+	Pinned []Chirp `json:"pinned"`
+}
+
+func (cfg *apiConfig) getProfileHandler(w http.ResponseWriter, r *http.Request) {
+	handle := strings.TrimPrefix(r.PathValue("handle"), "@")
+	if !validHandle(handle) {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	row, err := cfg.db.GetUserProfileByHandle(r.Context(), handle)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't fetch profile", err)
+		return
+	}
+	pinnedModels, err := cfg.db.ListPinnedChirps(r.Context(), row.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't fetch pinned chirps", err)
+		return
+	}
+	pinned, err := cfg.presentChirps(r, pinnedModels)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, Profile{
+		ID:             row.ID,
+		Handle:         row.Handle.String,
+		DisplayName:    row.DisplayName,
+		Bio:            row.Bio,
+		CreatedAt:      row.CreatedAt,
+		IsChirpyRed:    row.IsChirpyRed,
+		ChirpCount:     row.ChirpCount,
+		FollowerCount:  row.FollowerCount,
+		FollowingCount: row.FollowingCount,
+		Pinned:         pinned,
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateHandle(t *testing.T) {
+	for _, handle := range []string{"alice", "Bob_99", "x"} {
+		if err := validateHandle(handle); err != nil {
+			t.Errorf("validateHandle(%q) = %v, want nil", handle, err)
+		}
+	}
+	for _, handle := range []string{"", "has space", "émile", "sixteen_chars_xx", "Admin", "support"} {
+		if err := validateHandle(handle); err == nil {
+			t.Errorf("validateHandle(%q) = nil, want an error", handle)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUpWithHandle(t, srv, "alice@example.com", "alice")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var updated User
+	code := doJSON(t, srv, "PUT", "/api/users", bob.Token, map[string]string{"handle": "Bob", "display_name": "Bob B.", "bio": "hi"}, &updated)
+	if code != http.StatusOK {
+		t.Fatalf("PUT /api/users: got status %d", code)
+	}
+	if updated.Handle != "Bob" || updated.DisplayName != "Bob B." || updated.Email != "bob@example.com" {
+		t.Errorf("unexpected user after update: %+v", updated)
+	}
+	if code := doJSON(t, srv, "PUT", "/api/users", bob.Token, map[string]string{"handle": "ALICE"}, nil); code != http.StatusConflict {
+		t.Errorf("taking another user's handle: got status %d, want %d", code, http.StatusConflict)
+	}
+	if code := doJSON(t, srv, "PUT", "/api/users", bob.Token, map[string]string{"bio": strings.Repeat("a", maxBioLength+1)}, nil); code != http.StatusBadRequest {
+		t.Errorf("long bio: got status %d, want %d", code, http.StatusBadRequest)
+	}
+	if code := doJSON(t, srv, "POST", "/api/users", "", map[string]string{"email": "c@example.com", "password": "x", "handle": "admin"}, nil); code != http.StatusBadRequest {
+		t.Errorf("reserved handle: got status %d, want %d", code, http.StatusBadRequest)
+	}
+
+	doJSON(t, srv, "POST", "/api/users/"+bob.ID.String()+"/follow", alice.Token, nil, nil)
+	doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]string{"body": "first"}, nil)
+
+	resp, err := srv.Client().Get(srv.URL + "/api/users/bob")
+	if err != nil {
+		t.Fatalf("GET profile: %v", err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET profile: got status %d", resp.StatusCode)
+	}
+	if strings.Contains(string(raw), "bob@example.com") || strings.Contains(string(raw), "password") {
+		t.Errorf("profile leaks private fields: %s", raw)
+	}
+
+	var profile Profile
+	doJSON(t, srv, "GET", "/api/users/@Bob", "", nil, &profile)
+	if profile.ID != bob.ID || profile.Handle != "Bob" || profile.ChirpCount != 1 || profile.FollowerCount != 1 || profile.FollowingCount != 0 {
+		t.Errorf("unexpected profile: %+v", profile)
+	}
+	if code := doJSON(t, srv, "GET", "/api/users/nobody", "", nil, nil); code != http.StatusNotFound {
+		t.Errorf("unknown handle: got status %d, want %d", code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// purgeDeleted hard-deletes the chirps and users whose retention ran out
+// before now. A deleted chirp that still has replies stays as a tombstone
+// so the conversation keeps its shape, but its body and edit history are
+// wiped. It reports how many chirps and users were removed.
+func (cfg *apiConfig) purgeDeleted(ctx context.Context, now time.Time) (chirps, users int64, err error) {
+	cutoff := now.Add(-cfg.retention)
+	// Removing a reply can free its parent, so keep going until a pass
+	// finds nothing left to remove.
+	for {
+		n, err := cfg.db.PurgeDeletedChirps(ctx, cutoff)
+		if err != nil {
+			return chirps, users, err
+		}
+		if n == 0 {
+			break
+		}
+		chirps += n
+	}
+	if err := cfg.db.ScrubDeletedChirps(ctx, cutoff); err != nil {
+		return chirps, users, err
+	}
+	// Let go of the media of the tombstones, so purgeOrphanedMedia can
+	// remove them.
+	if err := cfg.db.DetachDeletedChirpMedia(ctx, cutoff); err != nil {
+		return chirps, users, err
+	}
+	users, err = cfg.db.PurgeDeletedUsers(ctx, cutoff)
+	return chirps, users, err
+}
+
+// purgeOrphanedMedia removes the media that have not been attached to a
+// chirp since before now minus orphanedMediaTTL: uploads that were never
+// used and those whose chirp was purged. Blobs go first, so a failure
+// leaves a row to retry rather than a file nothing points at.
+func (cfg *apiConfig) purgeOrphanedMedia(ctx context.Context, now time.Time) (int, error) {
+	ids, err := cfg.db.ListOrphanedMediaItems(ctx, now.Add(-orphanedMediaTTL))
+	if err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		if err := cfg.deleteMediaBlobs(ctx, id); err != nil {
+			return i, err
+		}
+		if err := cfg.db.DeleteMediaItem(ctx, id); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
+// runPurger calls purgeDeleted and purgeOrphanedMedia every interval until ctx is cancelled.
+func (cfg *apiConfig) runPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		chirps, users, err := cfg.purgeDeleted(ctx, time.Now())
+		if err != nil {
+			log.Printf("Purging deleted rows failed: %s", err)
+		} else if chirps > 0 || users > 0 {
+			log.Printf("Purged %d deleted chirps and %d deleted users", chirps, users)
+		}
+		if n, err := cfg.purgeOrphanedMedia(ctx, time.Now()); err != nil {
+			log.Printf("Purging orphaned media failed: %s", err)
+		} else if n > 0 {
+			log.Printf("Purged %d orphaned media", n)
+		}
+		if n, err := cfg.db.DeleteExpiredAccessTokens(ctx, time.Now()); err != nil {
+			log.Printf("Purging expired access tokens failed: %s", err)
+		} else if n > 0 {
+			log.Printf("Purged %d expired access tokens", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+)
+
+// legacyTokenBatch is how many plaintext refresh tokens are hashed per
+// query.
+const legacyTokenBatch = 500
+
+// hashLegacyRefreshTokens replaces the plaintext of refresh tokens issued
+// before tokens were stored hashed with their hash, so they keep working
+// and the database stops holding them. It reports how many it hashed.
+func (cfg *apiConfig) hashLegacyRefreshTokens(ctx context.Context) (int, error) {
+	n := 0
+	for {
+		rows, err := cfg.db.ListUnhashedRefreshTokens(ctx, legacyTokenBatch)
+		if err != nil {
+			return n, err
+		}
+		if len(rows) == 0 {
+			return n, nil
+		}
+		for _, row := range rows {
+			err := cfg.db.SetRefreshTokenHash(ctx, database.SetRefreshTokenHashParams{
+				TokenHash: cfg.hashRefreshToken(row.Token.String),
+				ID:        row.ID,
+			})
+			if err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+}
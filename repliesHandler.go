@@ -0,0 +1,168 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// maxThreadSize bounds how many chirps a single thread response loads.
+const maxThreadSize = 1000
+
+type ThreadNode struct {
+	Chirp
+	Depth   int          `json:"depth"`
+	Replies []ThreadNode `json:"replies"`
+}
+
+type threadResponse struct {
+	Root      ThreadNode `json:"root"`
+	Truncated bool       `json:"truncated,omitempty"`
+}
+
+func (cfg *apiConfig) getRepliesHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+	page, err := parsePageParams(r.URL.Query(), "asc")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if page.Desc {
+		respondWithError(w, http.StatusBadRequest, "only sort=asc is supported", nil)
+		return
+	}
+
+	if _, ok := cfg.conversationChirp(w, r, chirpID); !ok {
+		return
+	}
+
+	replies, err := cfg.db.GetReplies(r.Context(), database.GetRepliesParams{
+		ParentID:        uuid.NullUUID{UUID: chirpID, Valid: true},
+		CursorCreatedAt: page.cursorTime(),
+		CursorID:        page.cursorID(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching replies", err)
+		return
+	}
+
+	replies, next := trimPage(page, replies, chirpCursor)
+	apiChirps, err := cfg.presentChirps(r, replies)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	setLinkHeader(w, r, next)
+	respondWithJSON(w, http.StatusOK, chirpsPage{
+		Chirps:     apiChirps,
+		NextCursor: next,
+	})
+}
+
+// conversationChirp loads a chirp whose replies or thread are being read.
+// Deleted chirps still count while they have live replies, since they are
+// shown as tombstones in the conversation. It writes the error response
+// itself and reports whether the caller may go ahead.
+func (cfg *apiConfig) conversationChirp(w http.ResponseWriter, r *http.Request, id uuid.UUID) (database.Chirp, bool) {
+	chirp, err := cfg.db.GetChirpIncludingDeleted(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && chirp.DeletedAt.Valid && chirp.ReplyCount == 0) {
+		respondWithError(w, http.StatusNotFound, "ID not Found", err)
+		return database.Chirp{}, false
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching chirp by ID", err)
+		return database.Chirp{}, false
+	}
+	return chirp, true
+}
+
+func (cfg *apiConfig) getThreadHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid Chirp ID", err)
+		return
+	}
+
+	chirp, ok := cfg.conversationChirp(w, r, chirpID)
+	if !ok {
+		return
+	}
+
+	rootID := chirp.ID
+	if chirp.RootID.Valid {
+		rootID = chirp.RootID.UUID
+	}
+	chirps, err := cfg.db.GetThread(r.Context(), database.GetThreadParams{
+		RootID: rootID,
+		Limit:  maxThreadSize + 1,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching thread", err)
+		return
+	}
+	truncated := len(chirps) > maxThreadSize
+	if truncated {
+		chirps = chirps[:maxThreadSize]
+	}
+
+	apiChirps, err := cfg.presentChirps(r, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	root, ok := buildThread(apiChirps, rootID)
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Thread not found", nil)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, threadResponse{Root: root, Truncated: truncated})
+}
+
+// buildThread arranges the chirps of a conversation into a tree under
+// rootID. Chirps whose parent is no longer in the thread hang off the root,
+// and deleted chirps are dropped unless something below them survives.
+// chirps must be ordered by created_at so siblings keep posting order.
+func buildThread(chirps []Chirp, rootID uuid.UUID) (ThreadNode, bool) {
+	byID := make(map[uuid.UUID]Chirp, len(chirps))
+	for _, c := range chirps {
+		byID[c.ID] = c
+	}
+	root, ok := byID[rootID]
+	if !ok {
+		return ThreadNode{}, false
+	}
+
+	children := map[uuid.UUID][]Chirp{}
+	for _, c := range chirps {
+		if c.ID == rootID {
+			continue
+		}
+		parent := rootID
+		if c.InReplyTo != nil {
+			if _, ok := byID[*c.InReplyTo]; ok {
+				parent = *c.InReplyTo
+			}
+		}
+		children[parent] = append(children[parent], c)
+	}
+
+	var build func(c Chirp, depth int) (ThreadNode, bool)
+	build = func(c Chirp, depth int) (ThreadNode, bool) {
+		node := ThreadNode{Chirp: c, Depth: depth, Replies: []ThreadNode{}}
+		for _, child := range children[c.ID] {
+			if reply, ok := build(child, depth+1); ok {
+				node.Replies = append(node.Replies, reply)
+			}
+		}
+		return node, !c.Deleted || len(node.Replies) > 0
+	}
+	node, _ := build(root, 0)
+	return node, true
+}
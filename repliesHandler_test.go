@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestThread_RepliesAndTombstones(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	var root, reply, nested Chirp
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{"body": "root"}, &root)
+	if code := doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]interface{}{"body": "reply", "in_reply_to": root.ID}, &reply); code != http.StatusCreated {
+		t.Fatalf("POST reply: got status %d", code)
+	}
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]interface{}{"body": "nested", "in_reply_to": reply.ID}, &nested)
+	if nested.RootID == nil || *nested.RootID != root.ID {
+		t.Errorf("nested reply has root %v, want %v", nested.RootID, root.ID)
+	}
+
+	var got Chirp
+	doJSON(t, srv, "GET", "/api/chirps/"+root.ID.String(), "", nil, &got)
+	if got.ReplyCount != 1 {
+		t.Errorf("root has reply_count %d, want 1", got.ReplyCount)
+	}
+
+	if code := doJSON(t, srv, "DELETE", "/api/chirps/"+root.ID.String(), alice.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("DELETE root: got status %d", code)
+	}
+
+	var thread threadResponse
+	if code := doJSON(t, srv, "GET", "/api/chirps/"+nested.ID.String()+"/thread", "", nil, &thread); code != http.StatusOK {
+		t.Fatalf("GET thread: got status %d", code)
+	}
+	if !thread.Root.Deleted || thread.Root.Body != "" {
+		t.Errorf("deleted root was not tombstoned: %+v", thread.Root.Chirp)
+	}
+	if len(thread.Root.Replies) != 1 || len(thread.Root.Replies[0].Replies) != 1 {
+		t.Fatalf("unexpected thread shape: %+v", thread.Root)
+	}
+	if d := thread.Root.Replies[0].Replies[0].Depth; d != 2 {
+		t.Errorf("nested reply has depth %d, want 2", d)
+	}
+
+	var replies chirpsPage
+	doJSON(t, srv, "GET", "/api/chirps/"+reply.ID.String()+"/replies", "", nil, &replies)
+	if len(replies.Chirps) != 1 || replies.Chirps[0].ID != nested.ID {
+		t.Errorf("unexpected replies: %+v", replies.Chirps)
+	}
+
+	if code := doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]interface{}{"body": "late", "in_reply_to": root.ID}, nil); code != http.StatusNotFound {
+		t.Errorf("reply to deleted chirp: got status %d, want %d", code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+const (
+	// maxDraftAttempts is how many times the scheduler tries a draft that
+	// keeps failing for reasons other than its content before giving up.
+	maxDraftAttempts = 5
+	// draftRetryDelay is the wait after the first failed attempt. It
+	// doubles with each attempt after that.
+	draftRetryDelay = time.Minute
+)
+
+// publishDueDrafts publishes the scheduled drafts due at now, each in its
+// own transaction. ClaimDueDraft locks the draft and skips ones that other
+// servers have locked, and the draft is deleted in the transaction that
+// posts it, so every draft is published exactly once however many servers
+// run the scheduler. A draft that can no longer be published is
+// unscheduled with the reason recorded. One that fails for any other
+// reason is put off with retryDraft so the drafts due after it still go
+// out. It reports how many drafts were published.
+func (cfg *apiConfig) publishDueDrafts(ctx context.Context, now time.Time) (int, error) {
+	published := 0
+	for {
+		var draft *database.Draft
+		var ok bool
+		err := cfg.db.ExecTx(ctx, func(q database.Querier) error {
+			claimed, err := q.ClaimDueDraft(ctx, now)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			draft = &claimed
+			_, _, err = cfg.publishDraft(ctx, q, claimed)
+			var notPublishable draftError
+			if errors.As(err, &notPublishable) {
+				return q.FailDraft(ctx, database.FailDraftParams{ID: claimed.ID, LastError: notPublishable.msg})
+			}
+			ok = err == nil
+			return err
+		})
+		if err == nil {
+			if draft == nil {
+				return published, nil
+			}
+			if ok {
+				published++
+			}
+			continue
+		}
+		if draft == nil || ctx.Err() != nil {
+			return published, err
+		}
+		log.Printf("Publishing draft %s failed: %s", draft.ID, err)
+		if err := cfg.retryDraft(ctx, *draft, now); err != nil {
+			return published, err
+		}
+	}
+}
+
+// retryDraft puts off a draft whose publish failed, doubling the delay with
+// each attempt, and unschedules it once it has used up its attempts.
+func (cfg *apiConfig) retryDraft(ctx context.Context, d database.Draft, now time.Time) error {
+	attempts := int(d.PublishAttempts) + 1
+	if attempts >= maxDraftAttempts {
+		return cfg.db.FailDraft(ctx, database.FailDraftParams{
+			ID:        d.ID,
+			LastError: "Couldn't publish the chirp; schedule it again to retry",
+		})
+	}
+	delay := draftRetryDelay << (attempts - 1)
+	return cfg.db.RetryDraft(ctx, database.RetryDraftParams{
+		ID:      d.ID,
+		RetryAt: sql.NullTime{Time: now.Add(delay), Valid: true},
+	})
+}
+
+// runScheduler calls publishDueDrafts every interval until ctx is
+// cancelled.
+func (cfg *apiConfig) runScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := cfg.publishDueDrafts(ctx, time.Now())
+		if err != nil {
+			log.Printf("Publishing scheduled chirps failed: %s", err)
+		} else if n > 0 {
+			log.Printf("Published %d scheduled chirps", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
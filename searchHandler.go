@@ -0,0 +1,134 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/search"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type SearchResult struct {
+	Chirp
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type searchPage struct {
+	Results    []SearchResult `json:"results"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// Search results are ordered by rank, so their cursor carries the rank in
+// front of the usual (created_at, id) position.
+func encodeSearchCursor(rank float32, c pageCursor) string {
+	raw := strconv.FormatFloat(float64(rank), 'g', -1, 32) + "|" + encodeCursor(c)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeSearchCursor(s string) (float32, pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, pageCursor{}, errors.New("invalid cursor")
+	}
+	rankStr, inner, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return 0, pageCursor{}, errors.New("invalid cursor")
+	}
+	rank, err := strconv.ParseFloat(rankStr, 32)
+	if err != nil {
+		return 0, pageCursor{}, errors.New("invalid cursor")
+	}
+	c, err := decodeCursor(inner)
+	if err != nil {
+		return 0, pageCursor{}, err
+	}
+	return float32(rank), c, nil
+}
+
+func (cfg *apiConfig) searchChirpsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q, err := search.Parse(query.Get("q"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rawCursor := query.Get("cursor")
+	query.Del("cursor")
+	page, err := parsePageParams(query, "desc")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if !page.Desc {
+		respondWithError(w, http.StatusBadRequest, "search results are always sorted by relevance", nil)
+		return
+	}
+	var cursorRank sql.NullFloat64
+	if rawCursor != "" {
+		rank, c, err := decodeSearchCursor(rawCursor)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		cursorRank = sql.NullFloat64{Float64: float64(rank), Valid: true}
+		page.Cursor = &c
+	}
+
+	var authorID uuid.NullUUID
+	if s := query.Get("author_id"); s != "" {
+		id, parseErr := uuid.Parse(s)
+		if parseErr != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid author_id", parseErr)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	rows, err := cfg.db.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:           q.TSQuery(),
+		AuthorID:        authorID,
+		CursorRank:      cursorRank,
+		CursorCreatedAt: page.cursorTime(),
+		CursorID:        page.cursorID(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error searching chirps", err)
+		return
+	}
+
+	next := ""
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		last := rows[len(rows)-1]
+		next = encodeSearchCursor(last.Rank, chirpCursor(last.Chirp))
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	apiChirps, err := cfg.presentChirps(r, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	results := make([]SearchResult, 0, len(rows))
+	for i, row := range rows {
+		results = append(results, SearchResult{
+			Chirp:   apiChirps[i],
+			Rank:    row.Rank,
+			Snippet: row.Snippet,
+		})
+	}
+
+	setLinkHeader(w, r, next)
+	respondWithJSON(w, http.StatusOK, searchPage{Results: results, NextCursor: next})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSearchChirps(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "the quick brown fox"}, nil)
+	doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": "a brown quick fox"}, nil)
+	doJSON(t, srv, "POST", "/api/chirps", bob.Token, map[string]string{"body": "quick thinking"}, nil)
+
+	search := func(params url.Values) searchPage {
+		t.Helper()
+		var page searchPage
+		if code := doJSON(t, srv, "GET", "/api/chirps/search?"+params.Encode(), "", nil, &page); code != http.StatusOK {
+			t.Fatalf("search %v: got status %d", params, code)
+		}
+		return page
+	}
+
+	if got := search(url.Values{"q": {`"quick brown"`}}); len(got.Results) != 1 || got.Results[0].Snippet != "the <mark>quick brown</mark> fox" {
+		t.Errorf("phrase search returned %+v", got.Results)
+	}
+	if got := search(url.Values{"q": {"quick"}, "author_id": {bob.ID.String()}}); len(got.Results) != 1 || got.Results[0].UserId != bob.ID {
+		t.Errorf("author filter returned %+v", got.Results)
+	}
+	if got := search(url.Values{"q": {"thin*"}}); len(got.Results) != 1 {
+		t.Errorf("prefix search returned %+v", got.Results)
+	}
+
+	var seen int
+	params := url.Values{"q": {"quick"}, "limit": {"2"}}
+	for page := search(params); ; page = search(params) {
+		seen += len(page.Results)
+		if page.NextCursor == "" {
+			break
+		}
+		params.Set("cursor", page.NextCursor)
+	}
+	if seen != 3 {
+		t.Errorf("paging through results saw %d chirps, want 3", seen)
+	}
+
+	if code := doJSON(t, srv, "GET", "/api/chirps/search?q=-fox", "", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("negation-only query: got status %d, want %d", code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a login, identified by the family of refresh tokens it
+// rotates through.
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	DeviceName string    `json:"device_name,omitempty"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current marks the session the request's access token belongs to.
+	Current bool `json:"current"`
+}
+
+// sessionFromModel describes a session by its live refresh token, which
+// was issued the last time the session was used.
+func sessionFromModel(rt database.RefreshToken, current uuid.UUID) Session {
+	return Session{
+		ID:         rt.FamilyID,
+		DeviceName: rt.DeviceName,
+		UserAgent:  rt.UserAgent,
+		IPAddress:  rt.IpAddress,
+		CreatedAt:  rt.SessionCreatedAt,
+		LastUsedAt: rt.CreatedAt,
+		ExpiresAt:  rt.ExpiresAt,
+		Current:    rt.FamilyID == current,
+	}
+}
+
+func (cfg *apiConfig) getSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, sessionID, ok := cfg.requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := cfg.db.ListSessions(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't list sessions", err)
+		return
+	}
+	sessions := make([]Session, 0, len(tokens))
+	for _, rt := range tokens {
+		sessions = append(sessions, sessionFromModel(rt, sessionID))
+	}
+	respondWithJSON(w, http.StatusOK, sessions)
+}
+
+// deleteSessionHandler logs one of the caller's sessions out by revoking
+// its refresh tokens and the access tokens issued to it.
+func (cfg *apiConfig) deleteSessionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := cfg.requireUser(w, r)
+	if !ok {
+		return
+	}
+	sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid session ID", err)
+		return
+	}
+
+	var revoked []database.RevokeUserAccessTokensRow
+	err = cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		rt, err := q.GetSession(r.Context(), database.GetSessionParams{
+			FamilyID: sessionID,
+			UserID:   userID,
+		})
+		if err != nil {
+			return err
+		}
+		// By family rather than hash: tokens from before refresh tokens
+		// were hashed may not have one yet.
+		if err := q.RevokeRefreshTokenFamily(r.Context(), rt.FamilyID); err != nil {
+			return err
+		}
+		revoked, err = q.RevokeUserAccessTokens(r.Context(), database.RevokeUserAccessTokensParams{
+			UserID:    userID,
+			SessionID: uuid.NullUUID{UUID: sessionID, Valid: true},
+		})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Session not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke session", err)
+		return
+	}
+	cfg.denylist.add(revoked)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteOtherSessionsHandler logs the caller out everywhere except the
+// session their access token belongs to.
+func (cfg *apiConfig) deleteOtherSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, sessionID, ok := cfg.requireSession(w, r)
+	if !ok {
+		return
+	}
+	if sessionID == uuid.Nil {
+		respondWithError(w, http.StatusBadRequest, "This access token doesn't belong to a session; log in again", nil)
+		return
+	}
+
+	var revoked []database.RevokeUserAccessTokensRow
+	err := cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		var err error
+		revoked, err = logOutElsewhere(r.Context(), q, userID, sessionID)
+		return err
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke sessions", err)
+		return
+	}
+	cfg.denylist.add(revoked)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// logOutElsewhere revokes the refresh and access tokens of every session of
+// the user but keep, or of all of them when keep is uuid.Nil. It returns
+// the access tokens it revoked, for the caller to add to the denylist once
+// the transaction commits.
+func logOutElsewhere(ctx context.Context, q database.Querier, userID, keep uuid.UUID) ([]database.RevokeUserAccessTokensRow, error) {
+	tokens, err := q.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, rt := range tokens {
+		if rt.FamilyID == keep {
+			continue
+		}
+		if err := q.RevokeRefreshTokenFamily(ctx, rt.FamilyID); err != nil {
+			return nil, err
+		}
+	}
+	return q.RevokeUserAccessTokens(ctx, database.RevokeUserAccessTokensParams{
+		UserID:        userID,
+		KeepSessionID: uuid.NullUUID{UUID: keep, Valid: keep != uuid.Nil},
+	})
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSessions(t *testing.T) {
+	var cfg *apiConfig
+	srv := newTestServerWithConfig(t, func(c *apiConfig) { cfg = c })
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+	login := func(device string) loginResponse {
+		t.Helper()
+		var resp loginResponse
+		creds := map[string]string{"email": "alice@example.com", "password": "hunter2", "device_name": device}
+		if code := doJSON(t, srv, "POST", "/api/login", "", creds, &resp); code != http.StatusOK {
+			t.Fatalf("login: got status %d", code)
+		}
+		return resp
+	}
+	laptop := login("Laptop")
+	phone := login("Phone")
+
+	var sessions []Session
+	if code := doJSON(t, srv, "GET", "/api/sessions", alice.Token, nil, &sessions); code != http.StatusOK {
+		t.Fatalf("GET /api/sessions: got status %d", code)
+	}
+	if len(sessions) != 3 {
+		t.Fatalf("sessions: %+v", sessions)
+	}
+	var current, laptopSession Session
+	for _, s := range sessions {
+		if s.Current {
+			current = s
+		}
+		if s.DeviceName == "Laptop" {
+			laptopSession = s
+		}
+	}
+	if current.DeviceName != "" || laptopSession.Current || laptopSession.UserAgent == "" || laptopSession.IPAddress == "" {
+		t.Errorf("sessions: %+v", sessions)
+	}
+
+	// Refreshing keeps the session and marks it used.
+	time.Sleep(10 * time.Millisecond)
+	var refreshed refreshResponse
+	doJSON(t, srv, "POST", "/api/refresh", laptop.RefreshToken, nil, &refreshed)
+	var after []Session
+	doJSON(t, srv, "GET", "/api/sessions", refreshed.Token, nil, &after)
+	if len(after) != 3 || after[0].ID != laptopSession.ID || !after[0].Current {
+		t.Fatalf("sessions after refresh: %+v", after)
+	}
+	if !after[0].LastUsedAt.After(laptopSession.LastUsedAt) || !after[0].CreatedAt.Equal(laptopSession.CreatedAt) {
+		t.Errorf("refreshed session: before %+v, after %+v", laptopSession, after[0])
+	}
+
+	if code := doJSON(t, srv, "DELETE", "/api/sessions/"+laptopSession.ID.String(), bob.Token, nil, nil); code != http.StatusNotFound {
+		t.Errorf("deleting someone else's session: got status %d", code)
+	}
+	if code := doJSON(t, srv, "DELETE", "/api/sessions/"+laptopSession.ID.String(), alice.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete session: got status %d", code)
+	}
+	if code := doJSON(t, srv, "POST", "/api/refresh", refreshed.RefreshToken, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("refresh in a deleted session: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", "/api/sessions", refreshed.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("access token of a deleted session: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", "/api/sessions", phone.Token, nil, nil); code != http.StatusOK {
+		t.Errorf("access token of another session: got status %d", code)
+	}
+
+	if code := doJSON(t, srv, "DELETE", "/api/sessions/others", alice.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("log out everywhere else: got status %d", code)
+	}
+	if code := doJSON(t, srv, "POST", "/api/refresh", phone.RefreshToken, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("refresh in another session: got status %d", code)
+	}
+	doJSON(t, srv, "GET", "/api/sessions", alice.Token, nil, &sessions)
+	if len(sessions) != 1 || !sessions[0].Current {
+		t.Errorf("sessions after logging out elsewhere: %+v", sessions)
+	}
+	if code := doJSON(t, srv, "POST", "/api/refresh", alice.RefreshToken, nil, nil); code != http.StatusOK {
+		t.Errorf("refresh in the current session: got status %d", code)
+	}
+
+	// Access tokens from before sessions can't tell which one to keep.
+	legacy, _, err := cfg.keys.MakeSessionJWT(alice.ID, uuid.Nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := doJSON(t, srv, "DELETE", "/api/sessions/others", legacy, nil, nil); code != http.StatusBadRequest {
+		t.Errorf("log out elsewhere without a session: got status %d", code)
+	}
+}
+
+func TestLogin_DeviceNameTooLong(t *testing.T) {
+	srv := newTestServer(t)
+	signUp(t, srv, "alice@example.com")
+	creds := map[string]string{"email": "alice@example.com", "password": "hunter2", "device_name": strings.Repeat("x", maxDeviceNameLength+1)}
+	if code := doJSON(t, srv, "POST", "/api/login", "", creds, nil); code != http.StatusBadRequest {
+		t.Errorf("login with a long device name: got status %d", code)
+	}
+}
+
+// unhashedStore hides the hashes of refresh tokens listed as sessions,
+// standing in for rows stored before refresh tokens were hashed.
+type unhashedStore struct {
+	database.Store
+}
+
+func (s unhashedStore) ExecTx(ctx context.Context, fn func(database.Querier) error) error {
+	return s.Store.ExecTx(ctx, func(q database.Querier) error {
+		return fn(unhashedQuerier{Querier: q})
+	})
+}
+
+type unhashedQuerier struct {
+	database.Querier
+}
+
+func (q unhashedQuerier) GetSession(ctx context.Context, arg database.GetSessionParams) (database.RefreshToken, error) {
+	rt, err := q.Querier.GetSession(ctx, arg)
+	rt.TokenHash = sql.NullString{}
+	return rt, err
+}
+
+func (q unhashedQuerier) ListSessions(ctx context.Context, userID uuid.UUID) ([]database.RefreshToken, error) {
+	tokens, err := q.Querier.ListSessions(ctx, userID)
+	for i := range tokens {
+		tokens[i].TokenHash = sql.NullString{}
+	}
+	return tokens, err
+}
+
+func TestSessions_RevokesUnhashedTokens(t *testing.T) {
+	srv := newTestServerWithConfig(t, func(c *apiConfig) { c.db = unhashedStore{Store: c.db} })
+	alice := signUp(t, srv, "alice@example.com")
+	login := func() loginResponse {
+		t.Helper()
+		var resp loginResponse
+		creds := map[string]string{"email": "alice@example.com", "password": "hunter2"}
+		if code := doJSON(t, srv, "POST", "/api/login", "", creds, &resp); code != http.StatusOK {
+			t.Fatalf("login: got status %d", code)
+		}
+		return resp
+	}
+	laptop, phone := login(), login()
+
+	var sessions []Session
+	doJSON(t, srv, "GET", "/api/sessions", laptop.Token, nil, &sessions)
+	var laptopSession Session
+	for _, s := range sessions {
+		if s.Current {
+			laptopSession = s
+		}
+	}
+	if code := doJSON(t, srv, "DELETE", "/api/sessions/"+laptopSession.ID.String(), alice.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete session: got status %d", code)
+	}
+	if code := doJSON(t, srv, "POST", "/api/refresh", laptop.RefreshToken, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("refresh in a deleted session: got status %d", code)
+	}
+
+	if code := doJSON(t, srv, "DELETE", "/api/sessions/others", alice.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("log out everywhere else: got status %d", code)
+	}
+	if code := doJSON(t, srv, "POST", "/api/refresh", phone.RefreshToken, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("refresh in another session: got status %d", code)
+	}
+}
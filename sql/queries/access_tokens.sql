@@ -0,0 +1,23 @@
+-- name: CreateAccessToken :exec
+INSERT INTO access_tokens (jti, user_id, session_id, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: RevokeUserAccessTokens :many
+-- Revokes the user's outstanding access tokens, except those of
+-- keep_session_id when it is set, and only those of session_id when it is.
+UPDATE access_tokens
+SET revoked_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+AND revoked_at IS NULL
+AND expires_at > NOW()
+AND (sqlc.narg('keep_session_id')::uuid IS NULL OR session_id IS DISTINCT FROM sqlc.narg('keep_session_id')::uuid)
+AND (sqlc.narg('session_id')::uuid IS NULL OR session_id = sqlc.narg('session_id')::uuid)
+RETURNING jti, expires_at;
+
+-- name: ListRevokedAccessTokens :many
+SELECT jti, expires_at FROM access_tokens
+WHERE revoked_at IS NOT NULL AND expires_at > NOW();
+
+-- name: DeleteExpiredAccessTokens :execrows
+DELETE FROM access_tokens
+WHERE expires_at <= $1;
@@ -0,0 +1,61 @@
+-- name: BookmarkChirp :execrows
+-- Bookmarking a chirp again moves it to the given collection. Nothing is
+-- written if the collection isn't the user's.
+INSERT INTO bookmarks (user_id, chirp_id, collection_id, created_at)
+SELECT sqlc.arg('user_id')::uuid, sqlc.arg('chirp_id')::uuid, sqlc.narg('collection_id')::uuid, NOW()
+WHERE sqlc.narg('collection_id')::uuid IS NULL OR EXISTS (
+    SELECT 1 FROM bookmark_collections
+    WHERE id = sqlc.narg('collection_id')::uuid AND user_id = sqlc.arg('user_id')::uuid
+)
+ON CONFLICT (user_id, chirp_id) DO UPDATE SET collection_id = EXCLUDED.collection_id;
+
+-- name: UnbookmarkChirp :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = $1 AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListBookmarks :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg('user_id')
+AND chirps.deleted_at IS NULL
+AND (sqlc.narg('collection_id')::uuid IS NULL OR bookmarks.collection_id = sqlc.narg('collection_id')::uuid)
+AND (
+    sqlc.narg('cursor_created_at')::timestamptz IS NULL
+    OR (bookmarks.created_at, bookmarks.chirp_id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CreateBookmarkCollection :one
+INSERT INTO bookmark_collections (id, user_id, name, created_at, updated_at)
+VALUES (gen_random_uuid(), $1, $2, NOW(), NOW())
+RETURNING *;
+
+-- name: GetBookmarkCollection :one
+SELECT * FROM bookmark_collections
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListBookmarkCollections :many
+-- bookmark_count leaves out bookmarks of deleted chirps, which aren't listed.
+SELECT sqlc.embed(bookmark_collections), (
+    SELECT COUNT(*) FROM bookmarks
+    JOIN chirps ON chirps.id = bookmarks.chirp_id
+    WHERE bookmarks.collection_id = bookmark_collections.id AND chirps.deleted_at IS NULL
+) AS bookmark_count
+FROM bookmark_collections
+WHERE bookmark_collections.user_id = $1
+ORDER BY bookmark_collections.name, bookmark_collections.id;
+
+-- name: RenameBookmarkCollection :one
+UPDATE bookmark_collections
+SET name = $1, updated_at = NOW()
+WHERE id = $2 AND user_id = $3
+RETURNING *;
+
+-- name: DeleteBookmarkCollection :execrows
+DELETE FROM bookmark_collections
+WHERE id = $1 AND user_id = $2;
@@ -1,11 +1,20 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+WITH parent AS (
+    UPDATE chirps
+    SET reply_count = reply_count + 1
+    WHERE chirps.id = sqlc.narg('parent_id')::uuid
+)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, rechirp_of_id, quote_of_id)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
-    $1,
-    $2
+    sqlc.arg('body'),
+    sqlc.arg('user_id'),
+    sqlc.narg('parent_id')::uuid,
+    sqlc.narg('root_id')::uuid,
+    sqlc.narg('rechirp_of_id')::uuid,
+    sqlc.narg('quote_of_id')::uuid
 )
 RETURNING *;
 
@@ -14,17 +23,168 @@ RETURNING *;
 DELETE FROM chirps;
 
 -- name: GetAllChirps :many
-SELECT * FROM chirps ORDER BY created_at;
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+ORDER BY created_at;
 
 -- name: GetOneChirps :one
 SELECT * FROM chirps
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetChirpIncludingDeleted :one
+SELECT * FROM chirps
 WHERE id = $1;
 
--- name: DeleteChirp :exec
+-- name: SoftDeleteChirp :exec
+WITH target AS (
+    UPDATE chirps
+    SET deleted_at = NOW(), updated_at = NOW()
+    WHERE chirps.id = $1 AND chirps.deleted_at IS NULL
+    RETURNING parent_id
+), rechirps AS (
+    UPDATE chirps
+    SET deleted_at = NOW(), updated_at = NOW()
+    WHERE rechirp_of_id = $1 AND deleted_at IS NULL
+    RETURNING id
+), pins AS (
+    DELETE FROM pinned_chirps
+    WHERE chirp_id = $1 OR chirp_id IN (SELECT id FROM rechirps)
+)
+UPDATE chirps
+SET reply_count = reply_count - 1
+WHERE chirps.id = (SELECT parent_id FROM target);
+
+-- name: RestoreChirp :one
+WITH target AS (
+    SELECT id, parent_id, deleted_at FROM chirps
+    WHERE chirps.id = sqlc.arg('id') AND chirps.deleted_at > sqlc.arg('cutoff')::timestamptz
+), rechirps AS (
+    UPDATE chirps
+    SET deleted_at = NULL, updated_at = NOW()
+    FROM target
+    WHERE chirps.rechirp_of_id = target.id
+    AND chirps.deleted_at = target.deleted_at
+    AND NOT EXISTS (
+        SELECT 1 FROM chirps live
+        WHERE live.user_id = chirps.user_id
+        AND live.rechirp_of_id = target.id
+        AND live.deleted_at IS NULL
+    )
+), parent AS (
+    UPDATE chirps
+    SET reply_count = reply_count + 1
+    WHERE chirps.id = (SELECT parent_id FROM target)
+)
+UPDATE chirps
+SET deleted_at = NULL, updated_at = NOW()
+FROM target
+WHERE chirps.id = target.id
+RETURNING chirps.*;
+
+-- name: AddUserReplyCounts :exec
+UPDATE chirps
+SET reply_count = reply_count + counts.replies * sqlc.arg('delta')::int
+FROM (
+    SELECT parent_id, COUNT(*) AS replies FROM chirps
+    WHERE user_id = sqlc.arg('user_id')
+    AND parent_id IS NOT NULL
+    AND deleted_at IS NOT DISTINCT FROM sqlc.narg('deleted_at')
+    GROUP BY parent_id
+) counts
+WHERE chirps.id = counts.parent_id;
+
+-- name: PurgeDeletedChirps :execrows
 DELETE FROM chirps
-WHERE id = $1;
+WHERE deleted_at < sqlc.arg('cutoff')::timestamptz
+AND NOT EXISTS (
+    SELECT 1 FROM chirps replies
+    WHERE replies.parent_id = chirps.id
+);
+
+-- name: ScrubDeletedChirps :exec
+WITH expired AS (
+    SELECT id FROM chirps
+    WHERE deleted_at < sqlc.arg('cutoff')::timestamptz
+), revisions AS (
+    DELETE FROM chirp_revisions
+    WHERE chirp_id IN (SELECT id FROM expired)
+), mentions AS (
+    DELETE FROM chirp_mentions
+    WHERE chirp_id IN (SELECT id FROM expired)
+), tags AS (
+    DELETE FROM chirp_tags
+    WHERE chirp_id IN (SELECT id FROM expired)
+), polls AS (
+    DELETE FROM polls
+    WHERE chirp_id IN (SELECT id FROM expired)
+), bookmarks AS (
+    DELETE FROM bookmarks
+    WHERE chirp_id IN (SELECT id FROM expired)
+)
+UPDATE chirps
+SET body = ''
+WHERE id IN (SELECT id FROM expired) AND body <> '';
+
+-- name: EditChirp :one
+-- The old body was written when the chirp was posted or last edited;
+-- updated_at also moves on deletes and restores, so it can't say when.
+WITH revision AS (
+    INSERT INTO chirp_revisions (id, chirp_id, body, created_at)
+    SELECT gen_random_uuid(), chirps.id, chirps.body, COALESCE(chirps.edited_at, chirps.created_at)
+    FROM chirps
+    WHERE chirps.id = sqlc.arg('id') AND chirps.deleted_at IS NULL
+)
+UPDATE chirps
+SET body = sqlc.arg('body'), updated_at = NOW(), edited_at = NOW()
+WHERE chirps.id = sqlc.arg('id') AND chirps.deleted_at IS NULL
+RETURNING *;
 
 -- name: GetChirpsByID :many 
 SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY created_at;
\ No newline at end of file
+WHERE user_id = $1 AND deleted_at IS NULL
+ORDER BY created_at;
+
+
+-- name: ListChirpsAsc :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND (
+    sqlc.narg('cursor_created_at')::timestamptz IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: ListChirpsDesc :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id')::uuid)
+AND (
+    sqlc.narg('cursor_created_at')::timestamptz IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetReplies :many
+SELECT * FROM chirps
+WHERE parent_id = sqlc.arg('parent_id')
+AND (deleted_at IS NULL OR reply_count > 0)
+AND (
+    sqlc.narg('cursor_created_at')::timestamptz IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetThread :many
+SELECT * FROM chirps
+WHERE id = sqlc.arg('root_id') OR root_id = sqlc.arg('root_id')
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+
+-- name: ListChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
@@ -0,0 +1,76 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, user_id, body, parent_id, quote_of_id, publish_at, created_at, updated_at)
+VALUES (
+    gen_random_uuid(),
+    sqlc.arg('user_id'),
+    sqlc.arg('body'),
+    sqlc.narg('parent_id'),
+    sqlc.narg('quote_of_id'),
+    sqlc.narg('publish_at'),
+    NOW(),
+    NOW()
+)
+RETURNING *;
+
+-- name: GetDraft :one
+SELECT * FROM drafts
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListDrafts :many
+SELECT * FROM drafts
+WHERE user_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamptz IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET body = sqlc.arg('body'),
+    parent_id = sqlc.narg('parent_id'),
+    quote_of_id = sqlc.narg('quote_of_id'),
+    publish_at = sqlc.narg('publish_at'),
+    last_error = '',
+    publish_attempts = 0,
+    retry_at = NULL,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id') AND user_id = sqlc.arg('user_id')
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts
+WHERE id = $1 AND user_id = $2;
+
+-- name: LockDraft :one
+-- Waits for a scheduler that is publishing the draft, after which the
+-- draft is gone.
+SELECT * FROM drafts
+WHERE id = $1 AND user_id = $2
+FOR UPDATE;
+
+-- name: ClaimDueDraft :one
+-- Drafts another server is publishing are skipped rather than waited for,
+-- so each due draft is published by exactly one transaction. Drafts of
+-- suspended users wait until the suspension is lifted.
+SELECT drafts.* FROM drafts
+JOIN users ON users.id = drafts.user_id
+WHERE drafts.publish_at <= sqlc.arg('now')::timestamptz
+AND users.deleted_at IS NULL AND users.suspended_at IS NULL
+AND (drafts.retry_at IS NULL OR drafts.retry_at <= sqlc.arg('now')::timestamptz)
+ORDER BY drafts.publish_at, drafts.id
+LIMIT 1
+FOR UPDATE OF drafts SKIP LOCKED;
+
+-- name: FailDraft :exec
+UPDATE drafts
+SET publish_at = NULL, last_error = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: RetryDraft :exec
+-- Puts off a draft that failed to publish for a reason other than its
+-- content. Unlike FailDraft it stays scheduled.
+UPDATE drafts
+SET publish_attempts = publish_attempts + 1, retry_at = $2
+WHERE id = $1;
@@ -0,0 +1,34 @@
+-- name: LikeChirp :execrows
+INSERT INTO chirp_likes (chirp_id, user_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (chirp_id, user_id) DO NOTHING;
+
+-- name: UnlikeChirp :execrows
+DELETE FROM chirp_likes
+WHERE chirp_id = $1 AND user_id = $2;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = sqlc.arg('user_id') AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListChirpLikes :many
+SELECT user_id, created_at FROM chirp_likes
+WHERE chirp_id = sqlc.arg('chirp_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamptz IS NULL
+    OR (created_at, user_id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, user_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListUserLikes :many
+SELECT sqlc.embed(chirps), chirp_likes.created_at AS liked_at FROM chirp_likes
+JOIN chirps ON chirps.id = chirp_likes.chirp_id
+WHERE chirp_likes.user_id = sqlc.arg('user_id')
+AND chirps.deleted_at IS NULL
+AND (
+    sqlc.narg('cursor_created_at')::timestamptz IS NULL
+    OR (chirp_likes.created_at, chirp_likes.chirp_id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY chirp_likes.created_at DESC, chirp_likes.chirp_id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,35 @@
+-- name: CreateMediaItem :one
+INSERT INTO media_items (id, user_id, content_type, size_bytes, width, height, created_at)
+VALUES (sqlc.arg('id'), sqlc.arg('user_id')::uuid, sqlc.arg('content_type'), sqlc.arg('size_bytes'), sqlc.arg('width'), sqlc.arg('height'), NOW())
+RETURNING *;
+
+-- name: GetMediaItem :one
+-- Media attached to a deleted chirp are no longer served.
+SELECT media_items.* FROM media_items
+LEFT JOIN chirps ON chirps.id = media_items.chirp_id
+WHERE media_items.id = $1 AND chirps.deleted_at IS NULL;
+
+-- name: AttachMediaItem :execrows
+UPDATE media_items
+SET chirp_id = sqlc.arg('chirp_id')::uuid, position = sqlc.arg('position')::smallint
+WHERE id = sqlc.arg('id') AND user_id = sqlc.arg('user_id')::uuid AND chirp_id IS NULL;
+
+-- name: ListMediaItemsForChirps :many
+SELECT * FROM media_items
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: DetachDeletedChirpMedia :exec
+UPDATE media_items
+SET chirp_id = NULL, position = NULL
+FROM chirps
+WHERE chirps.id = media_items.chirp_id AND chirps.deleted_at < sqlc.arg('cutoff')::timestamptz;
+
+-- name: ListOrphanedMediaItems :many
+SELECT id FROM media_items
+WHERE chirp_id IS NULL AND created_at < sqlc.arg('cutoff')::timestamptz
+ORDER BY created_at;
+
+-- name: DeleteMediaItem :exec
+DELETE FROM media_items
+WHERE id = $1;
@@ -0,0 +1,27 @@
+-- name: CreateChirpMentions :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, start_offset, end_offset)
+SELECT sqlc.arg('chirp_id')::uuid, unnest(sqlc.arg('user_ids')::uuid[]), unnest(sqlc.arg('start_offsets')::int[]), unnest(sqlc.arg('end_offsets')::int[]);
+
+-- name: ListMentionsForChirps :many
+SELECT chirp_mentions.chirp_id, chirp_mentions.user_id, chirp_mentions.start_offset, chirp_mentions.end_offset, users.handle FROM chirp_mentions
+JOIN users ON users.id = chirp_mentions.user_id
+WHERE chirp_mentions.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_mentions.chirp_id, chirp_mentions.start_offset;
+
+-- name: ListMentioningChirps :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND EXISTS (
+    SELECT 1 FROM chirp_mentions
+    WHERE chirp_mentions.chirp_id = chirps.id AND chirp_mentions.user_id = sqlc.arg('user_id')
+)
+AND (
+    sqlc.narg('cursor_created_at')::timestamptz IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = $1;
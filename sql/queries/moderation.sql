@@ -0,0 +1,35 @@
+-- name: ListModerationRules :many
+SELECT * FROM moderation_rules
+ORDER BY word;
+
+-- name: UpsertModerationRule :one
+INSERT INTO moderation_rules (word, action, created_at, updated_at)
+VALUES ($1, $2, NOW(), NOW())
+ON CONFLICT (word) DO UPDATE
+SET action = EXCLUDED.action, updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteModerationRule :execrows
+DELETE FROM moderation_rules
+WHERE word = $1;
+
+-- name: FlagChirp :exec
+INSERT INTO chirp_flags (chirp_id, words, created_at)
+VALUES (sqlc.arg('chirp_id'), sqlc.arg('words')::text[], NOW())
+ON CONFLICT (chirp_id) DO UPDATE
+SET words = EXCLUDED.words, created_at = NOW();
+
+-- name: ListChirpFlags :many
+SELECT sqlc.embed(chirps), chirp_flags.words, chirp_flags.created_at AS flagged_at FROM chirp_flags
+JOIN chirps ON chirps.id = chirp_flags.chirp_id
+WHERE chirps.deleted_at IS NULL
+AND (
+    sqlc.narg('cursor_created_at')::timestamptz IS NULL
+    OR (chirp_flags.created_at, chirp_flags.chirp_id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY chirp_flags.created_at DESC, chirp_flags.chirp_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: DeleteChirpFlag :execrows
+DELETE FROM chirp_flags
+WHERE chirp_id = $1;
@@ -0,0 +1,19 @@
+-- name: PinChirp :execrows
+INSERT INTO pinned_chirps (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnpinChirp :execrows
+DELETE FROM pinned_chirps
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: CountPinnedChirps :one
+SELECT COUNT(*) FROM pinned_chirps
+WHERE user_id = $1;
+
+-- name: ListPinnedChirps :many
+-- Most recently pinned first.
+SELECT chirps.* FROM pinned_chirps
+JOIN chirps ON chirps.id = pinned_chirps.chirp_id
+WHERE pinned_chirps.user_id = $1 AND chirps.deleted_at IS NULL
+ORDER BY pinned_chirps.created_at DESC, pinned_chirps.chirp_id DESC;
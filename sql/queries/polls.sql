@@ -0,0 +1,34 @@
+-- name: CreatePoll :one
+INSERT INTO polls (chirp_id, options, multiple_choice, hide_results, expires_at, created_at)
+VALUES (sqlc.arg('chirp_id'), sqlc.arg('options')::text[], sqlc.arg('multiple_choice'), sqlc.arg('hide_results'), sqlc.arg('expires_at'), NOW())
+RETURNING *;
+
+-- name: GetPoll :one
+SELECT * FROM polls
+WHERE chirp_id = $1;
+
+-- name: CastPollVote :execrows
+-- Nothing is inserted once the poll has expired.
+INSERT INTO poll_votes (chirp_id, user_id, choices, created_at)
+SELECT polls.chirp_id, sqlc.arg('user_id')::uuid, sqlc.arg('choices')::integer[], NOW()
+FROM polls
+WHERE polls.chirp_id = sqlc.arg('chirp_id') AND polls.expires_at > NOW();
+
+-- name: ListPollsForChirps :many
+SELECT sqlc.embed(polls), (
+    SELECT COUNT(*) FROM poll_votes
+    WHERE poll_votes.chirp_id = polls.chirp_id
+) AS voter_count
+FROM polls
+WHERE polls.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListPollTallies :many
+SELECT poll_votes.chirp_id, choice::integer AS choice, COUNT(*) AS votes
+FROM poll_votes, unnest(poll_votes.choices) AS choice
+WHERE poll_votes.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY poll_votes.chirp_id, choice
+ORDER BY poll_votes.chirp_id, choice;
+
+-- name: ListPollVotesByUser :many
+SELECT * FROM poll_votes
+WHERE user_id = sqlc.arg('user_id') AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
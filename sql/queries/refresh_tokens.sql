@@ -1,26 +1,67 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens (id, token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, device_name, user_agent, ip_address, session_created_at)
 VALUES(
-    $1,
+    gen_random_uuid(),
+    $1::text,
     NOW(),
     NOW(),
     $2,
     $3,
-    NULL
+    NULL,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
 )
 RETURNING *;
 
 -- name: GetRefreshToken :one
 SELECT * FROM refresh_tokens
-WHERE token = $1;
+WHERE token_hash = $1::text;
+
+-- name: LockRefreshToken :one
+SELECT * FROM refresh_tokens
+WHERE token_hash = $1::text
+FOR UPDATE;
 
 -- name: GetUserFromRefreshToken :one
 SELECT users.* FROM refresh_tokens
 LEFT JOIN users
 ON users.id = refresh_tokens.user_id
-WHERE refresh_tokens.token = $1;
+AND users.deleted_at IS NULL
+WHERE refresh_tokens.token_hash = $1::text;
 
 -- name: Revoke :exec
 UPDATE refresh_tokens
 SET updated_at = NOW(), revoked_at = NOW()
-WHERE token = $1;
\ No newline at end of file
+WHERE token_hash = $1::text;
+
+-- name: RotateRefreshToken :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(), rotated_at = NOW(), revoked_at = COALESCE(revoked_at, NOW())
+WHERE token_hash = $1::text;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(), revoked_at = NOW()
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: ListUnhashedRefreshTokens :many
+SELECT id, token FROM refresh_tokens
+WHERE token_hash IS NULL
+LIMIT $1;
+
+-- name: SetRefreshTokenHash :exec
+UPDATE refresh_tokens
+SET token_hash = $1::text, token = NULL
+WHERE id = $2;
+
+-- name: ListSessions :many
+SELECT * FROM refresh_tokens
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY created_at DESC, id DESC;
+
+-- name: GetSession :one
+SELECT * FROM refresh_tokens
+WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW();
@@ -0,0 +1,15 @@
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps),
+    ts_rank(to_tsvector('english', chirps.body), query)::real AS rank,
+    ts_headline('english', chirps.body, query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')::text AS snippet
+FROM chirps, to_tsquery('english', sqlc.arg('query')) AS query
+WHERE to_tsvector('english', chirps.body) @@ query
+AND chirps.deleted_at IS NULL
+AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')::uuid)
+AND (
+    sqlc.narg('cursor_rank')::real IS NULL
+    OR (ts_rank(to_tsvector('english', chirps.body), query)::real, chirps.created_at, chirps.id)
+        < (sqlc.narg('cursor_rank')::real, sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
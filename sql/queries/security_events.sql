@@ -0,0 +1,9 @@
+-- name: CreateSecurityEvent :one
+INSERT INTO security_events (id, user_id, kind, family_id, ip_address, user_agent, created_at)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, NOW())
+RETURNING *;
+
+-- name: ListSecurityEvents :many
+SELECT * FROM security_events
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC;
@@ -0,0 +1,41 @@
+-- name: TagChirp :exec
+WITH upserted AS (
+    INSERT INTO tags (id, name, created_at)
+    SELECT gen_random_uuid(), name, NOW()
+    FROM unnest(sqlc.arg('names')::text[]) AS name
+    ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+    RETURNING id
+)
+INSERT INTO chirp_tags (chirp_id, tag_id, created_at)
+SELECT sqlc.arg('chirp_id')::uuid, upserted.id, sqlc.arg('created_at')::timestamptz
+FROM upserted
+ON CONFLICT (chirp_id, tag_id) DO NOTHING;
+
+-- name: ListChirpsByTag :many
+SELECT sqlc.embed(chirps) FROM chirp_tags
+JOIN tags ON tags.id = chirp_tags.tag_id
+JOIN chirps ON chirps.id = chirp_tags.chirp_id
+WHERE tags.name = sqlc.arg('tag')
+AND chirps.deleted_at IS NULL
+AND (
+    sqlc.narg('cursor_created_at')::timestamptz IS NULL
+    OR (chirp_tags.created_at, chirp_tags.chirp_id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY chirp_tags.created_at DESC, chirp_tags.chirp_id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CountTagUsage :many
+SELECT tags.name,
+    COUNT(*) FILTER (WHERE chirp_tags.created_at >= sqlc.arg('window_start')::timestamptz) AS recent,
+    COUNT(*) FILTER (WHERE chirp_tags.created_at < sqlc.arg('window_start')::timestamptz) AS baseline
+FROM chirp_tags
+JOIN tags ON tags.id = chirp_tags.tag_id
+JOIN chirps ON chirps.id = chirp_tags.chirp_id
+WHERE chirp_tags.created_at >= sqlc.arg('baseline_start')::timestamptz
+AND chirps.deleted_at IS NULL
+GROUP BY tags.name
+HAVING COUNT(*) FILTER (WHERE chirp_tags.created_at >= sqlc.arg('window_start')::timestamptz) > 0;
+
+-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = $1;
@@ -1,11 +1,14 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle, display_name, bio)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
 RETURNING *;
 
@@ -14,15 +17,18 @@ DELETE FROM users;
 
 -- name: GetUserByEmail :one
 SELECT * FROM users
-WHERE email = $1;
+WHERE email = $1 AND deleted_at IS NULL;
 
 
 -- name: UpdateUser :one
 UPDATE users 
 SET hashed_password = $1,
 email = $2,
+handle = $3,
+display_name = $4,
+bio = $5,
 updated_at = NOW()
-WHERE id = $3
+WHERE id = $6
 RETURNING *;
 
 
@@ -30,4 +36,90 @@ RETURNING *;
 UPDATE users
 SET updated_at = NOW(), is_chirpy_red = true
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: LockUser :one
+-- Serialises writes that check a per-user limit before inserting.
+SELECT * FROM users
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: GetUserIncludingDeleted :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUsersByHandles :many
+SELECT * FROM users
+WHERE lower(handle) = ANY(sqlc.arg('handles')::text[])
+AND deleted_at IS NULL;
+
+-- name: GetUserProfileByHandle :one
+SELECT users.id, users.created_at, users.handle, users.display_name, users.bio, users.is_chirpy_red,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = users.id AND chirps.deleted_at IS NULL) AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = users.id) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following_count
+FROM users
+WHERE lower(users.handle) = lower(sqlc.arg('handle'))
+AND users.deleted_at IS NULL;
+
+-- name: SoftDeleteUser :exec
+WITH target AS (
+    UPDATE users
+    SET deleted_at = NOW(), updated_at = NOW()
+    WHERE users.id = $1 AND users.deleted_at IS NULL
+    RETURNING users.id
+), tokens AS (
+    UPDATE refresh_tokens
+    SET revoked_at = NOW(), updated_at = NOW()
+    WHERE user_id IN (SELECT id FROM target) AND revoked_at IS NULL
+)
+UPDATE chirps
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE chirps.deleted_at IS NULL
+AND (
+    chirps.user_id IN (SELECT id FROM target)
+    OR chirps.rechirp_of_id IN (
+        SELECT authored.id FROM chirps authored
+        WHERE authored.user_id IN (SELECT id FROM target)
+        AND authored.deleted_at IS NULL
+    )
+);
+
+-- name: RestoreUser :one
+WITH target AS (
+    SELECT id, deleted_at FROM users
+    WHERE users.id = sqlc.arg('id') AND users.deleted_at > sqlc.arg('cutoff')::timestamptz
+), restored_chirps AS (
+    UPDATE chirps
+    SET deleted_at = NULL, updated_at = NOW()
+    FROM target
+    WHERE chirps.deleted_at = target.deleted_at
+    AND (
+        chirps.user_id = target.id
+        OR chirps.rechirp_of_id IN (SELECT authored.id FROM chirps authored WHERE authored.user_id = target.id)
+    )
+)
+UPDATE users
+SET deleted_at = NULL, updated_at = NOW()
+FROM target
+WHERE users.id = target.id
+RETURNING users.*;
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE deleted_at < sqlc.arg('cutoff')::timestamptz;
+
+-- name: SuspendUser :execrows
+-- Suspending a suspended user keeps the time of the first suspension.
+UPDATE users
+SET suspended_at = COALESCE(suspended_at, NOW()), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: UnsuspendUser :execrows
+UPDATE users
+SET suspended_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN parent_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+ADD COLUMN root_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX chirps_parent_id_idx ON chirps (parent_id, created_at, id);
+CREATE INDEX chirps_root_id_idx ON chirps (root_id, created_at, id);
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN deleted_at,
+DROP COLUMN reply_count,
+DROP COLUMN root_id,
+DROP COLUMN parent_id;
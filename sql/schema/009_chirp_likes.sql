@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE chirp_likes (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE INDEX chirp_likes_chirp_id_created_at_idx ON chirp_likes (chirp_id, created_at, user_id);
+CREATE INDEX chirp_likes_user_id_created_at_idx ON chirp_likes (user_id, created_at, chirp_id);
+
+ALTER TABLE chirps
+ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+
+-- The counter is kept by a trigger so likes removed by a cascading user
+-- delete are accounted for too.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_likes_count() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE chirps SET like_count = like_count + 1 WHERE id = NEW.chirp_id;
+    ELSE
+        UPDATE chirps SET like_count = like_count - 1 WHERE id = OLD.chirp_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirp_likes_count
+AFTER INSERT OR DELETE ON chirp_likes
+FOR EACH ROW EXECUTE FUNCTION chirp_likes_count();
+
+-- +goose Down
+DROP TABLE chirp_likes;
+DROP FUNCTION chirp_likes_count();
+ALTER TABLE chirps
+DROP COLUMN like_count;
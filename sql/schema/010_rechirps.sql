@@ -0,0 +1,17 @@
+-- +goose Up
+-- quote_of_id deliberately has no foreign key: a quote outlives the chirp it
+-- quotes and is rendered with a tombstone once the original is gone.
+ALTER TABLE chirps
+ADD COLUMN rechirp_of_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+ADD COLUMN quote_of_id UUID;
+
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_id_key ON chirps (user_id, rechirp_of_id)
+WHERE rechirp_of_id IS NOT NULL;
+CREATE INDEX chirps_quote_of_id_idx ON chirps (quote_of_id);
+
+-- +goose Down
+DROP INDEX chirps_quote_of_id_idx;
+DROP INDEX chirps_user_id_rechirp_of_id_key;
+ALTER TABLE chirps
+DROP COLUMN quote_of_id,
+DROP COLUMN rechirp_of_id;
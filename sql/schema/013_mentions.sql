@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN handle TEXT;
+
+CREATE UNIQUE INDEX users_handle_key ON users (lower(handle));
+
+-- Offsets are in Unicode code points into the chirp body.
+CREATE TABLE chirp_mentions (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, start_offset)
+);
+
+CREATE INDEX chirp_mentions_user_id_chirp_id_idx ON chirp_mentions (user_id, chirp_id);
+
+-- +goose Down
+DROP TABLE chirp_mentions;
+DROP INDEX users_handle_key;
+ALTER TABLE users
+DROP COLUMN handle;
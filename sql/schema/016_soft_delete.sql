@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Deleted rechirps are kept until they are purged, so they must not stop
+-- the user from rechirping the same chirp again.
+DROP INDEX chirps_user_id_rechirp_of_id_key;
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_id_key ON chirps (user_id, rechirp_of_id)
+WHERE rechirp_of_id IS NOT NULL AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_id_rechirp_of_id_key;
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_id_key ON chirps (user_id, rechirp_of_id)
+WHERE rechirp_of_id IS NOT NULL;
+DROP INDEX chirps_deleted_at_idx;
+DROP INDEX users_deleted_at_idx;
+ALTER TABLE users
+DROP COLUMN deleted_at;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Rules added through the admin API. They are layered over the rules from
+-- the config file, and an 'allow' rule lifts a word the config blocks.
+CREATE TABLE moderation_rules (
+    word TEXT PRIMARY KEY,
+    action TEXT NOT NULL CHECK (action IN ('mask', 'reject', 'flag', 'allow')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Chirps that tripped a flag rule and are waiting for a moderator.
+CREATE TABLE chirp_flags (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    words TEXT[] NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX chirp_flags_created_at_idx ON chirp_flags (created_at, chirp_id);
+
+-- +goose Down
+DROP TABLE chirp_flags;
+DROP TABLE moderation_rules;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Images uploaded for chirps. The bytes live in the blob store, keyed by the
+-- row's id; the row records what the upload was checked to be. Uploads start
+-- out unattached and are tied to a chirp when it is posted. Rows that lose
+-- their chirp or uploader are kept until the purger has removed their blobs.
+CREATE TABLE media_items (
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    position SMALLINT CHECK (position BETWEEN 0 AND 3),
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (chirp_id, position)
+);
+
+CREATE INDEX media_items_unattached_idx ON media_items (created_at) WHERE chirp_id IS NULL;
+
+-- +goose Down
+DROP TABLE media_items;
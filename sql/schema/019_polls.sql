@@ -0,0 +1,28 @@
+-- +goose Up
+-- A poll attached to a chirp. Options are addressed by their index in the
+-- options array.
+CREATE TABLE polls (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    options TEXT[] NOT NULL CHECK (cardinality(options) BETWEEN 2 AND 4),
+    multiple_choice BOOLEAN NOT NULL,
+    -- hide_results keeps the tallies from people who haven't voted until
+    -- the poll expires.
+    hide_results BOOLEAN NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- One ballot per user per poll, holding every option they picked.
+CREATE TABLE poll_votes (
+    chirp_id UUID NOT NULL REFERENCES polls(chirp_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    choices SMALLINT[] NOT NULL CHECK (cardinality(choices) >= 1),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE INDEX poll_votes_user_id_idx ON poll_votes (user_id);
+
+-- +goose Down
+DROP TABLE poll_votes;
+DROP TABLE polls;
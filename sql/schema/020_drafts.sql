@@ -0,0 +1,24 @@
+-- +goose Up
+-- Chirps that haven't been posted yet. A draft with publish_at set is
+-- scheduled: the scheduler posts it as a chirp once that time has passed and
+-- deletes the draft in the same transaction. parent_id and quote_of_id are
+-- not foreign keys; they are checked again when the draft is published.
+CREATE TABLE drafts (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    parent_id UUID,
+    quote_of_id UUID,
+    publish_at TIMESTAMP WITH TIME ZONE,
+    -- last_error says why a scheduled draft couldn't be published. The
+    -- draft is unscheduled so its author can fix it.
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX drafts_user_id_created_at_idx ON drafts (user_id, created_at, id);
+CREATE INDEX drafts_publish_at_idx ON drafts (publish_at) WHERE publish_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE drafts;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- Bookmarks are private to the user who made them. A bookmark can be filed
+-- in one of its owner's collections; deleting the collection leaves the
+-- bookmark unfiled.
+CREATE TABLE bookmark_collections (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE bookmarks (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    collection_id UUID REFERENCES bookmark_collections(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX bookmarks_user_id_created_at_idx ON bookmarks (user_id, created_at, chirp_id);
+CREATE INDEX bookmarks_collection_id_idx ON bookmarks (collection_id);
+CREATE INDEX bookmarks_chirp_id_idx ON bookmarks (chirp_id);
+
+-- +goose Down
+DROP TABLE bookmarks;
+DROP TABLE bookmark_collections;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Chirps their authors pinned to the top of their profile. Deleting a chirp
+-- unpins it.
+CREATE TABLE pinned_chirps (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX pinned_chirps_chirp_id_idx ON pinned_chirps (chirp_id);
+
+-- +goose Down
+DROP TABLE pinned_chirps;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Refresh tokens are single use. Each login starts a family and every
+-- refresh replaces the presented token with the next one in it. A token
+-- that was already rotated showing up again means it was copied, so the
+-- whole family is revoked and the attempt is recorded.
+ALTER TABLE refresh_tokens
+    ADD COLUMN family_id UUID NOT NULL DEFAULT gen_random_uuid(),
+    ADD COLUMN rotated_at TIMESTAMP DEFAULT NULL;
+
+-- Existing tokens each got a family of their own above.
+ALTER TABLE refresh_tokens ALTER COLUMN family_id DROP DEFAULT;
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+CREATE TABLE security_events (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    family_id UUID,
+    ip_address TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX security_events_user_id_created_at_idx ON security_events (user_id, created_at);
+
+-- +goose Down
+DROP TABLE security_events;
+ALTER TABLE refresh_tokens DROP COLUMN rotated_at, DROP COLUMN family_id;
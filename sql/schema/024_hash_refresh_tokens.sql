@@ -0,0 +1,24 @@
+-- +goose Up
+-- Refresh tokens are stored as a keyed hash of the token rather than the
+-- token itself, so they get a surrogate key. Tokens issued before this
+-- migration keep their plaintext until the server, which holds the hash
+-- key, hashes them on startup; the token column can be dropped once none
+-- are left.
+ALTER TABLE refresh_tokens ADD COLUMN id UUID NOT NULL DEFAULT gen_random_uuid();
+ALTER TABLE refresh_tokens ALTER COLUMN id DROP DEFAULT;
+ALTER TABLE refresh_tokens DROP CONSTRAINT refresh_tokens_pkey;
+ALTER TABLE refresh_tokens ADD PRIMARY KEY (id);
+ALTER TABLE refresh_tokens ALTER COLUMN token DROP NOT NULL;
+ALTER TABLE refresh_tokens ADD COLUMN token_hash TEXT;
+
+CREATE UNIQUE INDEX refresh_tokens_token_hash_idx ON refresh_tokens (token_hash);
+
+-- +goose Down
+-- Hashed tokens can't be turned back into tokens, so they are dropped and
+-- their users have to log in again.
+DELETE FROM refresh_tokens WHERE token IS NULL;
+DROP INDEX refresh_tokens_token_hash_idx;
+ALTER TABLE refresh_tokens DROP COLUMN token_hash;
+ALTER TABLE refresh_tokens DROP CONSTRAINT refresh_tokens_pkey;
+ALTER TABLE refresh_tokens DROP COLUMN id;
+ALTER TABLE refresh_tokens ADD PRIMARY KEY (token);
@@ -0,0 +1,29 @@
+-- +goose Up
+-- A session is a login and the refresh tokens it rotates through, which
+-- share a family_id. Each token carries what the session was last used
+-- from; the device name and session start are copied along on rotation.
+-- Every use of a session rotates its token, so the live token's created_at
+-- is when the session was last used.
+ALTER TABLE refresh_tokens
+    ADD COLUMN device_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN ip_address TEXT NOT NULL DEFAULT '',
+    ADD COLUMN session_created_at TIMESTAMP;
+
+UPDATE refresh_tokens
+SET session_created_at = (
+    SELECT MIN(family.created_at) FROM refresh_tokens family
+    WHERE family.family_id = refresh_tokens.family_id
+);
+
+ALTER TABLE refresh_tokens ALTER COLUMN session_created_at SET NOT NULL;
+
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+-- +goose Down
+DROP INDEX refresh_tokens_user_id_idx;
+ALTER TABLE refresh_tokens
+    DROP COLUMN session_created_at,
+    DROP COLUMN ip_address,
+    DROP COLUMN user_agent,
+    DROP COLUMN device_name;
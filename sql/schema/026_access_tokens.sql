@@ -0,0 +1,17 @@
+-- +goose Up
+-- Access tokens are recorded by jti when they are issued, so the
+-- outstanding ones can be revoked. A revoked token stays here, and is
+-- rejected, until it would have expired anyway.
+CREATE TABLE access_tokens (
+    jti TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    session_id UUID,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX access_tokens_user_id_idx ON access_tokens (user_id);
+CREATE INDEX access_tokens_expires_at_idx ON access_tokens (expires_at);
+
+-- +goose Down
+DROP TABLE access_tokens;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Soft-deleted accounts give up their email and handle straight away, so
+-- someone else can sign up with them during the restore window. Restoring
+-- the account fails if they have been taken.
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+CREATE UNIQUE INDEX users_email_key ON users (email) WHERE deleted_at IS NULL;
+
+DROP INDEX users_handle_key;
+CREATE UNIQUE INDEX users_handle_key ON users (lower(handle)) WHERE deleted_at IS NULL;
+
+-- +goose Down
+-- Fails while a deleted account shares an email or handle with a live one;
+-- purge or rename it first.
+DROP INDEX users_handle_key;
+CREATE UNIQUE INDEX users_handle_key ON users (lower(handle));
+
+DROP INDEX users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
@@ -0,0 +1,14 @@
+-- +goose Up
+-- A scheduled draft whose publish fails for a reason other than the draft
+-- itself, such as the database going away mid-publish, is retried after
+-- retry_at with a growing delay, and unscheduled once it has failed too
+-- many times. Until retry_at it is skipped so it doesn't hold up the drafts
+-- due after it.
+ALTER TABLE drafts
+ADD COLUMN publish_attempts INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN retry_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose Down
+ALTER TABLE drafts
+DROP COLUMN publish_attempts,
+DROP COLUMN retry_at;
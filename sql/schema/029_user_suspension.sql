@@ -0,0 +1,10 @@
+-- +goose Up
+-- Suspended users can't log in or refresh, and their outstanding tokens are
+-- revoked when they are suspended. Unlike deletion, nothing else about the
+-- account changes, so lifting the suspension is just clearing the column.
+ALTER TABLE users
+ADD COLUMN suspended_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN suspended_at;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- lib/pq can only scan arrays of types it knows, and SMALLINT[] isn't one
+-- of them, so ballots are read back as INTEGER[].
+ALTER TABLE poll_votes
+ALTER COLUMN choices TYPE INTEGER[];
+
+-- +goose Down
+ALTER TABLE poll_votes
+ALTER COLUMN choices TYPE SMALLINT[];
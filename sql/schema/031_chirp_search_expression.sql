@@ -0,0 +1,17 @@
+-- +goose Up
+-- The stored vector made every SELECT * FROM chirps send it to the app,
+-- which never reads it. Search matches against the same expression, so
+-- the index serves it without the column.
+DROP INDEX chirps_search_vector_idx;
+ALTER TABLE chirps
+DROP COLUMN search_vector;
+
+CREATE INDEX chirps_search_idx ON chirps USING GIN (to_tsvector('english', body));
+
+-- +goose Down
+DROP INDEX chirps_search_idx;
+ALTER TABLE chirps
+ADD COLUMN search_vector TSVECTOR
+GENERATED ALWAYS AS (to_tsvector('english', body)) STORED;
+
+CREATE INDEX chirps_search_vector_idx ON chirps USING GIN (search_vector);
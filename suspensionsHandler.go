@@ -0,0 +1,77 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+var errUserSuspended = errors.New("user is suspended")
+
+// suspendUserHandler lets an admin lock an account out. Its refresh and
+// access tokens are revoked, and logging in or refreshing is refused until
+// the suspension is lifted.
+func (cfg *apiConfig) suspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin key required", nil)
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	var revoked []database.RevokeUserAccessTokensRow
+	err = cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		// Marking the user first locks their row, so a login holding it
+		// finishes issuing its tokens before they are revoked below.
+		n, err := q.SuspendUser(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return sql.ErrNoRows
+		}
+		revoked, err = logOutElsewhere(r.Context(), q, id, uuid.Nil)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't suspend user", err)
+		return
+	}
+	cfg.denylist.add(revoked)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unsuspendUserHandler lifts a suspension. The user has to log in again;
+// the tokens revoked by the suspension stay revoked.
+func (cfg *apiConfig) unsuspendUserHandler(w http.ResponseWriter, r *http.Request) {
+	if !cfg.isAdmin(r) {
+		respondWithError(w, http.StatusUnauthorized, "Admin key required", nil)
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	n, err := cfg.db.UnsuspendUser(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unsuspend user", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSuspendUser(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+	bob := signUp(t, srv, "bob@example.com")
+	creds := map[string]string{"email": "alice@example.com", "password": "hunter2"}
+
+	path := "/admin/users/" + alice.ID.String() + "/suspend"
+	if code := doJSON(t, srv, "POST", path, bob.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("suspend without admin key: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := doAdmin(t, srv, "POST", "/admin/users/"+uuid.NewString()+"/suspend", nil, nil); code != http.StatusNotFound {
+		t.Errorf("suspend an unknown user: got status %d, want %d", code, http.StatusNotFound)
+	}
+	if code := doAdmin(t, srv, "POST", path, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("POST %s: got status %d", path, code)
+	}
+
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", alice.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("suspended user's access token: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := doJSON(t, srv, "POST", "/api/refresh", alice.RefreshToken, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("suspended user's refresh token: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := doJSON(t, srv, "POST", "/api/login", "", creds, nil); code != http.StatusForbidden {
+		t.Errorf("suspended user's login: got status %d, want %d", code, http.StatusForbidden)
+	}
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", bob.Token, nil, nil); code != http.StatusOK {
+		t.Errorf("another user's access token: got status %d", code)
+	}
+
+	path = "/admin/users/" + alice.ID.String() + "/unsuspend"
+	if code := doAdmin(t, srv, "POST", path, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("POST %s: got status %d", path, code)
+	}
+	var again loginResponse
+	if code := doJSON(t, srv, "POST", "/api/login", "", creds, &again); code != http.StatusOK {
+		t.Fatalf("login after the suspension was lifted: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", again.Token, nil, nil); code != http.StatusOK {
+		t.Errorf("new access token: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", alice.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("access token revoked by the suspension: got status %d, want %d", code, http.StatusUnauthorized)
+	}
+}
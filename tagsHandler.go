@@ -0,0 +1,206 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxTagLength = 64
+
+	defaultTrendingWindow = time.Hour
+	minTrendingWindow     = 5 * time.Minute
+	maxTrendingWindow     = 24 * time.Hour
+	// The baseline a window is compared against covers this many windows
+	// immediately before it.
+	trendingBaselineWindows = 24
+	defaultTrendingLimit    = 10
+	maxTrendingLimit        = 50
+)
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_'
+}
+
+// extractHashtags returns the distinct hashtags in body, lowercased, in the
+// order they first appear. A tag starts with # at the beginning of the body
+// or after a character that can't be part of a tag, and must contain at
+// least one letter so "#1" stays plain text.
+func extractHashtags(body string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	prev := ' '
+	for i, r := range body {
+		if r != '#' || isTagRune(prev) || prev == '#' {
+			prev = r
+			continue
+		}
+		prev = r
+		rest := body[i+1:]
+		end := strings.IndexFunc(rest, func(r rune) bool { return !isTagRune(r) })
+		if end < 0 {
+			end = len(rest)
+		}
+		tag, ok := normalizeTag(rest[:end])
+		if ok && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// normalizeTag lowercases a tag without its leading # and reports whether it
+// is one extractHashtags would accept.
+func normalizeTag(s string) (string, bool) {
+	s = strings.ToLower(s)
+	if s == "" || utf8.RuneCountInString(s) > maxTagLength {
+		return "", false
+	}
+	hasLetter := false
+	for _, r := range s {
+		if !isTagRune(r) {
+			return "", false
+		}
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+	}
+	return s, hasLetter
+}
+
+func (cfg *apiConfig) getTagChirpsHandler(w http.ResponseWriter, r *http.Request) {
+	tag, ok := normalizeTag(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "Invalid tag", nil)
+		return
+	}
+	page, err := parsePageParams(r.URL.Query(), "desc")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if !page.Desc {
+		respondWithError(w, http.StatusBadRequest, "only sort=desc is supported", nil)
+		return
+	}
+
+	rows, err := cfg.db.ListChirpsByTag(r.Context(), database.ListChirpsByTagParams{
+		Tag:             tag,
+		CursorCreatedAt: page.cursorTime(),
+		CursorID:        page.cursorID(),
+		Limit:           page.queryLimit(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching tagged chirps", err)
+		return
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	chirps, next := trimPage(page, chirps, chirpCursor)
+	apiChirps, err := cfg.presentChirps(r, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't load chirp details", err)
+		return
+	}
+	setLinkHeader(w, r, next)
+	respondWithJSON(w, http.StatusOK, chirpsPage{
+		Chirps:     apiChirps,
+		NextCursor: next,
+	})
+}
+
+type TrendingTag struct {
+	Tag           string  `json:"tag"`
+	Count         int64   `json:"count"`
+	BaselineCount int64   `json:"baseline_count"`
+	Score         float64 `json:"score"`
+}
+
+type trendingResponse struct {
+	Window string        `json:"window"`
+	Tags   []TrendingTag `json:"tags"`
+}
+
+// trendingScore compares a tag's use in the window with how often it would
+// be expected to appear based on the baseline. The +1 keeps brand new tags
+// from scoring infinitely and favours tags used more than once.
+func trendingScore(recent, baseline int64) float64 {
+	expected := float64(baseline) / trendingBaselineWindows
+	return float64(recent) / (expected + 1)
+}
+
+func parseTrendingParams(r *http.Request) (time.Duration, int, error) {
+	window := defaultTrendingWindow
+	if s := r.URL.Query().Get("window"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < minTrendingWindow || d > maxTrendingWindow {
+			return 0, 0, errors.New("window must be a duration between 5m and 24h")
+		}
+		window = d
+	}
+	limit := defaultTrendingLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return 0, 0, errors.New("limit must be a positive integer")
+		}
+		limit = min(n, maxTrendingLimit)
+	}
+	return window, limit, nil
+}
+
+func (cfg *apiConfig) getTrendingTagsHandler(w http.ResponseWriter, r *http.Request) {
+	window, limit, err := parseTrendingParams(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	windowStart := time.Now().Add(-window)
+	rows, err := cfg.db.CountTagUsage(r.Context(), database.CountTagUsageParams{
+		WindowStart:   windowStart,
+		BaselineStart: windowStart.Add(-trendingBaselineWindows * window),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error counting tags", err)
+		return
+	}
+
+	tags := make([]TrendingTag, 0, len(rows))
+	for _, row := range rows {
+		tags = append(tags, TrendingTag{
+			Tag:           row.Name,
+			Count:         row.Recent,
+			BaselineCount: row.Baseline,
+			Score:         trendingScore(row.Recent, row.Baseline),
+		})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Score != tags[j].Score {
+			return tags[i].Score > tags[j].Score
+		}
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	if len(tags) > limit {
+		tags = tags[:limit]
+	}
+
+	respondWithJSON(w, http.StatusOK, trendingResponse{
+		Window: window.String(),
+		Tags:   tags,
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"no tags here", nil},
+		{"#Go is fun, #go!", []string{"go"}},
+		{"mixing #one and #two_words.", []string{"one", "two_words"}},
+		{"issue#42 and a#b are not tags", nil},
+		{"#1 is not a tag but #1st is", []string{"1st"}},
+		{"##double and #", nil},
+		{"#café", []string{"café"}},
+	}
+	for _, tt := range tests {
+		if got := extractHashtags(tt.body); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("extractHashtags(%q) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestTagPagesAndTrending(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+
+	for _, body := range []string{"learning #Go", "more #go and #rust", "#1 is not a tag"} {
+		doJSON(t, srv, "POST", "/api/chirps", alice.Token, map[string]string{"body": body}, nil)
+	}
+
+	var page chirpsPage
+	if code := doJSON(t, srv, "GET", "/api/tags/GO/chirps", "", nil, &page); code != http.StatusOK {
+		t.Fatalf("GET tag page: got status %d", code)
+	}
+	if len(page.Chirps) != 2 || page.Chirps[0].Body != "more #go and #rust" {
+		t.Errorf("unexpected #go chirps: %+v", page.Chirps)
+	}
+	if code := doJSON(t, srv, "GET", "/api/tags/no-such-tag!/chirps", "", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("invalid tag: got status %d, want %d", code, http.StatusBadRequest)
+	}
+
+	var trending trendingResponse
+	if code := doJSON(t, srv, "GET", "/api/tags/trending?window=30m", "", nil, &trending); code != http.StatusOK {
+		t.Fatalf("GET trending: got status %d", code)
+	}
+	if len(trending.Tags) != 2 || trending.Tags[0].Tag != "go" || trending.Tags[0].Count != 2 || trending.Tags[1].Tag != "rust" {
+		t.Errorf("unexpected trending tags: %+v", trending.Tags)
+	}
+	if code := doJSON(t, srv, "GET", "/api/tags/trending?window=1s", "", nil, nil); code != http.StatusBadRequest {
+		t.Errorf("tiny window: got status %d, want %d", code, http.StatusBadRequest)
+	}
+}
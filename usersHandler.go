@@ -3,21 +3,61 @@ package main
 import (
 	"chirpy/internal/auth"
 	"chirpy/internal/database"
+	"chirpy/internal/textlen"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	accessTokenValidity  = 1 * time.Hour
+	refreshTokenValidity = 60 * (24 * time.Hour)
+)
+
+const (
+	// maxDeviceNameLength is in user-perceived characters.
+	maxDeviceNameLength = 50
+	// maxUserAgentLength is in bytes.
+	maxUserAgentLength = 512
+)
+
+// securityEventRefreshTokenReuse is recorded when a refresh token that was
+// already exchanged is presented again.
+const securityEventRefreshTokenReuse = "refresh_token_reuse"
+
+var (
+	errInvalidRefreshToken = errors.New("invalid refresh token")
+	errRefreshTokenReused  = errors.New("rotated refresh token presented again")
+)
+
+func userFromModel(u database.User) User {
+	return User{
+		ID:          u.ID,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+		Email:       u.Email,
+		IsChirpyRed: u.IsChirpyRed,
+		Handle:      u.Handle.String,
+		DisplayName: u.DisplayName,
+		Bio:         u.Bio,
+	}
+}
+
 func (cfg *apiConfig) usersHandler(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email       string `json:"email"`
+		Password    string `json:"password"`
+		Handle      string `json:"handle"`
+		DisplayName string `json:"display_name"`
+		Bio         string `json:"bio"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -28,6 +68,11 @@ func (cfg *apiConfig) usersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateProfile(params.Handle, params.DisplayName, params.Bio); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "hashing password failed", err)
@@ -37,20 +82,20 @@ func (cfg *apiConfig) usersHandler(w http.ResponseWriter, r *http.Request) {
 	user, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
 		Email:          params.Email,
 		HashedPassword: hashedPassword,
+		Handle:         nullHandle(params.Handle),
+		DisplayName:    params.DisplayName,
+		Bio:            params.Bio,
 	})
-
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Email or handle is already taken", err)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Creating User failed", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, User{
-		ID:          user.ID,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-		Email:       user.Email,
-		IsChirpyRed: user.IsChirpyRed,
-	})
+	respondWithJSON(w, http.StatusCreated, userFromModel(user))
 }
 
 func (cfg *apiConfig) usersLoginHandler(w http.ResponseWriter, r *http.Request) {
@@ -58,6 +103,8 @@ func (cfg *apiConfig) usersLoginHandler(w http.ResponseWriter, r *http.Request)
 		Password         string `json:"password"`
 		Email            string `json:"email"`
 		ExpiresInSeconds int    `json:"expires_in_seconds"`
+		// DeviceName labels the session in the sessions list.
+		DeviceName string `json:"device_name"`
 	}
 	type response struct {
 		User
@@ -72,6 +119,11 @@ func (cfg *apiConfig) usersLoginHandler(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err)
 		return
 	}
+	deviceName := strings.TrimSpace(params.DeviceName)
+	if textlen.Graphemes(deviceName) > maxDeviceNameLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Device names can be at most %d characters", maxDeviceNameLength), nil)
+		return
+	}
 
 	user, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
 	if err != nil {
@@ -86,96 +138,214 @@ func (cfg *apiConfig) usersLoginHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	refreshTokenString, err := auth.MakeRefreshToken()
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't create refresh token", err)
+	// Each login starts a new session, and with it a new family of
+	// refresh tokens.
+	sessionID := uuid.New()
+	var accessToken, refreshTokenString string
+	err = cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		// Locked so a suspension waits for the tokens and revokes them,
+		// rather than missing tokens issued after it was checked for.
+		locked, err := q.LockUser(r.Context(), user.ID)
+		if err != nil {
+			return err
+		}
+		if locked.SuspendedAt.Valid {
+			return errUserSuspended
+		}
+		refreshTokenString, err = cfg.createRefreshToken(r.Context(), q, database.CreateRefreshTokenParams{
+			UserID:           user.ID,
+			ExpiresAt:        time.Now().Add(refreshTokenValidity),
+			FamilyID:         sessionID,
+			DeviceName:       deviceName,
+			UserAgent:        userAgent(r),
+			IpAddress:        clientIP(r),
+			SessionCreatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		accessToken, err = cfg.issueAccessToken(r.Context(), q, user.ID, sessionID)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
-
-	refreshTokenExpiry := time.Now().Add(60 * 24 * time.Hour)
-	_, err = cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		Token:     refreshTokenString,
-		UserID:    user.ID,
-		ExpiresAt: refreshTokenExpiry,
-	})
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't store refresh token", err)
+	if errors.Is(err, errUserSuspended) {
+		respondWithError(w, http.StatusForbidden, "This account is suspended", err)
 		return
 	}
-
-	accessToken, err := auth.MakeJWT(
-		user.ID,
-		cfg.secret,
-		1*time.Hour,
-	)
-
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't create access JWT", err)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't log in", err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, response{
-		User: User{
-			ID:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
-		},
+		User:         userFromModel(user),
 		Token:        accessToken,
 		RefreshToken: refreshTokenString,
 	})
 }
 
-func (cfg *apiConfig) createRefreshToken(c context.Context, userID uuid.UUID) (string, error) {
-	const refreshTokenValidity = 60 * (24 * time.Hour)
+// createRefreshToken makes a new refresh token and stores it with params.
+// Only its hash is stored; the token itself is returned to be handed to the
+// client.
+func (cfg *apiConfig) createRefreshToken(c context.Context, q database.Querier, params database.CreateRefreshTokenParams) (string, error) {
 	rTokenString, err := auth.MakeRefreshToken()
 	if err != nil {
 		return "", err
 	}
-	params := database.CreateRefreshTokenParams{
-		Token:     rTokenString,
-		UserID:    userID,
-		ExpiresAt: time.Now().Add(refreshTokenValidity),
-	}
-	_, err = cfg.db.CreateRefreshToken(c, params)
+	params.TokenHash = cfg.hashRefreshToken(rTokenString)
+	_, err = q.CreateRefreshToken(c, params)
 	if err != nil {
 		return "", err
 	}
 	return rTokenString, nil
 }
 
+// issueAccessToken makes an access token for a session and records its jti,
+// so it can be revoked before it expires.
+func (cfg *apiConfig) issueAccessToken(c context.Context, q database.Querier, userID, sessionID uuid.UUID) (string, error) {
+	token, claims, err := cfg.keys.MakeSessionJWT(userID, sessionID, accessTokenValidity)
+	if err != nil {
+		return "", err
+	}
+	err = q.CreateAccessToken(c, database.CreateAccessTokenParams{
+		Jti:       claims.ID,
+		UserID:    userID,
+		SessionID: uuid.NullUUID{UUID: sessionID, Valid: true},
+		ExpiresAt: claims.ExpiresAt.Time,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// handlerRefresh exchanges a refresh token for a new access token and the
+// next refresh token in its family. The presented token stops working, and
+// presenting it again revokes the whole family: only one of the two parties
+// holding it can be the user, and there is no telling which.
 func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 	rTokenString, err := auth.GetBearerToken(r.Header)
 	if err != nil {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
-	refreshToken, err := cfg.db.GetRefreshToken(r.Context(), rTokenString)
-	if err != nil || !isRefreshTokenValid(refreshToken) {
-		w.WriteHeader(http.StatusUnauthorized)
+
+	tokenHash := cfg.hashRefreshToken(rTokenString)
+	reused := false
+	var accessToken, nextRefreshToken string
+	var revoked []database.RevokeUserAccessTokensRow
+	err = cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		refreshToken, err := q.LockRefreshToken(r.Context(), tokenHash)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+
+		if refreshToken.RotatedAt.Valid {
+			// Committed rather than rolled back, so the family stays
+			// revoked and the event is kept.
+			reused = true
+			if err := q.RevokeRefreshTokenFamily(r.Context(), refreshToken.FamilyID); err != nil {
+				return err
+			}
+			revoked, err = q.RevokeUserAccessTokens(r.Context(), database.RevokeUserAccessTokensParams{
+				UserID:    refreshToken.UserID,
+				SessionID: uuid.NullUUID{UUID: refreshToken.FamilyID, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+			_, err = q.CreateSecurityEvent(r.Context(), database.CreateSecurityEventParams{
+				UserID:    refreshToken.UserID,
+				Kind:      securityEventRefreshTokenReuse,
+				FamilyID:  uuid.NullUUID{UUID: refreshToken.FamilyID, Valid: true},
+				IpAddress: clientIP(r),
+				UserAgent: userAgent(r),
+			})
+			return err
+		}
+		if !isRefreshTokenValid(refreshToken) {
+			return errInvalidRefreshToken
+		}
+		user, err := q.GetUserFromRefreshToken(r.Context(), tokenHash)
+		if err != nil {
+			return err
+		}
+		if !user.ID.Valid || user.SuspendedAt.Valid {
+			return errInvalidRefreshToken
+		}
+
+		if err := q.RotateRefreshToken(r.Context(), tokenHash); err != nil {
+			return err
+		}
+		// The next token keeps the family's expiry, so refreshing doesn't
+		// extend a session past 60 days from login.
+		nextRefreshToken, err = cfg.createRefreshToken(r.Context(), q, database.CreateRefreshTokenParams{
+			UserID:           user.ID.UUID,
+			ExpiresAt:        refreshToken.ExpiresAt,
+			FamilyID:         refreshToken.FamilyID,
+			DeviceName:       refreshToken.DeviceName,
+			UserAgent:        userAgent(r),
+			IpAddress:        clientIP(r),
+			SessionCreatedAt: refreshToken.SessionCreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		accessToken, err = cfg.issueAccessToken(r.Context(), q, user.ID.UUID, refreshToken.FamilyID)
+		return err
+	})
+	if reused && err == nil {
+		cfg.denylist.add(revoked)
+		respondWithError(w, http.StatusUnauthorized, "Refresh token was already used; log in again", errRefreshTokenReused)
 		return
 	}
-
-	user, err := cfg.db.GetUserFromRefreshToken(r.Context(), rTokenString)
-	if err != nil {
+	if errors.Is(err, errInvalidRefreshToken) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
-	jwtTokenString, err := auth.MakeJWT(user.ID.UUID, cfg.secret, 1*time.Hour)
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't refresh token", err)
 		return
 	}
 
 	type RespBody struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
-	respBody := RespBody{Token: jwtTokenString}
+	respBody := RespBody{Token: accessToken, RefreshToken: nextRefreshToken}
 
 	respondWithJSON(w, http.StatusOK, respBody)
 }
 
+// clientIP is the address the request came from, without its port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// userAgent is the request's User-Agent, cut short so clients can't have
+// an arbitrarily long one stored.
+func userAgent(r *http.Request) string {
+	ua := r.UserAgent()
+	if len(ua) > maxUserAgentLength {
+		ua = strings.ToValidUTF8(ua[:maxUserAgentLength], "")
+	}
+	return ua
+}
+
+func (cfg *apiConfig) hashRefreshToken(token string) string {
+	return auth.HashRefreshToken(token, cfg.refreshTokenKey)
+}
+
 func isExpired(expiresAt time.Time) bool {
 
 	return expiresAt.Compare(time.Now()) == -1
@@ -185,6 +355,8 @@ func isRefreshTokenValid(refreshToken database.RefreshToken) bool {
 	return !(isExpired(refreshToken.ExpiresAt) || refreshToken.RevokedAt.Valid)
 }
 
+// handlerRevoke logs a session out by revoking the refresh token and the
+// access tokens issued under its family.
 func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 	rTokenString, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -192,7 +364,22 @@ func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = cfg.db.Revoke(r.Context(), rTokenString)
+	tokenHash := cfg.hashRefreshToken(rTokenString)
+	var revoked []database.RevokeUserAccessTokensRow
+	err = cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		refreshToken, err := q.LockRefreshToken(r.Context(), tokenHash)
+		if err != nil {
+			return err
+		}
+		if err := q.Revoke(r.Context(), tokenHash); err != nil {
+			return err
+		}
+		revoked, err = q.RevokeUserAccessTokens(r.Context(), database.RevokeUserAccessTokensParams{
+			UserID:    refreshToken.UserID,
+			SessionID: uuid.NullUUID{UUID: refreshToken.FamilyID, Valid: true},
+		})
+		return err
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			// To prevent token probing
@@ -202,58 +389,105 @@ func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	cfg.denylist.add(revoked)
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handlerUpdateUser changes the fields present in the request and leaves
+// the rest alone. Sending an empty handle removes it. Changing the password
+// logs the user out of every other session.
 func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email       string  `json:"email"`
+		Password    string  `json:"password"`
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
-		return
-	}
-	userID, err := auth.ValidateJWT(token, cfg.secret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+	userID, sessionID, ok := cfg.requireSession(w, r)
+	if !ok {
 		return
 	}
 
 	params := parameters{}
 	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&params)
+	err := decoder.Decode(&params)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "hashing password failed", err)
+	current, err := cfg.db.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	} else if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error fetching user", err)
 		return
 	}
-
-	user, err := cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
-		HashedPassword: hashedPassword,
-		Email:          params.Email,
+	update := database.UpdateUserParams{
+		HashedPassword: current.HashedPassword,
+		Email:          current.Email,
+		Handle:         current.Handle,
+		DisplayName:    current.DisplayName,
+		Bio:            current.Bio,
 		ID:             userID,
+	}
+	if params.Email != "" {
+		update.Email = params.Email
+	}
+	if params.Handle != nil {
+		update.Handle = nullHandle(*params.Handle)
+	}
+	if params.DisplayName != nil {
+		update.DisplayName = *params.DisplayName
+	}
+	if params.Bio != nil {
+		update.Bio = *params.Bio
+	}
+	// Only validate a handle the user is setting, so accounts whose handle
+	// became reserved later can still edit the rest of their profile.
+	newHandle := ""
+	if params.Handle != nil {
+		newHandle = *params.Handle
+	}
+	if err := validateProfile(newHandle, update.DisplayName, update.Bio); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if params.Password != "" {
+		update.HashedPassword, err = auth.HashPassword(params.Password)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "hashing password failed", err)
+			return
+		}
+	}
+
+	var user database.User
+	var revoked []database.RevokeUserAccessTokensRow
+	err = cfg.db.ExecTx(r.Context(), func(q database.Querier) error {
+		var err error
+		user, err = q.UpdateUser(r.Context(), update)
+		if err != nil || params.Password == "" {
+			return err
+		}
+		revoked, err = logOutElsewhere(r.Context(), q, userID, sessionID)
+		return err
 	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Email or handle is already taken", err)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "error updating user", err)
 		return
 	}
+	cfg.denylist.add(revoked)
 
-	respondWithJSON(w, http.StatusOK, User{
-		ID:          userID,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-		Email:       user.Email,
-		IsChirpyRed: user.IsChirpyRed,
-	})
+	respondWithJSON(w, http.StatusOK, userFromModel(user))
 
 }
 
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"chirpy/internal/auth"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type refreshResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func TestRefresh_RotatesTokens(t *testing.T) {
+	srv := newTestServer(t)
+	alice := signUp(t, srv, "alice@example.com")
+
+	var first refreshResponse
+	if code := doJSON(t, srv, "POST", "/api/refresh", alice.RefreshToken, nil, &first); code != http.StatusOK {
+		t.Fatalf("refresh: got status %d", code)
+	}
+	if first.RefreshToken == "" || first.RefreshToken == alice.RefreshToken {
+		t.Fatalf("refresh didn't rotate the refresh token: %+v", first)
+	}
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", first.Token, nil, nil); code != http.StatusOK {
+		t.Errorf("new access token rejected: got status %d", code)
+	}
+
+	var second refreshResponse
+	if code := doJSON(t, srv, "POST", "/api/refresh", first.RefreshToken, nil, &second); code != http.StatusOK {
+		t.Fatalf("refresh with the rotated token: got status %d", code)
+	}
+
+	// A revoked token is just rejected; it was never rotated.
+	var other loginResponse
+	doJSON(t, srv, "POST", "/api/login", "", map[string]string{"email": "alice@example.com", "password": "hunter2"}, &other)
+	doJSON(t, srv, "POST", "/api/revoke", other.RefreshToken, nil, nil)
+	if code := doJSON(t, srv, "POST", "/api/refresh", other.RefreshToken, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("refresh with a revoked token: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", other.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("access token of a revoked session: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", second.Token, nil, nil); code != http.StatusOK {
+		t.Errorf("access token of another session: got status %d", code)
+	}
+}
+
+func TestRefresh_ReuseRevokesFamily(t *testing.T) {
+	var cfg *apiConfig
+	srv := newTestServerWithConfig(t, func(c *apiConfig) { cfg = c })
+	alice := signUp(t, srv, "alice@example.com")
+	var other loginResponse
+	doJSON(t, srv, "POST", "/api/login", "", map[string]string{"email": "alice@example.com", "password": "hunter2"}, &other)
+
+	var next refreshResponse
+	doJSON(t, srv, "POST", "/api/refresh", alice.RefreshToken, nil, &next)
+
+	// The old token turning up again means someone copied it.
+	if code := doJSON(t, srv, "POST", "/api/refresh", alice.RefreshToken, nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("reusing a rotated token: got status %d", code)
+	}
+	if code := doJSON(t, srv, "POST", "/api/refresh", next.RefreshToken, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("token issued from the reused one still works: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", next.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("access token issued from the reused one still works: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", other.Token, nil, nil); code != http.StatusOK {
+		t.Errorf("a separate login's access token was revoked: got status %d", code)
+	}
+	if code := doJSON(t, srv, "POST", "/api/refresh", other.RefreshToken, nil, nil); code != http.StatusOK {
+		t.Errorf("a separate login was revoked: got status %d", code)
+	}
+
+	events, err := cfg.db.ListSecurityEvents(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Kind != securityEventRefreshTokenReuse || !events[0].FamilyID.Valid {
+		t.Fatalf("security events: %+v", events)
+	}
+	if events[0].IpAddress == "" {
+		t.Errorf("event is missing the client address: %+v", events[0])
+	}
+}
+
+func TestRefreshTokens_StoredHashed(t *testing.T) {
+	var cfg *apiConfig
+	srv := newTestServerWithConfig(t, func(c *apiConfig) { cfg = c })
+	alice := signUp(t, srv, "alice@example.com")
+
+	ctx := context.Background()
+	if _, err := cfg.db.GetRefreshToken(ctx, alice.RefreshToken); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("refresh token found by its plaintext: %v", err)
+	}
+	stored, err := cfg.db.GetRefreshToken(ctx, auth.HashRefreshToken(alice.RefreshToken, cfg.refreshTokenKey))
+	if err != nil {
+		t.Fatalf("refresh token not found by its hash: %v", err)
+	}
+	if stored.Token.Valid {
+		t.Errorf("plaintext stored alongside the hash: %+v", stored)
+	}
+
+	// Revoking looks the token up by its hash too.
+	doJSON(t, srv, "POST", "/api/revoke", alice.RefreshToken, nil, nil)
+	if code := doJSON(t, srv, "POST", "/api/refresh", alice.RefreshToken, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("refresh after revoke: got status %d", code)
+	}
+}
+
+func TestUpdatePassword_RevokesOtherSessions(t *testing.T) {
+	var cfg *apiConfig
+	srv := newTestServerWithConfig(t, func(c *apiConfig) { cfg = c })
+	alice := signUp(t, srv, "alice@example.com")
+	var other loginResponse
+	doJSON(t, srv, "POST", "/api/login", "", map[string]string{"email": "alice@example.com", "password": "hunter2"}, &other)
+
+	if code := doJSON(t, srv, "PUT", "/api/users", alice.Token, map[string]string{"bio": "hi"}, nil); code != http.StatusOK {
+		t.Fatalf("update bio: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", other.Token, nil, nil); code != http.StatusOK {
+		t.Errorf("other session rejected after a profile edit: got status %d", code)
+	}
+
+	// A server that hasn't reloaded yet still accepts the other session's
+	// token.
+	elsewhere := newTokenDenylist(cfg.db)
+	claims, err := cfg.keys.ParseJWT(other.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code := doJSON(t, srv, "PUT", "/api/users", alice.Token, map[string]string{"password": "correct horse"}, nil); code != http.StatusOK {
+		t.Fatalf("change password: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", other.Token, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("other session's access token after a password change: got status %d", code)
+	}
+	if code := doJSON(t, srv, "POST", "/api/refresh", other.RefreshToken, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("other session's refresh token after a password change: got status %d", code)
+	}
+	if code := doJSON(t, srv, "GET", "/api/bookmarks", alice.Token, nil, nil); code != http.StatusOK {
+		t.Errorf("the session that changed the password was logged out: got status %d", code)
+	}
+
+	if elsewhere.Revoked(claims) {
+		t.Fatal("revocation seen before reloading")
+	}
+	if err := elsewhere.reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !elsewhere.Revoked(claims) {
+		t.Error("revocation not seen after reloading")
+	}
+}